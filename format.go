@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/metruzanca/bj/internal/locales"
+	"github.com/metruzanca/bj/internal/tracker"
+)
+
+// Named --format values, anything else is a template
+const (
+	formatTable = "table" // the --list table (the default)
+	formatWide  = "wide"  // the --list table with MEM and CPU columns
+	formatCSV   = "csv"   // comma separated values with a header row
+	formatTSV   = "tsv"   // tab separated values with a header row
+	formatJSONL = "jsonl" // one JSON object per job per line
+)
+
+var namedFormats = []string{formatTable, formatWide, formatCSV, formatTSV, formatJSONL}
+
+// delimitedHeader names the columns of the csv and tsv formats
+var delimitedHeader = []string{"id", "name", "status", "exit_code", "start_time", "end_time", "duration_secs", "pwd", "command"}
+
+// parseFormat checks a --format value, returning the template to run for each
+// job, or nil for the named formats. \t and \n in a template stand for a tab
+// and a newline, since they're awkward to type in a shell.
+func parseFormat(format string) (*template.Template, error) {
+	if slices.Contains(namedFormats, format) {
+		return nil, nil
+	}
+	text := strings.NewReplacer(`\t`, "\t", `\n`, "\n").Replace(format)
+	return template.New("format").Funcs(formatFuncs(nil)).Parse(text)
+}
+
+// formatFuncs are the helpers a --format template can call on top of the
+// tracker.Job fields. jobs lets status work out queue positions.
+func formatFuncs(jobs []tracker.Job) template.FuncMap {
+	return template.FuncMap{
+		// status is the job's state as --list shows it, like done or exit(1)
+		"status": func(job tracker.Job) string {
+			return jobStatus(jobs, job)
+		},
+		// duration is how long the job has run, to the second
+		"duration": func(job tracker.Job) string {
+			return job.Duration().Round(time.Second).String()
+		},
+		// ago is how long ago a time was, like "5 mins ago"
+		"ago": relativeTime,
+		// truncate shortens a command the way the --list table does
+		"truncate": truncateCommand,
+		// json encodes any value, like a job's env
+		"json": func(v interface{}) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+	}
+}
+
+// tableFormat reports whether --format asks for the --list table
+func tableFormat() bool {
+	return formatFlag == "" || formatFlag == formatTable || formatFlag == formatWide
+}
+
+// printFormatted writes jobs to stdout in the --format asked for
+func printFormatted(jobs []tracker.Job) {
+	if err := printJobs(os.Stdout, jobs, nil); err != nil {
+		exitWithError(locales.Msg("err.format_failed", err))
+	}
+}
+
+// printJobs writes jobs to w in the --format asked for, the --list table by
+// default. Jobs in highlight are picked out if it's a table.
+func printJobs(w io.Writer, jobs []tracker.Job, highlight map[int]bool) error {
+	return printJobsAmong(w, jobs, jobs, highlight)
+}
+
+// printJobsAmong is printJobs for some of all's jobs, so statuses like
+// "queued #2" still count the jobs that aren't printed
+func printJobsAmong(w io.Writer, jobs, all []tracker.Job, highlight map[int]bool) error {
+	switch formatFlag {
+	case "", formatTable, formatWide:
+		// --format wide turns on --wide when it's parsed
+		printJobTable(w, jobs, highlight)
+		return nil
+	case formatCSV:
+		out := csv.NewWriter(w)
+		out.Write(delimitedHeader)
+		for _, job := range jobs {
+			out.Write(delimitedRow(all, job))
+		}
+		out.Flush()
+		return out.Error()
+	case formatTSV:
+		// Tabs and newlines in values would break the columns
+		clean := strings.NewReplacer("\t", " ", "\n", " ", "\r", " ")
+		fmt.Fprintln(w, strings.Join(delimitedHeader, "\t"))
+		for _, job := range jobs {
+			row := delimitedRow(all, job)
+			for i := range row {
+				row[i] = clean.Replace(row[i])
+			}
+			fmt.Fprintln(w, strings.Join(row, "\t"))
+		}
+		return nil
+	case formatJSONL:
+		for _, job := range jobs {
+			data, err := json.Marshal(redactEnv(job))
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(w, string(data))
+		}
+		return nil
+	}
+
+	tmpl := formatTemplate.Funcs(formatFuncs(all))
+	for _, job := range jobs {
+		if err := tmpl.Execute(w, redactEnv(job)); err != nil {
+			return err
+		}
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
+// delimitedRow is a job's row in the csv and tsv formats, matching
+// delimitedHeader. Times are RFC 3339 and unfinished jobs leave the exit code
+// and end time empty.
+func delimitedRow(jobs []tracker.Job, job tracker.Job) []string {
+	var exitCode, endTime string
+	if job.ExitCode != nil {
+		exitCode = strconv.Itoa(*job.ExitCode)
+	}
+	if job.EndTime != nil {
+		endTime = job.EndTime.Format(time.RFC3339)
+	}
+	return []string{
+		strconv.Itoa(job.ID),
+		job.Name,
+		jobStatus(jobs, job),
+		exitCode,
+		job.StartTime.Format(time.RFC3339),
+		endTime,
+		strconv.FormatFloat(job.Duration().Seconds(), 'f', 3, 64),
+		job.PWD,
+		job.Command,
+	}
+}
@@ -1,8 +1,12 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
 )
@@ -11,13 +15,44 @@ const (
 	DefaultLogDir         = "logs"
 	DefaultViewer         = "less"
 	DefaultAutoPruneHours = 24 // auto-prune done jobs older than 24hrs (0 = disabled)
+	DefaultMaxParallel    = 1  // jobs a queue runs at once when it isn't configured
+	DefaultMaxLogFiles    = 5  // rotated segments kept per log when max_log_size is set
+	DefaultRestartDelay   = 5  // seconds a --restart job waits before running again
+
+	DefaultCompressMinSize = 64 << 10 // logs smaller than this aren't worth compressing
 )
 
+// DefaultEnvAllowlist is what jobs record of their environment when no
+// env_allowlist is set: enough to run the same way, and nothing secret
+var DefaultEnvAllowlist = []string{"PATH", "HOME", "USER", "SHELL", "LANG", "LC_*", "TERM", "TZ"}
+
 type Config struct {
 	LogDir         string `toml:"log_dir"`
 	Viewer         string `toml:"viewer"`
 	AutoPruneHours int    `toml:"auto_prune_hours"` // auto-clear done jobs older than N hours (0 = disabled)
 	NSFW           bool   `toml:"nsfw"`             // enable explicit mode for raunchier messages
+	LogTimestamps  bool   `toml:"log_timestamps"`   // prefix every output line with the time it was written
+	MaxLogSize     string `toml:"max_log_size"`     // rotate a job's logs once they reach this size, e.g. "50M" ("" = never)
+	MaxLogFiles    int    `toml:"max_log_files"`    // rotated segments kept per log (0 = DefaultMaxLogFiles)
+	RestartDelay   int    `toml:"restart_delay"`    // seconds a --restart job waits before running again
+
+	EnvAllowlist []string `toml:"env_allowlist,omitempty"` // variables recorded on jobs, as shell patterns (empty = DefaultEnvAllowlist)
+
+	Nice         int    `toml:"nice"`           // niceness every job runs at (0 = unchanged)
+	IONice       string `toml:"ionice"`         // I/O scheduling class every job runs in ("" = unchanged)
+	MaxMemory    string `toml:"max_memory"`     // address space limit for every job, e.g. "4G" ("" = none)
+	MaxCPUTime   string `toml:"max_cpu_time"`   // CPU time limit for every run, e.g. "30m" ("" = none)
+	MaxOpenFiles int    `toml:"max_open_files"` // open file limit for every job (0 = none)
+
+	CompressLogs    bool   `toml:"compress_logs"`     // gzip finished jobs' logs
+	CompressMinSize string `toml:"compress_min_size"` // only compress logs at least this big ("" = DefaultCompressMinSize)
+
+	Queues map[string]Queue `toml:"queues,omitempty"` // named queues for --queue, keyed by name
+}
+
+// Queue limits how many jobs from the same --queue run at once
+type Queue struct {
+	MaxParallel int `toml:"max_parallel"`
 }
 
 // ConfigDir returns the bj config directory path
@@ -39,6 +74,7 @@ func DefaultConfig() Config {
 		LogDir:         DefaultLogDir,
 		Viewer:         DefaultViewer,
 		AutoPruneHours: DefaultAutoPruneHours,
+		RestartDelay:   DefaultRestartDelay,
 	}
 }
 
@@ -67,7 +103,8 @@ func Load() (*Config, error) {
 
 	// Load existing config
 	var cfg Config
-	if _, err := toml.DecodeFile(configPath, &cfg); err != nil {
+	md, err := toml.DecodeFile(configPath, &cfg)
+	if err != nil {
 		return nil, err
 	}
 
@@ -78,6 +115,10 @@ func Load() (*Config, error) {
 	if cfg.Viewer == "" {
 		cfg.Viewer = DefaultViewer
 	}
+	// 0 is a valid restart delay, so only a missing key gets the default
+	if !md.IsDefined("restart_delay") {
+		cfg.RestartDelay = DefaultRestartDelay
+	}
 
 	return &cfg, nil
 }
@@ -100,6 +141,93 @@ func Save(cfg *Config) error {
 	return toml.NewEncoder(f).Encode(cfg)
 }
 
+// QueueLimit returns how many jobs the named queue may run at once
+// Queues without a [queues.NAME] section run one job at a time
+func (c *Config) QueueLimit(name string) int {
+	if q, ok := c.Queues[name]; ok && q.MaxParallel > 0 {
+		return q.MaxParallel
+	}
+	return DefaultMaxParallel
+}
+
+// EnvPatterns returns the shell patterns of the environment variables jobs record
+func (c *Config) EnvPatterns() []string {
+	if len(c.EnvAllowlist) > 0 {
+		return c.EnvAllowlist
+	}
+	return DefaultEnvAllowlist
+}
+
+// LogSizeLimit returns the size in bytes at which job logs are rotated (0 = never)
+func (c *Config) LogSizeLimit() (int64, error) {
+	if c.MaxLogSize == "" {
+		return 0, nil
+	}
+	return ParseSize(c.MaxLogSize)
+}
+
+// LogFilesLimit returns how many rotated segments are kept per log
+func (c *Config) LogFilesLimit() int {
+	if c.MaxLogFiles > 0 {
+		return c.MaxLogFiles
+	}
+	return DefaultMaxLogFiles
+}
+
+// MemoryLimit returns the address space limit in bytes jobs run with (0 = none)
+func (c *Config) MemoryLimit() (int64, error) {
+	if c.MaxMemory == "" {
+		return 0, nil
+	}
+	return ParseSize(c.MaxMemory)
+}
+
+// CPUTimeLimit returns the CPU time each run of a job may use (0 = no limit)
+func (c *Config) CPUTimeLimit() (time.Duration, error) {
+	if c.MaxCPUTime == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(c.MaxCPUTime)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid duration %q", c.MaxCPUTime)
+	}
+	return d, nil
+}
+
+// CompressThreshold returns the size in bytes from which finished jobs' logs
+// are compressed (0 = compression is off)
+func (c *Config) CompressThreshold() (int64, error) {
+	if !c.CompressLogs {
+		return 0, nil
+	}
+	if c.CompressMinSize == "" {
+		return DefaultCompressMinSize, nil
+	}
+	return ParseSize(c.CompressMinSize)
+}
+
+// ParseSize parses a size in bytes with an optional K, M or G suffix (powers
+// of 1024, optionally followed by B or iB), such as "500K" or "50MB"
+func ParseSize(s string) (int64, error) {
+	num := strings.ToUpper(strings.TrimSpace(s))
+	num = strings.TrimSuffix(strings.TrimSuffix(num, "B"), "I")
+
+	multiplier := int64(1)
+	for suffix, m := range map[string]int64{"K": 1 << 10, "M": 1 << 20, "G": 1 << 30} {
+		if strings.HasSuffix(num, suffix) {
+			num = strings.TrimSuffix(num, suffix)
+			multiplier = m
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(strings.TrimSpace(num), 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return n * multiplier, nil
+}
+
 // LogDir returns the absolute path to the log directory
 func (c *Config) LogDirPath() (string, error) {
 	configDir, err := ConfigDir()
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseSize(t *testing.T) {
+	valid := map[string]int64{
+		"100":     100,
+		"500K":    500 << 10,
+		"500kb":   500 << 10,
+		"50M":     50 << 20,
+		"50MiB":   50 << 20,
+		" 2G ":    2 << 30,
+		"1 G":     1 << 30,
+		"4096B":   4096,
+		"1048576": 1 << 20,
+	}
+	for in, want := range valid {
+		got, err := ParseSize(in)
+		if err != nil || got != want {
+			t.Errorf("ParseSize(%q) = %d, %v, want %d", in, got, err, want)
+		}
+	}
+
+	for _, in := range []string{"", "0", "-5M", "M", "10T", "1.5G", "ten"} {
+		if got, err := ParseSize(in); err == nil {
+			t.Errorf("ParseSize(%q) = %d, want an error", in, got)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("BJ_CONFIG_DIR", dir)
+
+	content := `max_log_size = "10M"
+compress_logs = true
+env_allowlist = ["PATH", "GO*"]
+max_cpu_time = "90s"
+
+[queues.builds]
+max_parallel = 3
+`
+	if err := os.WriteFile(filepath.Join(dir, "bj.toml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if size, err := cfg.LogSizeLimit(); err != nil || size != 10<<20 {
+		t.Errorf("LogSizeLimit = %d, %v, want 10M", size, err)
+	}
+	if n := cfg.LogFilesLimit(); n != DefaultMaxLogFiles {
+		t.Errorf("LogFilesLimit = %d, want the default %d", n, DefaultMaxLogFiles)
+	}
+	if size, err := cfg.CompressThreshold(); err != nil || size != DefaultCompressMinSize {
+		t.Errorf("CompressThreshold = %d, %v, want the default", size, err)
+	}
+	if d, err := cfg.CPUTimeLimit(); err != nil || d != 90*time.Second {
+		t.Errorf("CPUTimeLimit = %s, %v, want 90s", d, err)
+	}
+	if got := cfg.EnvPatterns(); !slices.Equal(got, []string{"PATH", "GO*"}) {
+		t.Errorf("EnvPatterns = %v, want the configured allowlist", got)
+	}
+	if n := cfg.QueueLimit("builds"); n != 3 {
+		t.Errorf("QueueLimit(builds) = %d, want 3", n)
+	}
+	if n := cfg.QueueLimit("other"); n != DefaultMaxParallel {
+		t.Errorf("QueueLimit(other) = %d, want the default %d", n, DefaultMaxParallel)
+	}
+	if cfg.RestartDelay != DefaultRestartDelay {
+		t.Errorf("RestartDelay = %d, want the default %d when unset", cfg.RestartDelay, DefaultRestartDelay)
+	}
+
+	// 0 is a real restart delay, not a missing one
+	os.WriteFile(filepath.Join(dir, "bj.toml"), []byte("restart_delay = 0\n"), 0644)
+	if cfg, err := Load(); err != nil || cfg.RestartDelay != 0 {
+		t.Errorf("Load with restart_delay = 0 gave %+v, %v", cfg, err)
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	cfg := DefaultConfig()
+
+	if size, err := cfg.LogSizeLimit(); err != nil || size != 0 {
+		t.Errorf("LogSizeLimit = %d, %v, want no rotation", size, err)
+	}
+	if size, err := cfg.CompressThreshold(); err != nil || size != 0 {
+		t.Errorf("CompressThreshold = %d, %v, want compression off", size, err)
+	}
+	if size, err := cfg.MemoryLimit(); err != nil || size != 0 {
+		t.Errorf("MemoryLimit = %d, %v, want no limit", size, err)
+	}
+	if got := cfg.EnvPatterns(); !slices.Equal(got, DefaultEnvAllowlist) {
+		t.Errorf("EnvPatterns = %v, want DefaultEnvAllowlist", got)
+	}
+
+	cfg.MaxCPUTime = "-1s"
+	if _, err := cfg.CPUTimeLimit(); err == nil {
+		t.Error("CPUTimeLimit accepted a negative duration")
+	}
+}
@@ -4,7 +4,7 @@ package locales
 var NSFW = Messages{
 	// Error messages
 	"err.id_only_with_retry":     "--id only makes sense with --retry. They go together like a hand and... well.",
-	"err.delay_only_with_retry":  "--delay without --retry? Even bj needs foreplay.",
+	"err.delay_only_with_retry":  "--delay without --retry or --restart? Even bj needs foreplay.",
 	"err.config_load":            "bj couldn't get in position: %v",
 	"err.tracker_init":           "bj lost its grip: %v",
 	"err.completion_usage":       "Usage: bj --completion <fish|zsh>",
@@ -21,10 +21,12 @@ var NSFW = Messages{
 	"err.gc_failed":              "bj had trouble wiping down: %v",
 	"err.kill_check_failed":      "bj can't check who's still going at it: %v",
 	"err.kill_failed":            "bj couldn't pull out in time: %v",
+	"err.kill_all_with_ref":      "--kill --all pulls out of everyone, so it doesn't take a job too ('%s')",
+	"err.failed_deps_all":        "--failed-deps picks who --kill --all pulls out of, add --all",
 	"err.retry_pwd_failed":       "bj couldn't find the right hole: %v",
 	"err.retry_history_failed":   "bj can't remember its conquests: %v",
 	"err.retry_find_failed":      "bj can't find that position: %v",
-	"err.job_not_found":          "Job %d? bj never touched that one.",
+	"err.job_not_found":          "Job %s? bj never touched that one.",
 	"err.job_still_running":      "Job %d is still throbbing. Patience.",
 	"err.job_already_succeeded":  "Job %d already came. Once is enough.",
 	"err.retry_start_failed":     "bj couldn't get hard again: %v",
@@ -39,32 +41,204 @@ var NSFW = Messages{
 	"err.job_id_minimum":         "Job IDs start at 1. '%d' is too small for bj.",
 	"err.delay_needs_value":      "--delay needs a number of seconds. Edging requires precision.",
 	"err.delay_non_negative":     "bj needs a non-negative delay, not '%s'. No going backwards.",
+	"err.name_needs_value":       "--name needs a name. bj likes to know who it's with.",
+	"err.name_numeric":           "'%s' looks like a job ID. bj wants a pet name, not a number.",
+	"err.name_in_use":            "bj is already going at it with a job named '%s'. Pick another name or pull out of that one first.",
+	"err.timeout_needs_value":    "--timeout needs a duration, like 30s or 5m. How long can you last?",
+	"err.invalid_duration":       "bj needs a duration like 30s, 5m or 1h, not '%s'. Stamina is measured in time.",
+	"err.exec_usage":             "Usage: bj --exec <job_id>",
+	"err.exec_failed":            "bj couldn't keep its eyes on the action: %v",
+	"err.after_needs_value":      "--after needs job IDs or names, like 3 or build,test. Who's going first?",
+	"err.await_usage":            "Usage: bj --await <job_id>",
+	"err.queue_needs_value":      "--queue needs a queue name. bj needs to know which line to get in.",
+	"err.signal_needs_value":     "--signal needs a signal, like TERM, INT or 9. bj needs to know how hard to stop.",
+	"err.invalid_signal":         "bj doesn't know the safe word '%s'. Try HUP, INT, QUIT, KILL, USR1, USR2, ALRM or TERM.",
+	"err.grace_needs_value":      "--grace needs a duration, like 5s or 1m. How long until things get rough?",
+	"err.pause_failed":           "bj couldn't catch its breath: %v",
+	"err.follow_json":            "--follow streams the log as plain text and can't be combined with --json. Pick one position.",
+	"err.watch_json":             "--watch keeps redrawing the table and can't be combined with --json. Watch or read, not both.",
+	"err.invalid_interval":       "--watch wants an interval like 2s, 500ms or 5, got '%s'. How often do you want to peek?",
+	"err.format_needs_value":     "--format needs a value: table, wide, csv, tsv, jsonl or a template like '{{.ID}} {{.Command}}'. Dress it how you like.",
+	"err.invalid_format":         "That --format template doesn't fit: %v",
+	"err.format_failed":          "Couldn't dress the jobs up in your --format: %v",
+	"err.format_json":            "--format and --json both pick the outfit. One look at a time.",
+	"err.sort_needs_value":       "--sort needs a field: start, duration, id or status. How do you like them lined up?",
+	"err.invalid_sort":           "--sort doesn't do '%s'. bj lines them up by start, duration, id or status.",
+	"err.max_jobs_needs_value":   "--limit needs a number of jobs. Know your limits.",
+	"err.invalid_max_jobs":       "--limit wants a positive number of jobs, got '%s'",
+	"err.cmd_needs_value":        "--cmd needs some text to look for in the command. What are you into?",
+	"err.exit_code_needs_value":  "--exit-code needs an exit code, like 1. How did it finish?",
+	"err.dir_needs_value":        "--dir needs a directory. Where did it happen?",
+	"err.logs_stream_conflict":   "--stdout and --stderr can't be combined, leave both off to get the whole package",
+	"err.logs_no_stream":         "job %d happened before bj learned to keep its %s separate, view its full log instead",
+	"err.since_needs_value":      "--since needs a time, like 10m or 2026-01-02 15:04. When did it start?",
+	"err.until_needs_value":      "--until needs a time, like 10m or 2026-01-02 15:04. When did it end?",
+	"err.invalid_time":           "bj doesn't know when '%s' is. Try a duration (10m, 2h), a time (15:04) or a date (2026-01-02 15:04)",
+	"err.logs_no_timestamps":     "job %d wasn't started with --timestamps, so bj can't kiss and tell when anything happened",
+	"err.grep_needs_pattern":     "--grep needs a pattern. What are you into?",
+	"err.grep_invalid_pattern":   "bj can't make sense of that pattern: %v",
+	"err.grep_failed":            "bj couldn't dig through the logs: %v",
+	"err.backoff_without_retry":  "--backoff, --max-delay and --jitter set the pace between rounds, so they need --retry or --restart",
+	"err.backoff_needs_value":    "--backoff needs a rhythm: fixed, linear or exponential",
+	"err.invalid_backoff":        "bj doesn't know the '%s' rhythm. Try fixed, linear or exponential.",
+	"err.max_delay_needs_value":  "--max-delay needs a duration, like 30s or 5m. Don't leave bj hanging forever.",
+	"err.attempt_needs_value":    "--attempt needs a number. Which round do you want to relive?",
+	"err.attempt_positive":       "bj needs a positive attempt number, not '%s'. Rounds start at 1.",
+	"err.attempt_conflict":       "--attempt shows part of the combined log, so it can't be combined with --stdout, --stderr or --follow",
+	"err.attempt_not_found":      "Job %d never went a round %d. bj only remembers %d.",
+	"err.attempt_rotated":        "Round %d of job %d has been rotated out of the log. Some things are best forgotten.",
+	"err.invalid_restart":        "bj doesn't know the '%s' policy. Try --restart=always or --restart=on-failure.",
+	"err.limit_without_restart":  "--restart-limit and --restart-window only make sense with --restart",
+	"err.window_needs_limit":     "--restart-window needs a --restart-limit. How many times is too many?",
+	"err.limit_needs_value":      "--restart-limit needs a number. Everyone has their limits.",
+	"err.limit_positive":         "--restart-limit wants a positive number, got '%s'",
+	"err.window_needs_value":     "--restart-window needs a duration, like 1m or 1h",
+	"err.ok_codes_needs_value":   "--ok-codes needs exit codes, like 0,1",
+	"err.invalid_ok_code":        "bj only takes exit codes from 0 to 255, not '%s'",
+	"err.fail_if_needs_value":    "--fail-if-output needs a pattern to look for",
+	"err.env_needs_value":        "--env needs a variable, like KEY=VALUE",
+	"err.invalid_env":            "bj needs --env as KEY=VALUE, not '%s'",
+	"err.env_file_needs_value":   "--env-file needs a file, like .env",
+	"err.env_file_failed":        "bj couldn't get into the env file %s: %v",
+	"err.cwd_needs_value":        "--cwd needs a directory to run in",
+	"err.invalid_cwd":            "bj can't get into '%s', it isn't a directory",
+	"err.here_failed":            "bj couldn't figure out where you are: %v",
+	"err.nice_needs_value":       "--nice needs a niceness, from -20 to 19",
+	"err.invalid_nice":           "bj needs a niceness from -20 (greedy) to 19 (polite), not '%s'",
+	"err.ionice_needs_value":     "--ionice needs a class: idle, best-effort or realtime",
+	"err.invalid_ionice":         "bj doesn't know the I/O class '%s'. Try idle, best-effort or realtime.",
+	"err.memory_needs_value":     "--max-memory needs a size, like 512M or 2G. Know your limits.",
+	"err.cpu_time_needs_value":   "--max-cpu-time needs a duration, like 30s or 10m",
+	"err.max_files_needs_value":  "--max-open-files needs a number of files",
+	"err.open_files_positive":    "--max-open-files wants a positive number, got '%s'",
+	"err.confine_usage":          "Usage: bj --confine <job_id>",
+	"err.confine_failed":         "bj couldn't tie the job down: %v",
+	"err.show_failed":            "bj can't find that encounter: %v",
+	"err.no_jobs":                "bj hasn't even gotten started yet",
+	"err.lines_needs_value":      "--lines needs a number. How much of the pillow talk do you want?",
+	"err.invalid_lines":          "--lines wants a number of lines (0 for none), got '%s'",
+	"err.tui_no_terminal":        "--tui needs a terminal to take over. Try --list --watch and just look",
+	"err.tui_failed":             "bj lost its grip on the terminal: %v",
+	"err.viewer_failed":          "bj couldn't open the log viewer: %v",
+	"err.log_size_needs_value":   "--max-log-size needs a size, like 500K or 50M. Size matters.",
+	"err.log_files_needs_value":  "--max-log-files needs a number of old log segments to keep",
+	"err.invalid_size":           "bj can't make sense of the size '%s'. Try 500K, 50M or 1G",
+	"err.log_files_positive":     "--max-log-files wants a positive number, got '%s'",
+	"err.wait_failed":            "bj got tired of waiting to finish: %v",
+	"err.resume_failed":          "bj couldn't get back on top: %v",
+	"err.await_failed":           "bj got tired of waiting its turn: %v",
 
 	// Status messages
 	"job.started":            "[%d] bj is going down on: %s",
 	"job.killed":             "[%d] bj pulled out early: %s",
+	"job.cancelled":          "[%d] bj sent it home before it got started: %s",
+	"job.kill_escalated":     "    %s got ignored for %s, so bj had to get rough with SIGKILL",
+	"job.paused":             "[%d] bj is catching its breath: %s",
+	"job.resumed":            "[%d] bj is back on top: %s",
 	"job.retry_unlimited":    "[%d] bj will edge until it explodes: %s",
 	"job.retry_one":          "[%d] bj will give it one good thrust: %s",
 	"job.retry_limited":      "[%d] bj will pound away up to %d times: %s",
 	"job.retry_one_existing": "[%d] bj is going for round two: %s",
+	"job.restarted":          "[%d] bj will keep coming back for more (restarts on failure): %s",
+	"job.restarted_always":   "[%d] bj will keep coming, again and again (restarts whenever it's done): %s",
+	"job.waiting":            "    bj will wait its turn until job(s) %s finish",
+	"job.queued":             "    bj put it in line for '%s' (position %d), it gets its turn when someone finishes",
 
 	// List messages
 	"list.empty":          "bj is all alone. Give it someone to do!",
 	"list.empty_filtered": "No jobs match your kink. bj has nothing to show.",
+	"list.watching":       "Peeking every %s, as of %s (Ctrl-C when you've seen enough)",
 
 	// Kill messages
 	"kill.no_running": "bj isn't inside anything right now. Nothing to pull out of!",
 
+	// Wait messages
+	"wait.nothing":   "bj isn't doing anyone in this directory. Nothing to wait for!",
+	"wait.finished":  "[%d] %s: %s",
+	"wait.summary":   "bj finished off %d job(s): %d satisfied, %d left wanting",
+	"wait.timed_out": "bj couldn't hold out longer than %s, %d job(s) still going at it",
+
+	// Pause messages
+	"pause.no_running": "bj isn't inside anything right now. Nothing to pause!",
+	"resume.no_paused": "bj isn't catching its breath. Nothing to resume!",
+
 	// Retry messages
 	"retry.no_failed": "bj hasn't had any misfires yet. Nothing to retry!",
 
+	// Grep messages
+	"grep.prefix":     "[%d] %s:",
+	"grep.no_matches": "bj felt around everywhere, nothing matches '%s'",
+
 	// Logs messages
 	"logs.no_jobs": "bj hasn't been with anyone yet. Pop its cherry first!",
 
+	// Show messages
+	"show.id":             "ID",
+	"show.name":           "Name",
+	"show.command":        "Command",
+	"show.directory":      "Directory",
+	"show.status":         "Status",
+	"show.exit_code":      "Exit code",
+	"show.pid":            "PID",
+	"show.started":        "Started",
+	"show.ended":          "Ended",
+	"show.duration":       "Lasted",
+	"show.paused":         "Breather",
+	"show.paused_for":     "Breathers",
+	"show.mode":           "Mode",
+	"show.attempts":       "Attempts",
+	"show.timeout":        "Timeout",
+	"show.after":          "After",
+	"show.after_any":      "After any",
+	"show.queue":          "Queue",
+	"show.ok_codes":       "OK codes",
+	"show.fail_if":        "Fail if",
+	"show.env":            "Env",
+	"show.log":            "Log",
+	"show.stdout":         "Stdout",
+	"show.stderr":         "Stderr",
+	"show.rotation":       "Rotation",
+	"show.timestamps":     "Timestamps",
+	"show.limits":         "Limits",
+	"show.peak_rss":       "Peak RSS",
+	"show.cpu_time":       "CPU time",
+	"show.wall_time":      "Wall time",
+	"show.alive":          "still going",
+	"show.gone":           "spent",
+	"show.process_group":  "%s, process group %d",
+	"show.env_count":      "%d variables (see --json)",
+	"show.rotation_value": "at %s, keeping %d files",
+	"show.yes":            "yes",
+	"show.no_limits":      "none",
+	"show.cpu_value":      "%s user, %s sys",
+	"show.tail":           "The last %d lines of pillow talk:",
+
+	// Exit code meanings
+	"exit.timeout":        "couldn't finish within %s",
+	"exit.skipped":        "skipped, a dependency couldn't perform",
+	"exit.cancelled":      "cancelled while waiting its turn, it never got any",
+	"exit.crashloop":      "crash loop, bj stopped going back for more",
+	"exit.bad_output":     "output matched --fail-if-output",
+	"exit.killed_with":    "killed with %s",
+	"exit.success":        "success",
+	"exit.ok_code":        "success, one of its --ok-codes",
+	"exit.lost":           "finished without telling bj, found by --gc",
+	"exit.not_executable": "command not executable",
+	"exit.not_found":      "command not found",
+	"exit.killed_by":      "killed by %s",
+	"exit.failure":        "failure",
+
 	// Prune messages
 	"prune.nothing": "Nothing to wipe down. bj keeps it clean.",
 	"prune.success": "Cleaned up %d spent job(s). Ready for another round.",
 
+	// TUI messages
+	"tui.title":         "bj - %d job(s), %d still going at it",
+	"tui.keys":          "↑/↓ select  enter view log  x kill  r retry  p pause/resume  P prune  q quit",
+	"tui.confirm_kill":  "Pull out of job #%d (%s)? y/n",
+	"tui.confirm_prune": "Wipe down %d spent job(s)? y/n",
+	"tui.killing":       "Pulling out of job #%d...",
+
 	// GC messages
 	"gc.nothing": "No ghosted jobs found. bj always finishes what it starts.",
 	"gc.success": "Found %d job(s) that came and went without telling bj. Marked as finished.",
@@ -78,8 +252,13 @@ Usage:
   bj <command>              Slip something in the background
   bj --retry[=N] <command>  Keep pounding until success (or N attempts)
   bj --list                 See who bj is doing
-  bj --logs [id]            Watch bj's performance
-  bj --kill [id]            Pull out mid-thrust
+  bj --logs [id|name]       Watch bj's performance
+  bj --show [id|name]       Get intimate with one job's details
+  bj --tui                  Take control of every job full-screen
+  bj --grep PATTERN         Dig through everyone's logs
+  bj --kill [id|name]       Pull out mid-thrust
+  bj --pause [id|name]      Stop to catch a breath (resume with --resume)
+  bj --wait [id|name...]    Wait for jobs to finish (exits non-zero if any failed)
   bj --retry[=N] [--id ID]  Try again with a failed conquest
   bj --prune                Clean up the mess when bj is done
   bj --gc                   Find jobs that finished without telling bj
@@ -91,15 +270,44 @@ Shell Integration:
 
 Options:
   --retry[=N]         Keep going until climax (or limit to N attempts)
-  --id ID             Specify job ID for --retry (defaults to most recent)
+  --restart[=always]  Come back for more after failing (or after every finish)
+  --restart-limit N   Give up after N restarts within --restart-window (default 1m)
+  --id ID             Specify job ID or name for --retry (defaults to most recent)
+  --name NAME         Give the job a pet name to call out instead of its ID
+  --timeout DUR       Cut it off if a run lasts longer than DUR (e.g. 30s, 5m)
+  --ok-codes N[,N]    Exit codes that still count as a happy ending (default 0)
+  --fail-if-output RE Call it a flop if a line of its output matches RE
+  --env KEY=VAL       Set the mood with an environment variable (repeatable)
+  --env-file FILE     Bring your own .env file
+  --cwd DIR           Do it somewhere else: run the job in DIR
+  --nice N            Be gentle: run at niceness N (-20 to 19)
+  --ionice CLASS      Disk manners: idle, best-effort or realtime (Linux)
+  --max-memory SIZE   Cap how much memory it can take (e.g. 2G)
+  --max-cpu-time DUR  Cap the CPU time each run can use (e.g. 10m)
+  --max-open-files N  Cap how many files it can open at once
+  --after ID[,ID]     Wait your turn until these jobs finish happy (skip if not)
+  --after-any ID[,ID] Wait your turn until these jobs finish, however it ends
+  --queue NAME        Get in line, only so many at once (see [queues.NAME])
+  --timestamps        Note down exactly when every line came out
+  --max-log-size SIZE Rotate the job's logs once they get this big (e.g. 50M)
+  --max-log-files N   Keep N rotated log segments per log (default 5)
+  --delay S           Rest S seconds between rounds
+  --backoff MODE      Build up the rest after each miss: fixed, linear or exponential
+  --max-delay DUR     Cap how long the rest builds up to (default 5m)
+  --jitter            Keep the rests unpredictable
   --json              Output in JSON format (works with all commands)
   -h, --help          Show this help (use with commands for detailed help)
+  --                  Stop reading options, everything after is the command
 
 Examples:
   bj sleep 10               Let bj handle your bedtime needs
   bj npm install            bj npm while you watch
   bj --retry npm test       Keep testing until satisfaction
   bj --retry=3 make build   Try building up to 3 times
+  bj --name api ./server    Start a job you can moan the name of
+  bj --timeout 10m ./ci.sh  Don't let a script drag on all night
+  bj --after 3 ./deploy.sh  Deploy once job 3 is done with the build
+  bj --queue shards ./p 1   Take the shards a few at a time, not all at once
   bj --list                 Check how bj is performing
   bj --logs                 See bj's latest moves
   bj --kill                 Stop the current action abruptly
@@ -115,77 +323,334 @@ Shell Setup:
 	// Help text - list
 	"help.list": `bj --list - See who bj is doing
 
-Usage: bj --list [--running] [--failed] [--done] [--json]
+Usage: bj --list [--running] [--failed] [--done] [--here] [--since TIME] [--cmd TEXT]
+                [--exit-code N] [--dir PATH] [--sort FIELD] [--reverse] [--limit N]
+                [--wide] [--watch [INTERVAL]] [--format FORMAT] [--json]
 
 Shows all tracked jobs with their status, start time, duration, and command.
 Active jobs are shown throbbing, spent jobs are dimmed, failures show
-the exit code in shameful red.
+the exit code in shameful red (or "timeout" if they couldn't finish in
+time, how they were stopped, like "killed(SIGTERM)", if bj pulled out, or
+"crashloop" if a --restart job couldn't last past its --restart-limit, or
+"bad-output" if it said something matching --fail-if-output). Jobs that
+finished with another of their --ok-codes show "done(N)". Jobs started with
+--after show "waiting" while they wait their turn, and "skipped" if the job
+before them couldn't finish. Jobs lined up in a --queue show "queued #N"
+with their place in line, and "cancelled" if they got sent home before
+their turn. NAME and QUEUE columns appear once any job uses them. Retry and
+restart jobs show how many rounds they've gone in the ATTEMPTS column ("2/3"
+when they only get so many).
+
+--watch lets you keep watching: the table is redrawn in place every INTERVAL
+(2s unless you say otherwise) with live statuses and durations, and jobs that
+just changed position are highlighted. Ctrl-C when you've seen enough. When
+the output isn't a terminal, the whole table is printed again every INTERVAL.
+
+--format dresses the jobs up differently: "table" (the default), "wide" (same
+as --wide), "csv" or "tsv" (a header row, then one row per job, with RFC 3339
+times and the duration in seconds), "jsonl" (one JSON object per line), or a
+Go template run for each job, like '{{.ID}} {{.Command}}'. Templates get at
+every job field (.ID, .Name, .Command, .PWD, .StartTime, .ExitCode, ...) and
+can call {{status .}}, {{duration .}}, {{ago .StartTime}}, {{truncate .Command}}
+and {{json .Env}}. \t and \n stand for a tab and a newline.
 
 Filters:
   --running   Only show jobs bj is still inside
   --failed    Only show the ones that couldn't finish
   --done      Only show successful climaxes
+  --here      Only show jobs started here (or below)
+  --since TIME
+              Only show jobs started at or after TIME (like 2h or 2026-01-02 15:04)
+  --cmd TEXT  Only show jobs whose command contains TEXT
+  --exit-code N
+              Only show jobs that exited with code N (killed jobs have minus
+              the signal number, like -15, and lost or skipped ones -1)
+  --dir PATH  Only show jobs started in PATH (or below it)
+
+The filters, --sort, --reverse and --limit work the same with --ids and
+--prune. --running, --failed and --done add up, the others narrow it down.
+
+Sorting:
+  --sort FIELD
+              Order by start (newest first, the default), duration (longest
+              first), id (lowest first) or status (unfinished, then ruined,
+              then successful)
+  --reverse   Flip the order
+  --limit N   Only show the first N jobs once sorted
 
 Options:
+  --wide      Add MEM (peak memory) and CPU (CPU time used) columns
+  --watch [INTERVAL]
+              Redraw the table every INTERVAL (like 2s, 500ms or 5) until Ctrl-C
+  --format FORMAT
+              Print jobs as table, wide, csv, tsv, jsonl or a Go template
   --json      Output raw job data as JSON
+  --reveal-env
+              Include environment variable values (hidden as *** otherwise)
 
 Examples:
   bj --list           Check who bj is doing
   bj --list --running See what bj is actively pounding
   bj --list --failed  Review the disappointments
+  bj --list --sort duration --limit 5
+                      Find the 5 jobs that lasted longest
+  bj --list --watch   Sit back and watch bj work
+  bj --list --format csv > jobs.csv
+                      Export every job to a spreadsheet
+  bj --list --format '{{.ID}}\t{{status .}}\t{{.Command}}'
+                      Print just the columns you care about
   bj --list --json    Get the raw details for scripting`,
 
+	// Help text - grep
+	"help.grep": `bj --grep - Dig through everyone's logs
+
+Usage: bj --grep PATTERN [--running|--failed|--done] [--json]
+
+Searches the logs of every job bj has been with for lines matching PATTERN
+(a regular expression), oldest first. Each match comes with the ID and
+command of the job that let it out, so you know exactly who said what.
+Rotated and compressed logs are searched too.
+
+Exits with status 1 if nothing matches, like grep.
+
+Options:
+  --running   Only search jobs still going at it
+  --failed    Only search jobs that left unsatisfied
+  --done      Only search jobs that finished happy
+  --json      Output matches as JSON, each with its job's metadata
+  --reveal-env
+              Include environment variable values (hidden as *** otherwise)
+
+Examples:
+  bj --grep 'panic:'              Find out who panicked
+  bj --grep --failed 'error'      Only dig through the disappointments
+  bj --grep '(?i)timeout' --json  Case-insensitive search, as JSON`,
+
 	// Help text - logs
 	"help.logs": `bj --logs - Watch bj's performance
 
-Usage: bj --logs [id] [--json]
+Usage: bj --logs [id|name] [--follow] [--stdout|--stderr] [--attempt N]
+               [--since TIME] [--until TIME] [--json]
 
 View every moan and groan (stdout/stderr) of a job. If no ID is specified,
 shows bj's most recent encounter.
 
+The moans (stdout) and groans (stderr) are also kept apart: --stdout or
+--stderr shows just one, while the default view has both intertwined in the
+order they came out.
+
+Jobs started with --timestamps (or with log_timestamps = true in the
+config) have every line marked with the moment it came out. For those,
+--since and --until show only what happened in that window. Both take a
+duration ago (10m, 2h), a time today (15:04) or a date (2026-01-02 15:04).
+
+With --follow, bj streams it live as it happens instead of opening the
+viewer, stops when the job finishes, and exits with the job's exit code
+(128+N if it was killed by signal N).
+
+Retry and restart jobs remember where each round started and finished, so
+--attempt N replays just the Nth one.
+
 Arguments:
-  id        Job ID to review (optional, defaults to latest)
+  id|name   Job ID or name to review (optional, defaults to latest)
 
 Options:
-  --json    Output job metadata and log content as JSON
+  -f, --follow  Watch it live until the job finishes
+  --stdout      Show only the command's stdout
+  --stderr      Show only the command's stderr
+  --attempt N   Relive only the Nth round of a retry or restart job
+  --since TIME  Only what came out at or after TIME (timestamped jobs)
+  --until TIME  Only what came out at or before TIME (timestamped jobs)
+  --json        Output job metadata and log content as JSON
+  --reveal-env  Include environment variable values (hidden as *** otherwise)
 
 Examples:
-  bj --logs         See bj's latest performance
-  bj --logs 5       Inspect a specific session
-  bj --logs --json  Get logs in JSON format`,
+  bj --logs                   See bj's latest performance
+  bj --logs 5                 Inspect a specific session
+  bj --logs api               Relive your time with "api"
+  bj --logs 5 -f && notify    Watch job #5 and hear about it if it finishes happy
+  bj --logs 5 --stderr        Only hear job #5's complaints
+  bj --logs 5 --attempt 2     Relive job #5's second round
+  bj --logs api --since 3h    See what "api" got up to last night
+  bj --logs --json            Get logs in JSON format`,
 
 	// Help text - prune
 	"help.prune": `bj --prune - Clean up after bj is done
 
-Usage: bj --prune [--json]
+Usage: bj --prune [--failed|--done] [--here] [--since TIME] [--cmd TEXT]
+                 [--exit-code N] [--dir PATH] [--sort FIELD] [--reverse] [--limit N]
+                 [--json]
 
 Wipes away all finished jobs (any exit code) from the job list and deletes
 their log files. Only active jobs are kept. If all jobs are pruned, the
 ID counter resets to 1.
 
+Feeling picky? Choose which finished jobs go with the same filters, --sort,
+--reverse and --limit as --list (see bj --list --help). --limit N wipes away
+the first N finished jobs that match, in --sort order.
+
 Options:
   --json    Output prune count as JSON
 
 Examples:
-  bj --prune        Wipe the sheets clean`,
+  bj --prune        Wipe the sheets clean
+  bj --prune --failed --since 1h
+                    Forget the last hour's disappointments
+  bj --prune --sort start --reverse --limit 20
+                    Kick out the 20 oldest finished jobs`,
 
 	// Help text - kill
 	"help.kill": `bj --kill - Make bj pull out
 
-Usage: bj --kill [id] [--json]
+Usage:
+  bj --kill [id|name] [--signal SIG] [--grace DUR] [--json]
+  bj --kill --all [--queue NAME] [--failed-deps] [--signal SIG] [--grace DUR] [--json]
 
-Terminates a job mid-thrust. Sends SIGTERM to the process group, stopping
-the entire action. If no ID is specified, kills whatever bj is currently inside.
+Terminates a job mid-thrust. Sends SIGTERM (or --signal) to the process group,
+stopping the entire action, and waits for it to finish up. If it won't take
+the hint by the end of the grace period, bj gets rough with SIGKILL. The
+signal that actually ended it is recorded. If no ID is specified, kills
+whatever bj is currently inside. A job still waiting in its --queue is just
+sent home and shows "cancelled".
 
 Arguments:
-  id        Job ID to kill (optional, defaults to latest running)
+  id|name   Job ID or name to kill (optional, defaults to latest running)
 
 Options:
-  --json    Output killed job info as JSON
+  --signal SIG  Safe word to use first: HUP, INT, QUIT, KILL, USR1, USR2, ALRM,
+                TERM or a number (default: TERM)
+  --grace DUR   How long to wait before using SIGKILL (default: 5s)
+  --all         Pull out of everything (running, waiting or queued)
+  --queue NAME  With --all, only the jobs lined up in this queue
+  --failed-deps With --all, only jobs started --after or --after-any a job
+                that couldn't finish
+  --json        Output killed job info as JSON
 
 Examples:
-  bj --kill         Pull out of the current job
-  bj --kill 5       Withdraw from job #5 specifically`,
+  bj --kill                      Pull out of the current job
+  bj --kill 5                    Withdraw from job #5 specifically
+  bj --kill api                  Pull out of "api" by name
+  bj --kill api --signal INT     Ask nicely with Ctrl-C instead
+  bj --kill 5 --grace 30s        Give job #5 time to clean up
+  bj --kill --all                Call off the whole orgy
+  bj --kill --all --queue shards Send the rest of the line home
+  bj --kill --all --failed-deps  Stop whoever was riding on a job that flopped`,
+
+	// Help text - wait
+	"help.wait": `bj --wait - Wait for bj to finish
+
+Usage: bj --wait [id|name...] [--timeout DUR] [--json]
+
+Blocks until the given jobs have finished, moaning about each one as it
+ends and summing it all up at the end. Without arguments, waits for every
+unfinished job started from the current directory. Jobs that ghosted without
+reporting back are detected (as with --gc) and count as failures.
+
+Exit status is 0 if every job finished happy, 1 if any didn't, and 124 if
+--timeout ran out first. Handy for scripts that like to do several jobs
+at once.
+
+Arguments:
+  id|name   Job IDs or names to wait for (optional)
+
+Options:
+  --timeout DUR   Stop waiting after DUR (e.g. 30s, 10m)
+  --json          Output the results as JSON
+
+Examples:
+  bj --wait                    Wait until everyone here is finished
+  bj --wait 3 4 5              Wait for jobs #3, #4 and #5 to finish
+  bj --wait build --timeout 5m Give "build" 5 minutes, tops
+  bj --wait && ./deploy.sh     Deploy only if everyone was satisfied`,
+
+	// Help text - pause
+	"help.pause": `bj --pause / --resume - Let bj catch its breath
+
+Usage:
+  bj --pause [id|name] [--json]
+  bj --resume [id|name] [--json]
+
+--pause freezes a job mid-stroke by sending SIGSTOP to its process group,
+giving the CPU back without losing any progress. --resume sends SIGCONT so
+it picks up right where it left off. Paused jobs show as "paused" in --list,
+and time spent catching a breath doesn't count towards the job's duration.
+
+Without an ID, --pause picks the most recent running job and --resume the
+most recent paused one.
+
+Arguments:
+  id|name   Job ID or name (optional)
+
+Options:
+  --json    Output job info as JSON
+
+Examples:
+  bj --pause            Hold it right there
+  bj --pause build      Make "build" wait a minute
+  bj --resume           Get back into the latest paused job
+  bj --resume 5         Pick up where you left off with job #5`,
+
+	// Help text - show
+	"help.show": `bj --show - Get intimate with a job
+
+Usage: bj --show [id|name] [--lines N] [--format FORMAT] [--json]
+
+Shows every intimate detail of one job (the latest if you don't say which),
+nothing cut short: the full command and the directory it happened in, its
+status, PID and whether it's still breathing, exact start and end times,
+how long it lasted, and the exit code along with what it means ("command not
+found", "killed by SIGTERM", ...).
+
+It also shows how the job was set up (retry or restart policy, timeout,
+dependencies, queue, --ok-codes, --fail-if-output), its log files and how
+big they got, the limits it runs under (--nice, --ionice, --max-memory,
+--max-cpu-time, --max-open-files) and what it has taken so far: peak memory,
+CPU time and wall time, added up over every round. Anything that doesn't
+apply is left out.
+
+Last comes the pillow talk: the end of its log, 10 lines unless you ask for
+more.
+
+--format prints just the job's own line in any --list format, like
+--format '{{.PWD}}' to get the directory it ran in.
+
+Options:
+  --lines N   Show the last N lines of output (0 for none)
+  --format FORMAT
+              Print the job as table, wide, csv, tsv, jsonl or a Go template
+              (see bj --list --help)
+  --json      Output the job's data as JSON, plus its status, whether it's
+              alive, duration, log size, exit code meaning and last lines
+  --reveal-env
+              Include environment variable values (hidden as *** otherwise)
+
+Examples:
+  bj --show             See what the latest job took out of you
+  bj --show api         Check up on the job named "api"
+  bj --show 5 --lines 50
+                        Relive the last 50 lines of job #5`,
+
+	// Help text - tui
+	"help.tui": `bj --tui - Take control of every job full-screen
+
+Usage: bj --tui [--running] [--failed] [--done] [--here]
+
+Takes over the whole terminal: the job list on top and the selected job's
+latest output below, both updating live as jobs start, finish and moan into
+their logs. The filters work like they do for --list.
+
+Keys:
+  ↑/↓ or k/j        Pick a job (PgUp/PgDn, Home/End or g/G to jump)
+  enter or v        Read its whole log in the viewer from bj.toml
+  x                 Kill the job (asks first, --signal and --grace apply)
+  r                 Give a ruined job one more go, like bj --retry=1 --id
+  p                 Pause the job, or resume it if it's catching its breath
+  P                 Prune the spent jobs on screen (asks first)
+  q or Ctrl-C       Quit
+
+Examples:
+  bj --tui            Watch everything from the best seat in the house
+  bj --tui --here     Just the jobs started in this directory`,
 
 	// Help text - gc
 	"help.gc": `bj --gc - Find jobs that ghosted
@@ -217,15 +682,26 @@ Options:
   --retry         Edge until climax (no limit)
   --retry=N       Give up after N attempts (blue balls after N tries)
   --delay S       Rest S seconds between attempts (refractory period)
-  --id ID         Specify which failed job to retry (defaults to most recent)
+  --backoff MODE  How the rest builds up after each miss:
+                  fixed (default), linear (S, 2S, 3S...) or
+                  exponential (S, 2S, 4S...)
+  --max-delay DUR Cap the growing rest (default 5m)
+  --jitter        Rest a random time between half and all of the delay
+  --timeout DUR   Cut off an attempt that lasts longer than DUR (counts as a miss)
+  --id ID         Specify which failed job to retry by ID or name
+                  (defaults to most recent)
   --json          Output job info as JSON
 
 Examples:
   bj --retry npm test              Keep testing until it comes
   bj --retry=3 make build          Try building up to 3 times
   bj --retry --delay 5 curl ...    Rest 5 seconds between attempts
+  bj --retry --backoff exponential --jitter curl ...
+                                   Rest 1s, 2s, 4s... and keep it unpredictable
+  bj --retry=3 --timeout 1m ./t.sh Give each round a minute to finish
   bj --retry                       Try again with the last failure
   bj --retry --id 5                Go again on job #5
+  bj --retry --id api              Go again with your latest "api"
   bj --retry=3 --delay 10 --id 5   Retry #5 up to 3 times, 10s rest`,
 
 	// Help text - completion
@@ -323,26 +799,397 @@ on individual commands. They'll treat you right.
 .B \-\-list
 See who bj has been doing. Add
 .BR \-\-running ", " \-\-failed ", or " \-\-done
-to filter by... performance.
+to filter by... performance, and
+.B \-\-here
+for just the jobs started in (or below) the current directory.
+.B \-\-wide
+adds each job's peak memory and CPU time. Retry and restart jobs show how many rounds
+they've gone in the
+.B ATTEMPTS
+column.
+.TP
+.BI \-\-watch " [interval]"
+With
+.BR \-\-list ,
+watch the action live: the table is redrawn in place every
+.I interval
+(default 2s), highlighting jobs that just changed position, until Ctrl-C.
+When stdout isn't a terminal, the full table is printed again each time.
+.TP
+.BI \-\-format " format"
+With
+.BR \-\-list ", " \-\-show " or " \-\-ids ,
+print jobs as
+.B table
+(the default),
+.B wide
+(like
+.BR \-\-wide ),
+.B csv
+or
+.B tsv
+(a header row, then one row per job),
+.B jsonl
+(one JSON object per line), or a Go
+.I text/template
+run for each job, like
+.BR "'{{.ID}} {{.Command}}'" .
+Templates see every job field and can call
+.BR status ", " duration ", " ago ", " truncate " and " json ;
+.B \et
+and
+.B \en
+stand for a tab and a newline.
+.TP
+.BI \-\-sort " field" ", \-\-reverse, \-\-limit" " n"
+With
+.BR \-\-list ", " \-\-ids " or " \-\-prune ,
+order jobs by
+.B start
+(newest first, the default),
+.B duration
+(longest first),
+.B id
+(lowest first) or
+.B status
+(unfinished, then ruined, then successful),
+.B \-\-reverse
+the order, and keep only the first
+.I n
+jobs.
 .TP
-.BI \-\-logs " [id]"
+.BI \-\-cmd " text" ", \-\-exit\-code" " n" ", \-\-dir" " path"
+With
+.BR \-\-list ", " \-\-ids " or " \-\-prune ,
+only pick jobs whose command contains
+.IR text ,
+that exited with code
+.IR n ,
+or that were started in (or below)
+.IR path .
+Killed jobs exit with minus the signal number, like \-15,
+and lost or skipped ones with \-1.
+.B \-\-since
+.I time
+picks jobs started at or after
+.IR time .
+These narrow down
+.BR \-\-running ", " \-\-failed ", " \-\-done " and " \-\-here ,
+so
+.B bj \-\-prune \-\-failed \-\-cmd make
+only prunes ruined make jobs.
+.TP
+.BI \-\-name " name"
+Give a job a pet name so you don't have to call it by its number.
+Works anywhere a job ID does. Only one running job can answer to a
+given name at a time. bj is monogamous like that.
+.TP
+.BI \-\-grep " pattern"
+Looking for something? Feels through every job's logs for lines matching
+the regular expression
+.I pattern
+and shows who said it by ID and command. Narrow it down with
+.BR \-\-running ", " \-\-failed " or " \-\-done .
+Exits 1 if nothing matches.
+.TP
+.BI \-\-logs " [id|name]"
 Watch bj's output. Every moan, groan, and triumphant climax message.
 Defaults to the most recent job if you can't remember which one.
 .TP
-.BI \-\-kill " [id]"
+.BR \-f ", " \-\-follow
+With
+.BR \-\-logs ,
+watch it live until the job finishes, then exit with its exit code.
+Perfect for
+.BR "bj \-\-logs 5 \-f && notify" .
+.TP
+.BR \-\-stdout ", " \-\-stderr
+With
+.BR \-\-logs ,
+show only what the command let out on that stream instead of both
+intertwined. Combine with
+.B \-f
+to watch just the groans.
+.TP
+.BI \-\-attempt " n"
+With
+.B \-\-logs
+on a
+.B \-\-retry
+or
+.B \-\-restart
+job, relive just its nth round. Each round is recorded with when it
+started and finished, how it ended and where it sits in the log.
+.TP
+.BI \-\-since " time" ", \-\-until" " time"
+With
+.B \-\-logs
+on a job started with
+.BR \-\-timestamps ,
+show only what happened in that window. Takes a duration ago
+.RB ( 10m ),
+a time today
+.RB ( 03:00 )
+or a date
+.RB ( "2026-01-02 03:00" ).
+.TP
+.BI \-\-kill " [id|name]"
 Sometimes you need to pull out abruptly. No judgment.
+Sends SIGTERM to the job's process group and waits for it to finish up.
+If it won't take the hint by the end of the grace period, SIGKILL settles
+it. The signal that actually ended the job is recorded.
+.TP
+.BI \-\-signal " sig"
+With
+.BR \-\-kill ,
+the safe word to use first, by name (\fBINT\fR, \fBSIGHUP\fR) or number.
+.TP
+.BI \-\-grace " duration"
+With
+.BR \-\-kill ,
+how long to wait before things get rough with SIGKILL (default 5s).
+.TP
+.B \-\-all
+With
+.BR \-\-kill ,
+pull out of everything at once. Narrow it down with
+.BR \-\-queue .
 .TP
 .BR \-\-retry [ =\fIN\fR ]
 bj doesn't give up easily. Use alone to retry a failed job, or with a
 command to keep pounding until satisfaction (or N attempts, whichever
 comes first).
 .TP
+.BR \-\-restart [ =always ]
+For the ones with stamina. Runs the command and brings it back whenever
+it fails, after
+.B restart_delay
+seconds (default 5). With
+.BR =always ,
+it goes again even after a happy ending.
+.TP
+.BI \-\-restart\-limit " n"
+With
+.BR \-\-restart ,
+give up once the command has come back more than
+.I n
+times within the restart window, and mark the job
+.BR crashloop .
+Even bj knows when to call it a night.
+.TP
+.BI \-\-restart\-window " duration"
+The window
+.B \-\-restart\-limit
+counts restarts in (default 1m).
+.TP
+.BI \-\-timeout " duration"
+Everyone has limits. Gives each run a deadline like
+.B 30s
+or
+.BR 5m .
+Past it, the whole process group gets SIGTERM, then SIGKILL if it
+still won't take the hint, and the job is marked
+.BR timeout .
+With
+.BR \-\-retry ,
+a timeout counts as a miss.
+.TP
+.BI \-\-ok\-codes " n[,n...]"
+Not every exit is a failure. Counts these exit codes as success instead of
+just 0, for
+.BR \-\-retry ,
+.BR \-\-restart ,
+.BR \-\-after ,
+the colors in
+.B \-\-list
+and the
+.B \-\-failed
+and
+.B \-\-done
+filters. Codes other than 0 show as
+.BR done(\fIn\fB) .
+.TP
+.BI \-\-fail\-if\-output " regex"
+Exit codes can lie. Counts a run as a miss if a line of its output matches
+.IR regex ,
+even when it exits cleanly. A job that finishes that way is marked
+.BR bad\-output .
+.TP
+.BI \-\-cwd " dir"
+Run the job in
+.I dir
+instead of the current directory. No need to
+.B cd
+first.
+.TP
+.BI \-\-nice " n"
+Run the job at niceness
+.I n
+(\-20 to 19) so it doesn't hog the CPU.
+.TP
+.BI \-\-ionice " class"
+Run the job in I/O scheduling class
+.BR idle ", " best\-effort " or " realtime
+(Linux only).
+.TP
+.BI \-\-max\-memory " size"
+Limit the job's address space
+.RB ( RLIMIT_AS ),
+like
+.BR 2G .
+Allocations past it fail.
+.TP
+.BI \-\-max\-cpu\-time " duration"
+Kill a run once it has used this much CPU time
+.RB ( RLIMIT_CPU ).
+.TP
+.BI \-\-max\-open\-files " n"
+Limit how many files the job can have open at once
+.RB ( RLIMIT_NOFILE ).
+.IP
+Limits apply to the command and everything it starts, are recorded on the
+job so
+.B \-\-retry \-\-id
+reruns it the same way, and default to the
+.BR nice ", " ionice ", " max_memory ", " max_cpu_time " and " max_open_files
+config options.
+.TP
+.BI \-\-show " [id|name]"
+Get intimate with one job, nothing cut short: the full command and
+directory, status, PID and whether it's still breathing, exact start and end
+times, the exit code and what it means, how it was set up, its log files and
+their size, its limits, peak memory, CPU and wall time, and the last lines of
+its output.
+.TP
+.BI \-\-lines " n"
+With
+.BR \-\-show ,
+print the last
+.I n
+lines of the job's output (default 10, 0 for none).
+.TP
+.B \-\-tui
+Take control of every job full-screen: the job list on top and the selected
+job's latest output below, updated live. Keys pick a job (arrows or j/k),
+read its log in the configured
+.B viewer
+(enter), kill it (x), retry it (r), pause or resume it (p), prune the spent
+jobs shown (P) and quit (q). Needs a terminal.
+.TP
+.BI \-\-env " key=value"
+Set an environment variable for the job. Repeat it for more.
+.TP
+.BI \-\-env\-file " file"
+Load environment variables from a
+.I .env
+file (KEY=VALUE lines, # comments). Later
+.B \-\-env
+flags win.
+.IP
+Either way, bj records the job's environment when it's launched (the
+variables matching
+.BR env_allowlist ,
+plus these) and puts it back for every run, so
+.B \-\-retry \-\-id
+reruns a job the way it was started.
+.TP
+.B \-\-timestamps
+Kiss and tell. Marks every line the job lets out with the exact moment
+it happened (also on for every job with
+.BR "log_timestamps = true" ).
+Lets
+.BR \-\-logs " " \-\-since " and " \-\-until
+reveal what went down at 3am.
+.TP
+.BI \-\-max\-log\-size " size"
+Size matters. Once a log gets as big as
+.I size
+(like
+.B 500K
+or
+.BR 50M )
+it's rotated into numbered segments, so a
+.B \-\-restart
+server that never stops can't fill up the disk. Defaults to
+.BR max_log_size .
+.TP
+.BI \-\-max\-log\-files " n"
+How many rotated segments to keep per log before the oldest goes (default
+.BR max_log_files ,
+or 5).
+.B \-\-logs
+stitches the segments back together.
+.TP
 .BI \-\-delay " secs"
-Pace yourself. Rest between retry attempts.
+Pace yourself. Rest between retry attempts (default 1s) or restarts
+(default
+.BR restart_delay ,
+5s).
+.TP
+.BI \-\-backoff " fixed|linear|exponential"
+With
+.B \-\-retry
+or
+.BR \-\-restart ,
+how the rest builds up after each miss:
+.B fixed
+rests the same every time,
+.B linear
+adds the delay once more per miss and
+.B exponential
+doubles it. The banner in the log shows each computed delay.
+.TP
+.BI \-\-max\-delay " duration"
+The longest a growing rest gets (default 5m).
+.TP
+.B \-\-jitter
+Keep it unpredictable. Each rest becomes a random time between half
+and all of the computed one, so jobs that failed together don't all
+come back at once.
+.TP
+.BI \-\-after " id[,id...]"
+Good things come to those who wait. The job sits in the
+.B waiting
+state until every listed job (by ID or name) has finished, then only
+goes if they all finished happy. Otherwise it's marked
+.B skipped
+and never gets its turn.
+.TP
+.BI \-\-after\-any " id[,id...]"
+Like
+.BR \-\-after ,
+but the job goes once the listed jobs finish, however they ended.
+.TP
+.BI \-\-queue " name"
+Take a number. Only
+.B max_parallel
+jobs from the same queue get going at once (set in
+.BR [queues.\fIname\fB] ,
+default 1); the rest are
+.B queued
+and get their turn in order as others finish.
+.TP
+.BI \-\-wait " [id|name...]"
+Patience. Blocks until the jobs finish (by default, everyone started
+from the current directory), then exits non-zero if any of them were
+left unsatisfied. With
+.BR \-\-timeout ,
+gives up after a while and exits 124.
+.TP
+.BI \-\-pause " [id|name]"
+Need to catch your breath? Freezes the job with SIGSTOP without losing
+any progress. Paused time doesn't count towards its duration.
+.TP
+.BI \-\-resume " [id|name]"
+Back on top. Sends SIGCONT to a paused job.
 .TP
 .B \-\-prune
 Clean up after bj is finished. Wipes away completed jobs and their logs.
-A tidy bj is a happy bj.
+A tidy bj is a happy bj. Takes the same filters,
+.BR \-\-sort ", " \-\-reverse " and " \-\-limit
+as
+.B \-\-list
+to be picky about it.
 .TP
 .B \-\-gc
 Find jobs that ghosted. Sometimes things end badly
@@ -394,6 +1241,18 @@ Don't be weird about it.
 log_dir = "logs"
 viewer = "less"
 auto_prune_hours = 24
+log_timestamps = false
+max_log_size = "50M"
+max_log_files = 5
+restart_delay = 5
+env_allowlist = ["PATH", "HOME", "AWS_*"]
+nice = 10
+max_memory = "4G"
+compress_logs = true
+compress_min_size = "64K"
+
+[queues.shards]
+max_parallel = 4
 .fi
 .RE
 .PP
@@ -461,11 +1320,64 @@ complete -c bj -l list -d "See what bj is working on"
 complete -c bj -l running -d "Filter: only running jobs"
 complete -c bj -l failed -d "Filter: only ruined jobs"
 complete -c bj -l done -d "Filter: only successful jobs"
+complete -c bj -n "__fish_seen_argument -l list" -l wide -d "Add memory and CPU columns"
+complete -c bj -n "__fish_seen_argument -l list" -l watch -d "Redraw the list until Ctrl-C"
+complete -c bj -n "__fish_seen_argument -l list -l show -l ids" -l format -d "Print jobs as a named format or Go template" -xa "table wide csv tsv jsonl"
+complete -c bj -l here -d "Filter: only jobs started in this directory"
+complete -c bj -n "__fish_seen_argument -l list -l ids -l prune" -l since -d "Filter: only jobs started since (e.g. 2h)" -x
+complete -c bj -n "__fish_seen_argument -l list -l ids -l prune" -l cmd -d "Filter: only commands containing this" -x
+complete -c bj -n "__fish_seen_argument -l list -l ids -l prune" -l exit-code -d "Filter: only jobs that exited with this code" -x
+complete -c bj -n "__fish_seen_argument -l list -l ids -l prune" -l dir -d "Filter: only jobs started in this directory" -xa "(__fish_complete_directories)"
+complete -c bj -n "__fish_seen_argument -l list -l ids -l prune" -l sort -d "Order jobs by" -xa "start duration id status"
+complete -c bj -n "__fish_seen_argument -l list -l ids -l prune" -l reverse -d "Flip the order"
+complete -c bj -n "__fish_seen_argument -l list -l ids -l prune" -l limit -d "Only the first N jobs" -x
 complete -c bj -l logs -d "Watch bj's performance"
+complete -c bj -l grep -d "Search every job's logs" -x
+complete -c bj -n "__fish_seen_argument -l logs" -s f -l follow -d "Stream output until the job finishes"
+complete -c bj -n "__fish_seen_argument -l logs" -l stdout -d "Show only stdout"
+complete -c bj -n "__fish_seen_argument -l logs" -l stderr -d "Show only stderr"
+complete -c bj -n "__fish_seen_argument -l show" -l lines -d "Show the last N lines of output" -x
+complete -c bj -n "__fish_seen_argument -l list -l show -l logs -l grep" -l reveal-env -d "Include environment values in JSON"
+complete -c bj -n "__fish_seen_argument -l logs" -l attempt -d "Show only one run of a retry/restart job" -x
+complete -c bj -n "__fish_seen_argument -l logs" -l since -d "Only lines written since (e.g. 10m, 15:04)" -x
+complete -c bj -n "__fish_seen_argument -l logs" -l until -d "Only lines written until (e.g. 10m, 15:04)" -x
 complete -c bj -l kill -d "Stop a job mid-action"
+complete -c bj -l pause -d "Pause a running job"
+complete -c bj -l wait -d "Wait for jobs to finish"
+complete -c bj -l resume -d "Resume a paused job"
+complete -c bj -l restart -d "Restart on failure, or after every exit with =always"
+complete -c bj -l restart-limit -d "Give up after this many restarts in the window" -x
+complete -c bj -l restart-window -d "Window for --restart-limit (e.g. 1m)" -x
 complete -c bj -l retry -d "Keep going until bj finishes"
-complete -c bj -l delay -d "Seconds to wait between retry attempts"
-complete -c bj -l id -d "Specify job ID for --retry" -xa "(bj --ids --failed 2>/dev/null)"
+complete -c bj -l delay -d "Seconds to wait between retries or restarts"
+complete -c bj -l backoff -d "How the delay grows after each failure" -xa "fixed linear exponential"
+complete -c bj -l max-delay -d "Cap the growing delay (e.g. 1m)" -x
+complete -c bj -l jitter -d "Randomize each delay"
+complete -c bj -l id -d "Specify job ID or name for --retry" -xa "(bj --ids --failed 2>/dev/null; bj --names --failed 2>/dev/null)"
+complete -c bj -l name -d "Name the job" -x
+complete -c bj -l timeout -d "Kill a run after this long (e.g. 30s, 5m)" -x
+complete -c bj -l ok-codes -d "Exit codes that count as success (e.g. 0,1)" -x
+complete -c bj -l fail-if-output -d "Fail a run whose output matches this regex" -x
+complete -c bj -l show -d "Show one job's details"
+complete -c bj -l tui -d "Manage jobs full-screen"
+complete -c bj -l nice -d "Run at this niceness (-20 to 19)" -x
+complete -c bj -l ionice -d "Run in this I/O class" -xa "idle best-effort realtime"
+complete -c bj -l max-memory -d "Limit memory (e.g. 2G)" -x
+complete -c bj -l max-cpu-time -d "Limit CPU time per run (e.g. 10m)" -x
+complete -c bj -l max-open-files -d "Limit open files" -x
+complete -c bj -l cwd -d "Run the job in this directory" -xa "(__fish_complete_directories)"
+complete -c bj -l env -d "Set an environment variable (KEY=VALUE)" -x
+complete -c bj -l env-file -d "Load environment variables from a .env file" -rF
+complete -c bj -l timestamps -d "Prefix each output line with when it was written"
+complete -c bj -l max-log-size -d "Rotate logs at this size (e.g. 50M)" -x
+complete -c bj -l max-log-files -d "Rotated log segments to keep" -x
+complete -c bj -l after -d "Wait for jobs to succeed first" -xa "(bj --ids 2>/dev/null; bj --names 2>/dev/null)"
+complete -c bj -l after-any -d "Wait for jobs to finish first" -xa "(bj --ids 2>/dev/null; bj --names 2>/dev/null)"
+complete -c bj -l queue -d "Run in a concurrency-limited queue" -x
+complete -c bj -l signal -d "Signal for --kill" -xa "TERM INT HUP QUIT KILL USR1 USR2 ALRM"
+complete -c bj -l grace -d "Wait this long before SIGKILL" -x
+complete -c bj -l all -d "Kill every unfinished job"
+complete -c bj -n "__fish_seen_argument -l all" -l failed-deps -d "Only jobs after a failed job"
 complete -c bj -l prune -d "Clean up when bj is finished"
 complete -c bj -l gc -d "Find ruined jobs after a crash"
 complete -c bj -l json -d "Output in JSON format"
@@ -475,9 +1387,17 @@ complete -c bj -l completion -d "Output shell completions" -xa "fish zsh"
 complete -c bj -l init -d "Output prompt integration" -xa "fish zsh"
 complete -c bj -l man -d "Output manual page"
 
-# Job ID completion for --logs and --kill
+# Job ID and name completion for --logs and --kill
 complete -c bj -n "__fish_seen_argument -l logs" -a "(bj --ids 2>/dev/null)" -d "Job ID"
+complete -c bj -n "__fish_seen_argument -l logs" -a "(bj --names 2>/dev/null)" -d "Job name"
 complete -c bj -n "__fish_seen_argument -l kill" -a "(bj --ids --running 2>/dev/null)" -d "Running job ID"
+complete -c bj -n "__fish_seen_argument -l kill" -a "(bj --names --running 2>/dev/null)" -d "Running job name"
+complete -c bj -n "__fish_seen_argument -l wait" -a "(bj --ids --running 2>/dev/null)" -d "Running job ID"
+complete -c bj -n "__fish_seen_argument -l wait" -a "(bj --names --running 2>/dev/null)" -d "Running job name"
+complete -c bj -n "__fish_seen_argument -l pause" -a "(bj --ids --running 2>/dev/null)" -d "Running job ID"
+complete -c bj -n "__fish_seen_argument -l pause" -a "(bj --names --running 2>/dev/null)" -d "Running job name"
+complete -c bj -n "__fish_seen_argument -l resume" -a "(bj --ids --running 2>/dev/null)" -d "Running job ID"
+complete -c bj -n "__fish_seen_argument -l resume" -a "(bj --names --running 2>/dev/null)" -d "Running job name"
 `,
 
 	// Shell completions - zsh (same as SFW, no innuendos in completions)
@@ -487,21 +1407,27 @@ complete -c bj -n "__fish_seen_argument -l kill" -a "(bj --ids --running 2>/dev/
 #          (ensure ~/.zsh/completions is in your fpath)
 
 _bj_job_ids() {
-    local -a job_ids
+    local -a job_ids job_names
     job_ids=(${(f)"$(bj --ids 2>/dev/null)"})
+    job_names=(${(f)"$(bj --names 2>/dev/null)"})
     _describe -t job-ids 'job ID' job_ids
+    _describe -t job-names 'job name' job_names
 }
 
 _bj_running_job_ids() {
-    local -a job_ids
+    local -a job_ids job_names
     job_ids=(${(f)"$(bj --ids --running 2>/dev/null)"})
+    job_names=(${(f)"$(bj --names --running 2>/dev/null)"})
     _describe -t job-ids 'running job ID' job_ids
+    _describe -t job-names 'running job name' job_names
 }
 
 _bj_failed_job_ids() {
-    local -a job_ids
+    local -a job_ids job_names
     job_ids=(${(f)"$(bj --ids --failed 2>/dev/null)"})
+    job_names=(${(f)"$(bj --names --failed 2>/dev/null)"})
     _describe -t job-ids 'ruined job ID' job_ids
+    _describe -t job-names 'ruined job name' job_names
 }
 
 _bj() {
@@ -510,11 +1436,63 @@ _bj() {
         '--running[Filter: only running jobs]' \
         '--failed[Filter: only ruined jobs]' \
         '--done[Filter: only successful jobs]' \
+        '--wide[Add memory and CPU columns]' \
+        '--watch[Redraw the list until Ctrl-C]::interval:' \
+        '--format[Print jobs as a named format or Go template]:format:(table wide csv tsv jsonl)' \
+        '--show[Show one job'\''s details]:job ID:_bj_job_ids' \
+        '--tui[Manage jobs full-screen]' \
+        '--lines[Show the last N lines of output]:lines:' \
+        '--here[Filter: only jobs started in this directory]' \
+        '--cmd[Filter: only commands containing this]:text:' \
+        '--exit-code[Filter: only jobs that exited with this code]:exit code:' \
+        '--dir[Filter: only jobs started in this directory]:directory:_directories' \
+        '--sort[Order jobs by]:field:(start duration id status)' \
+        '--reverse[Flip the order]' \
+        '--limit[Only the first N jobs]:count:' \
         '--logs[Watch bj'\''s performance]:job ID:_bj_job_ids' \
+        '--grep[Search every job'\''s logs]:pattern:' \
+        {-f,--follow}'[Stream output until the job finishes]' \
+        '(--stderr)--stdout[Show only stdout]' \
+        '(--stdout)--stderr[Show only stderr]' \
+        '--attempt[Show only one run of a retry/restart job]:attempt:' \
+        '--since[Only lines written or jobs started since]:time:' \
+        '--until[Only lines written until]:time:' \
+        '--reveal-env[Include environment values in JSON]' \
         '--kill[Stop a job mid-action]:job ID:_bj_running_job_ids' \
+        '--wait[Wait for jobs to finish]:*:job ID:_bj_running_job_ids' \
+        '--pause[Pause a running job]:job ID:_bj_running_job_ids' \
+        '--resume[Resume a paused job]:job ID:_bj_running_job_ids' \
+        '--restart=-[Restart on failure, or after every exit with =always]::policy:(always on-failure)' \
+        '--restart-limit[Give up after this many restarts in the window]:count:' \
+        '--restart-window[Window for --restart-limit]:duration:' \
         '--retry=-[Keep going until bj finishes]:max attempts:' \
-        '--delay[Seconds between retry attempts]:seconds:' \
-        '--id[Specify job ID for --retry]:job ID:_bj_failed_job_ids' \
+        '--delay[Seconds between retries or restarts]:seconds:' \
+        '--backoff[How the delay grows after each failure]:backoff:(fixed linear exponential)' \
+        '--max-delay[Cap the growing delay]:duration:' \
+        '--jitter[Randomize each delay]' \
+        '--id[Specify job ID or name for --retry]:job ID:_bj_failed_job_ids' \
+        '--name[Name the job]:name:' \
+        '--timeout[Kill a run after this long]:duration:' \
+        '--ok-codes[Exit codes that count as success]:exit codes:' \
+        '--fail-if-output[Fail a run whose output matches this regex]:pattern:' \
+        '--nice[Run at this niceness]:niceness:' \
+        '--ionice[Run in this I/O class]:class:(idle best-effort realtime)' \
+        '--max-memory[Limit memory]:size:' \
+        '--max-cpu-time[Limit CPU time per run]:duration:' \
+        '--max-open-files[Limit open files]:count:' \
+        '--cwd[Run the job in this directory]:directory:_directories' \
+        '*--env[Set an environment variable]:KEY=VALUE:' \
+        '*--env-file[Load environment variables from a .env file]:env file:_files' \
+        '--timestamps[Prefix each output line with when it was written]' \
+        '--max-log-size[Rotate logs at this size]:size:' \
+        '--max-log-files[Rotated log segments to keep]:count:' \
+        '--after[Wait for jobs to succeed first]:job ID:_bj_job_ids' \
+        '--after-any[Wait for jobs to finish first]:job ID:_bj_job_ids' \
+        '--queue[Run in a concurrency-limited queue]:queue:' \
+        '--signal[Signal for --kill]:signal:(TERM INT HUP QUIT KILL USR1 USR2 ALRM)' \
+        '--grace[Wait this long before SIGKILL]:duration:' \
+        '--all[Kill every unfinished job]' \
+        '--failed-deps[With --all, only jobs after a failed job]' \
         '--prune[Clean up when bj is finished]' \
         '--gc[Find ruined jobs after a crash]' \
         '--json[Output in JSON format]' \
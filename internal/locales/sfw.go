@@ -4,7 +4,7 @@ package locales
 var SFW = Messages{
 	// Error messages
 	"err.id_only_with_retry":    "--id only makes sense with --retry. They go together like... well, you know.",
-	"err.delay_only_with_retry": "--delay without --retry? bj needs something to delay between.",
+	"err.delay_only_with_retry": "--delay without --retry or --restart? bj needs something to delay between.",
 	"err.restart_and_retry":     "--restart and --retry don't play well together. Pick your stamina strategy.",
 	"err.restart_needs_command": "--restart needs a command to... well, restart. Give bj something to work with.",
 	"err.restart_pwd_failed":    "bj lost its bearings and can't restart: %v",
@@ -24,10 +24,12 @@ var SFW = Messages{
 	"err.gc_failed":             "bj had trouble cleaning up its mess: %v",
 	"err.kill_check_failed":     "bj can't check its active sessions: %v",
 	"err.kill_failed":           "bj couldn't pull out: %v",
+	"err.kill_all_with_ref":     "--kill --all stops every job, so it doesn't take a job too ('%s')",
+	"err.failed_deps_all":       "--failed-deps picks jobs for --kill --all, add --all",
 	"err.retry_pwd_failed":      "bj couldn't figure out where you are: %v",
 	"err.retry_history_failed":  "bj can't check its history: %v",
 	"err.retry_find_failed":     "bj can't find that one: %v",
-	"err.job_not_found":         "Job %d? bj doesn't remember that.",
+	"err.job_not_found":         "Job %s? bj doesn't remember that.",
 	"err.job_still_running":     "Job %d is still going. bj doesn't stop until it's done.",
 	"err.job_already_succeeded": "Job %d already finished successfully. No need to go again.",
 	"err.retry_start_failed":    "bj couldn't get started again: %v",
@@ -42,33 +44,204 @@ var SFW = Messages{
 	"err.job_id_minimum":        "Job IDs start at 1. '%d' won't satisfy bj.",
 	"err.delay_needs_value":     "--delay needs a number of seconds",
 	"err.delay_non_negative":    "bj needs a non-negative delay, not '%s'",
+	"err.name_needs_value":      "--name needs a name to go with it",
+	"err.name_numeric":          "'%s' looks like a job ID. Give bj a name with some letters in it.",
+	"err.name_in_use":           "bj is already busy with a job named '%s'. Pick another name or kill that one first.",
+	"err.timeout_needs_value":   "--timeout needs a duration, like 30s or 5m",
+	"err.invalid_duration":      "bj needs a duration like 30s, 5m or 1h, not '%s'",
+	"err.exec_usage":            "Usage: bj --exec <job_id>",
+	"err.exec_failed":           "bj couldn't keep an eye on the job: %v",
+	"err.after_needs_value":     "--after needs job IDs or names, like 3 or build,test",
+	"err.await_usage":           "Usage: bj --await <job_id>",
+	"err.queue_needs_value":     "--queue needs a queue name to go with it",
+	"err.signal_needs_value":    "--signal needs a signal, like TERM, INT or 9",
+	"err.invalid_signal":        "bj doesn't know the signal '%s'. Try HUP, INT, QUIT, KILL, USR1, USR2, ALRM or TERM.",
+	"err.grace_needs_value":     "--grace needs a duration, like 5s or 1m",
+	"err.pause_failed":          "bj couldn't take a breather: %v",
+	"err.follow_json":           "--follow streams the log as plain text and can't be combined with --json",
+	"err.watch_json":            "--watch redraws the table as it changes and can't be combined with --json",
+	"err.invalid_interval":      "--watch wants an interval like 2s, 500ms or 5, got '%s'",
+	"err.format_needs_value":    "--format needs a value: table, wide, csv, tsv, jsonl or a template like '{{.ID}} {{.Command}}'",
+	"err.invalid_format":        "Invalid --format template: %v",
+	"err.format_failed":         "Failed to format jobs: %v",
+	"err.format_json":           "--format and --json both pick an output format, use one or the other",
+	"err.sort_needs_value":      "--sort needs a field: start, duration, id or status",
+	"err.invalid_sort":          "--sort can't order jobs by '%s', try start, duration, id or status",
+	"err.max_jobs_needs_value":  "--limit needs a number of jobs",
+	"err.invalid_max_jobs":      "--limit wants a positive number of jobs, got '%s'",
+	"err.cmd_needs_value":       "--cmd needs some text to look for in the command",
+	"err.exit_code_needs_value": "--exit-code needs an exit code, like 1",
+	"err.dir_needs_value":       "--dir needs a directory",
+	"err.logs_stream_conflict":  "--stdout and --stderr can't be combined, leave both off to see everything",
+	"err.backoff_without_retry": "--backoff, --max-delay and --jitter pace reruns, so they need --retry or --restart",
+	"err.backoff_needs_value":   "--backoff needs a strategy: fixed, linear or exponential",
+	"err.invalid_backoff":       "bj doesn't know the backoff '%s'. Try fixed, linear or exponential.",
+	"err.max_delay_needs_value": "--max-delay needs a duration, like 30s or 5m",
+	"err.attempt_needs_value":   "--attempt needs an attempt number, like 1 for the first",
+	"err.attempt_positive":      "bj needs a positive attempt number, not '%s'",
+	"err.attempt_conflict":      "--attempt shows part of the combined log, so it can't be combined with --stdout, --stderr or --follow",
+	"err.attempt_not_found":     "Job %d has no attempt %d. bj has a record of %d.",
+	"err.attempt_rotated":       "Attempt %d of job %d has been rotated out of the log",
+	"err.invalid_restart":       "bj doesn't know the restart policy '%s'. Try --restart=always or --restart=on-failure.",
+	"err.limit_without_restart": "--restart-limit and --restart-window only make sense with --restart",
+	"err.window_needs_limit":    "--restart-window needs a --restart-limit to count against",
+	"err.limit_needs_value":     "--restart-limit needs a number of restarts",
+	"err.limit_positive":        "--restart-limit wants a positive number, got '%s'",
+	"err.window_needs_value":    "--restart-window needs a duration, like 1m or 1h",
+	"err.ok_codes_needs_value":  "--ok-codes needs exit codes, like 0,1",
+	"err.invalid_ok_code":       "bj needs exit codes from 0 to 255, not '%s'",
+	"err.fail_if_needs_value":   "--fail-if-output needs a pattern to look for",
+	"err.env_needs_value":       "--env needs a variable, like KEY=VALUE",
+	"err.invalid_env":           "bj needs --env as KEY=VALUE, not '%s'",
+	"err.env_file_needs_value":  "--env-file needs a file, like .env",
+	"err.env_file_failed":       "bj couldn't read the env file %s: %v",
+	"err.cwd_needs_value":       "--cwd needs a directory to run in",
+	"err.invalid_cwd":           "bj can't run anything in '%s', it isn't a directory",
+	"err.here_failed":           "bj couldn't figure out where you are: %v",
+	"err.nice_needs_value":      "--nice needs a niceness, from -20 to 19",
+	"err.invalid_nice":          "bj needs a niceness from -20 (greedy) to 19 (polite), not '%s'",
+	"err.ionice_needs_value":    "--ionice needs a class: idle, best-effort or realtime",
+	"err.invalid_ionice":        "bj doesn't know the I/O class '%s'. Try idle, best-effort or realtime.",
+	"err.memory_needs_value":    "--max-memory needs a size, like 512M or 2G",
+	"err.cpu_time_needs_value":  "--max-cpu-time needs a duration, like 30s or 10m",
+	"err.max_files_needs_value": "--max-open-files needs a number of files",
+	"err.open_files_positive":   "--max-open-files wants a positive number, got '%s'",
+	"err.confine_usage":         "Usage: bj --confine <job_id>",
+	"err.confine_failed":        "bj couldn't put limits on the job: %v",
+	"err.show_failed":           "bj can't find that one: %v",
+	"err.no_jobs":               "bj hasn't done anything yet",
+	"err.lines_needs_value":     "--lines needs a number of lines, like 20",
+	"err.invalid_lines":         "--lines wants a number of lines (0 for none), got '%s'",
+	"err.tui_no_terminal":       "--tui needs a terminal. Try --list --watch instead",
+	"err.tui_failed":            "bj lost control of the terminal: %v",
+	"err.viewer_failed":         "bj couldn't open the log viewer: %v",
+	"err.logs_no_stream":        "job %d was started before bj kept its %s separate, view its full log instead",
+	"err.since_needs_value":     "--since needs a time, like 10m or 2026-01-02 15:04",
+	"err.until_needs_value":     "--until needs a time, like 10m or 2026-01-02 15:04",
+	"err.invalid_time":          "bj doesn't know when '%s' is. Try a duration (10m, 2h), a time (15:04) or a date (2026-01-02 15:04)",
+	"err.logs_no_timestamps":    "job %d wasn't started with --timestamps, so bj can't tell when its lines were written",
+	"err.grep_needs_pattern":    "--grep needs a pattern to look for",
+	"err.grep_invalid_pattern":  "bj can't make sense of that pattern: %v",
+	"err.grep_failed":           "bj couldn't search the logs: %v",
+	"err.log_size_needs_value":  "--max-log-size needs a size, like 500K or 50M",
+	"err.log_files_needs_value": "--max-log-files needs a number of old log segments to keep",
+	"err.invalid_size":          "bj can't make sense of the size '%s'. Try 500K, 50M or 1G",
+	"err.log_files_positive":    "--max-log-files wants a positive number, got '%s'",
+	"err.wait_failed":           "bj got tired of waiting: %v",
+	"err.resume_failed":         "bj couldn't get back into it: %v",
+	"err.await_failed":          "bj lost track of what it was waiting for: %v",
 
 	// Status messages
 	"job.started":            "[%d] bj is on it: %s",
 	"job.killed":             "[%d] bj stopped abruptly: %s",
+	"job.cancelled":          "[%d] bj called it off before it started: %s",
+	"job.kill_escalated":     "    %s was ignored for %s, so bj used SIGKILL",
+	"job.paused":             "[%d] bj is taking a breather: %s",
+	"job.resumed":            "[%d] bj is back at it: %s",
 	"job.retry_unlimited":    "[%d] bj will keep edging until it succeeds: %s",
 	"job.retry_one":          "[%d] bj will give it one shot: %s",
 	"job.retry_limited":      "[%d] bj will tease up to %d times before giving up: %s",
 	"job.retry_one_existing": "[%d] bj is giving it one more go: %s",
 	"job.restarted":          "[%d] bj will keep coming back for more (restarts on failure): %s",
+	"job.restarted_always":   "[%d] bj will keep coming back for more (restarts whenever it exits): %s",
+	"job.waiting":            "    bj will wait for job(s) %s to finish first",
+	"job.queued":             "    bj queued it in '%s' (position %d), it starts when a slot frees up",
 
 	// List messages
 	"list.empty":          "bj has nothing going on. Give it something to do!",
 	"list.empty_filtered": "No jobs match your criteria. bj has nothing to show.",
+	"list.watching":       "Every %s, as of %s (Ctrl-C to stop)",
 
 	// Kill messages
 	"kill.no_running": "bj isn't doing anything right now. Nothing to stop!",
 
+	// Wait messages
+	"wait.nothing":   "bj isn't doing anything in this directory. Nothing to wait for!",
+	"wait.finished":  "[%d] %s: %s",
+	"wait.summary":   "bj finished %d job(s): %d done, %d ruined",
+	"wait.timed_out": "bj stopped waiting after %s with %d job(s) still going",
+
+	// Pause messages
+	"pause.no_running": "bj isn't doing anything right now. Nothing to pause!",
+	"resume.no_paused": "bj isn't taking a breather. Nothing to resume!",
+
 	// Retry messages
 	"retry.no_failed": "bj hasn't ruined anything yet. Nothing to retry!",
 
+	// Grep messages
+	"grep.prefix":     "[%d] %s:",
+	"grep.no_matches": "bj looked everywhere, nothing matches '%s'",
+
 	// Logs messages
 	"logs.no_jobs": "bj hasn't done anything yet. Get it started first!",
 
+	// Show messages
+	"show.id":             "ID",
+	"show.name":           "Name",
+	"show.command":        "Command",
+	"show.directory":      "Directory",
+	"show.status":         "Status",
+	"show.exit_code":      "Exit code",
+	"show.pid":            "PID",
+	"show.started":        "Started",
+	"show.ended":          "Ended",
+	"show.duration":       "Duration",
+	"show.paused":         "Paused",
+	"show.paused_for":     "Paused for",
+	"show.mode":           "Mode",
+	"show.attempts":       "Attempts",
+	"show.timeout":        "Timeout",
+	"show.after":          "After",
+	"show.after_any":      "After any",
+	"show.queue":          "Queue",
+	"show.ok_codes":       "OK codes",
+	"show.fail_if":        "Fail if",
+	"show.env":            "Env",
+	"show.log":            "Log",
+	"show.stdout":         "Stdout",
+	"show.stderr":         "Stderr",
+	"show.rotation":       "Rotation",
+	"show.timestamps":     "Timestamps",
+	"show.limits":         "Limits",
+	"show.peak_rss":       "Peak RSS",
+	"show.cpu_time":       "CPU time",
+	"show.wall_time":      "Wall time",
+	"show.alive":          "alive",
+	"show.gone":           "gone",
+	"show.process_group":  "%s, process group %d",
+	"show.env_count":      "%d variables (see --json)",
+	"show.rotation_value": "at %s, keeping %d files",
+	"show.yes":            "yes",
+	"show.no_limits":      "none",
+	"show.cpu_value":      "%s user, %s sys",
+	"show.tail":           "Last %d lines of output:",
+
+	// Exit code meanings
+	"exit.timeout":        "timed out after %s",
+	"exit.skipped":        "skipped, a dependency was ruined",
+	"exit.cancelled":      "cancelled while queued, it never ran",
+	"exit.crashloop":      "crash loop, bj stopped restarting it",
+	"exit.bad_output":     "output matched --fail-if-output",
+	"exit.killed_with":    "killed with %s",
+	"exit.success":        "success",
+	"exit.ok_code":        "success, one of its --ok-codes",
+	"exit.lost":           "ended unexpectedly, found by --gc",
+	"exit.not_executable": "command not executable",
+	"exit.not_found":      "command not found",
+	"exit.killed_by":      "killed by %s",
+	"exit.failure":        "failure",
+
 	// Prune messages
 	"prune.nothing": "Nothing to clean up. bj keeps it tidy.",
 	"prune.success": "Wiped away %d finished job(s). Fresh and ready for more.",
 
+	// TUI messages
+	"tui.title":         "bj - %d job(s), %d still going",
+	"tui.keys":          "↑/↓ select  enter view log  x kill  r retry  p pause/resume  P prune  q quit",
+	"tui.confirm_kill":  "Stop job #%d (%s)? y/n",
+	"tui.confirm_prune": "Wipe away %d finished job(s)? y/n",
+	"tui.killing":       "Stopping job #%d...",
+
 	// GC messages
 	"gc.nothing": "No orphaned jobs found. bj keeps track of all its encounters.",
 	"gc.success": "Found %d ruined job(s) that ended without bj noticing. Marked as failed.",
@@ -83,8 +256,13 @@ Usage:
   bj --retry[=N] <command>  Run with retry until success (or N attempts)
   bj --restart <command>    Run with infinite restart on failure (5s delay)
   bj --list                 See what bj is working on
-  bj --logs [id]            Watch bj's performance
-  bj --kill [id]            Stop a job mid-action
+  bj --logs [id|name]       Watch bj's performance
+  bj --show [id|name]       Show everything bj knows about one job
+  bj --tui                  Manage jobs full-screen
+  bj --grep PATTERN         Search every job's logs
+  bj --kill [id|name]       Stop a job mid-action
+  bj --pause [id|name]      Give bj a breather (resume with --resume)
+  bj --wait [id|name...]    Wait for jobs to finish (exits non-zero if any failed)
   bj --retry[=N] [--id ID]  Retry a ruined job
   bj --prune                Clean up when bj is finished
   bj --gc                   Find jobs that were ruined unexpectedly
@@ -97,9 +275,34 @@ Shell Integration:
 Options:
   --retry[=N]         Keep trying until success (or limit to N attempts)
   --restart           Restart command on failure after 5s (infinite loop)
-  --id ID             Specify job ID for --retry (defaults to most recent)
+  --restart=always    Restart command whenever it exits, even successfully
+  --restart-limit N   Give up after N restarts within --restart-window (default 1m)
+  --delay S           Wait S seconds between retries or restarts
+  --backoff MODE      Grow the delay after each failure: fixed, linear or exponential
+  --max-delay DUR     Cap the growing delay (default 5m)
+  --jitter            Randomize each delay so reruns don't all land at once
+  --id ID             Specify job ID or name for --retry (defaults to most recent)
+  --name NAME         Name the job so you can refer to it instead of its ID
+  --timeout DUR       Kill the job if a run takes longer than DUR (e.g. 30s, 5m)
+  --ok-codes N[,N]    Count these exit codes as success (default 0)
+  --fail-if-output RE Count a run as ruined if a line of its output matches RE
+  --env KEY=VAL       Set an environment variable for the job (repeatable)
+  --env-file FILE     Load environment variables from a .env file
+  --cwd DIR           Run the job in DIR instead of the current directory
+  --nice N            Run the job at niceness N (-20 to 19)
+  --ionice CLASS      Run with I/O class idle, best-effort or realtime (Linux)
+  --max-memory SIZE   Limit the job's memory (address space, e.g. 2G)
+  --max-cpu-time DUR  Kill a run once it has used DUR of CPU time (e.g. 10m)
+  --max-open-files N  Limit how many files the job can have open
+  --after ID[,ID]     Wait for these jobs to succeed first (skip if one fails)
+  --after-any ID[,ID] Wait for these jobs to finish first, whatever the result
+  --queue NAME        Run in a queue, waiting for a free slot (see [queues.NAME])
+  --timestamps        Prefix each output line with when it was written
+  --max-log-size SIZE Rotate the job's logs once they reach SIZE (e.g. 50M)
+  --max-log-files N   Keep N rotated log segments per log (default 5)
   --json              Output in JSON format (works with all commands)
   -h, --help          Show this help (use with commands for detailed help)
+  --                  Stop reading options, everything after is the command
 
 Examples:
   bj sleep 10               Let bj handle your sleep needs
@@ -107,6 +310,10 @@ Examples:
   bj --retry npm test       Keep testing until it passes
   bj --retry=3 make build   Try building up to 3 times
   bj --restart ./server     Restart server on crash (infinite loop)
+  bj --name api ./server    Start a job you can call by name
+  bj --timeout 10m ./ci.sh  Give up on a script that hangs
+  bj --after 3 ./deploy.sh  Deploy once job 3 finishes the build
+  bj --queue shards ./p 1   Run shards a few at a time instead of all at once
   bj --list                 Check how bj is doing
   bj --logs                 See bj's latest output
   bj --kill                 Stop the current job abruptly
@@ -122,77 +329,331 @@ Shell Setup:
 	// Help text - list
 	"help.list": `bj --list - See what bj is working on
 
-Usage: bj --list [--running] [--failed] [--done] [--json]
+Usage: bj --list [--running] [--failed] [--done] [--here] [--since TIME] [--cmd TEXT]
+                [--exit-code N] [--dir PATH] [--sort FIELD] [--reverse] [--limit N]
+                [--wide] [--watch [INTERVAL]] [--format FORMAT] [--json]
 
 Shows all tracked jobs with their status, start time, duration, and command.
 Running jobs are shown normally, completed jobs are dimmed, ruined jobs show
-the exit code in red.
+the exit code in red (or "timeout" if the job ran out of time, and the signal
+that ended it, like "killed(SIGTERM)", if it was killed, "crashloop" if a
+--restart job hit its --restart-limit, or "bad-output" if its output matched
+--fail-if-output). Jobs that exited with another of their --ok-codes show
+"done(N)". Jobs started with --after show "waiting" until their dependencies
+finish, and "skipped" if a dependency was ruined. Jobs waiting in a --queue
+show "queued #N" with their place in line, and "cancelled" if they were
+killed before getting a slot. NAME and QUEUE columns appear once any job
+uses them. Retry and restart jobs show how many times they've run in the
+ATTEMPTS column ("2/3" when the number of attempts is limited).
+
+--watch keeps the table up on screen, redrawing it in place every INTERVAL
+(2s unless you say otherwise) with live statuses and durations. Jobs that
+just changed state are highlighted. Ctrl-C stops watching. When the output
+isn't a terminal, the whole table is printed again every INTERVAL instead.
+
+--format prints jobs another way: "table" (the default), "wide" (same as
+--wide), "csv" or "tsv" (a header row, then one row per job, with RFC 3339
+times and the duration in seconds), "jsonl" (one JSON object per line), or a
+Go template run for each job, like '{{.ID}} {{.Command}}'. Templates see every
+job field (.ID, .Name, .Command, .PWD, .StartTime, .ExitCode, ...) and can
+call {{status .}}, {{duration .}}, {{ago .StartTime}}, {{truncate .Command}}
+and {{json .Env}}. \t and \n stand for a tab and a newline.
 
 Filters:
   --running   Only show jobs that are still going
-  --failed    Only show ruined jobs (non-zero exit code)
+  --failed    Only show ruined jobs (exit code not in --ok-codes)
   --done      Only show jobs that finished successfully
+  --here      Only show jobs started in this directory (or below it)
+  --since TIME
+              Only show jobs started at or after TIME (like 2h or 2026-01-02 15:04)
+  --cmd TEXT  Only show jobs whose command contains TEXT
+  --exit-code N
+              Only show jobs that exited with code N (killed jobs have minus
+              the signal number, like -15, and lost or skipped ones -1)
+  --dir PATH  Only show jobs started in PATH (or below it)
+
+The filters, --sort, --reverse and --limit work the same with --ids and
+--prune. --running, --failed and --done add up, the others narrow it down.
+
+Sorting:
+  --sort FIELD
+              Order by start (newest first, the default), duration (longest
+              first), id (lowest first) or status (unfinished, then ruined,
+              then successful)
+  --reverse   Flip the order
+  --limit N   Only show the first N jobs once sorted
 
 Options:
+  --wide      Add MEM (peak memory) and CPU (CPU time used) columns
+  --watch [INTERVAL]
+              Redraw the table every INTERVAL (like 2s, 500ms or 5) until Ctrl-C
+  --format FORMAT
+              Print jobs as table, wide, csv, tsv, jsonl or a Go template
   --json      Output raw job data as JSON
+  --reveal-env
+              Include environment variable values (hidden as *** otherwise)
 
 Examples:
   bj --list           Check how bj is doing
   bj --list --running See what bj is actively working on
   bj --list --failed  Review the ruined jobs
+  bj --list --sort duration --limit 5
+                      Find the 5 slowest jobs
+  bj --list --watch   Keep an eye on bj while your builds run
+  bj --list --format csv > jobs.csv
+                      Export every job to a spreadsheet
+  bj --list --format '{{.ID}}\t{{status .}}\t{{.Command}}'
+                      Print just the columns you care about
   bj --list --json    Get the raw details for scripting`,
 
+	// Help text - grep
+	"help.grep": `bj --grep - Search every job's logs
+
+Usage: bj --grep PATTERN [--running|--failed|--done] [--json]
+
+Searches the logs of every job bj is tracking for lines matching PATTERN (a
+regular expression), oldest job first. Each match is printed with the ID and
+command of the job it came from, so you don't have to map log files back to
+jobs yourself. Rotated and compressed logs are searched too.
+
+Exits with status 1 if nothing matches, like grep.
+
+Options:
+  --running   Only search running jobs
+  --failed    Only search ruined jobs
+  --done      Only search successful jobs
+  --json      Output matches as JSON, each with its job's metadata
+  --reveal-env
+              Include environment variable values (hidden as *** otherwise)
+
+Examples:
+  bj --grep 'panic:'              Find out which job panicked
+  bj --grep --failed 'error'      Search only the ruined jobs
+  bj --grep '(?i)timeout' --json  Case-insensitive search, as JSON`,
+
 	// Help text - logs
 	"help.logs": `bj --logs - Watch bj's performance
 
-Usage: bj --logs [id] [--json]
+Usage: bj --logs [id|name] [--follow] [--stdout|--stderr] [--attempt N]
+               [--since TIME] [--until TIME] [--json]
 
 View the output (stdout/stderr) of a job. If no ID is specified, shows the
 most recent job's logs.
 
+stdout and stderr are also kept separately: --stdout or --stderr shows just
+that stream, while the default view has both interleaved in the order they
+were written.
+
+Jobs started with --timestamps (or with log_timestamps = true in the
+config) have every line prefixed with the time it was written. For those,
+--since and --until show only the lines written in that window. Both take a
+duration ago (10m, 2h), a time today (15:04) or a date (2026-01-02 15:04).
+
+With --follow, bj streams the log to your terminal as it's written instead
+of opening the viewer, stops when the job finishes, and exits with the job's
+exit code (128+N if it was killed by signal N).
+
+Retry and restart jobs record where each run's output starts and ends, so
+--attempt N shows just the Nth run, from its banner to the next one.
+
 Arguments:
-  id        Job ID to view (optional, defaults to latest)
+  id|name   Job ID or name to view (optional, defaults to latest)
 
 Options:
-  --json    Output job metadata and log content as JSON
+  -f, --follow  Stream new output until the job finishes
+  --stdout      Show only the command's stdout
+  --stderr      Show only the command's stderr
+  --attempt N   Show only the Nth run of a retry or restart job
+  --since TIME  Only lines written at or after TIME (timestamped jobs)
+  --until TIME  Only lines written at or before TIME (timestamped jobs)
+  --json        Output job metadata and log content as JSON
+  --reveal-env  Include environment variable values (hidden as *** otherwise)
 
 Examples:
-  bj --logs         See bj's latest output
-  bj --logs 5       Inspect a specific session
-  bj --logs --json  Get logs in JSON format`,
+  bj --logs                   See bj's latest output
+  bj --logs 5                 Inspect a specific session
+  bj --logs api               Inspect the job named "api"
+  bj --logs 5 -f && notify    Watch job #5 and get notified if it succeeds
+  bj --logs 5 --stderr        Only show what job #5 complained about
+  bj --logs 5 --attempt 2     See how job #5's second try went
+  bj --logs api --since 3h    See what "api" has been up to lately
+  bj --logs --json            Get logs in JSON format`,
 
 	// Help text - prune
 	"help.prune": `bj --prune - Clean up when bj is finished
 
-Usage: bj --prune [--json]
+Usage: bj --prune [--failed|--done] [--here] [--since TIME] [--cmd TEXT]
+                 [--exit-code N] [--dir PATH] [--sort FIELD] [--reverse] [--limit N]
+                 [--json]
 
 Removes all completed jobs (any exit code) from the job list and deletes
 their log files. Only running jobs are kept. If all jobs are pruned, the
 ID counter resets to 1.
 
+To prune selectively, pick the finished jobs to remove with the same filters,
+--sort, --reverse and --limit as --list (see bj --list --help). --limit N
+removes the first N finished jobs that match, in --sort order.
+
 Options:
   --json    Output prune count as JSON
 
 Examples:
-  bj --prune        Wipe the slate clean after bj is done`,
+  bj --prune        Wipe the slate clean after bj is done
+  bj --prune --failed --since 1h
+                    Clear out the last hour's ruined jobs
+  bj --prune --sort start --reverse --limit 20
+                    Prune the 20 oldest finished jobs`,
 
 	// Help text - kill
 	"help.kill": `bj --kill - Make bj stop what it's doing
 
-Usage: bj --kill [id] [--json]
+Usage:
+  bj --kill [id|name] [--signal SIG] [--grace DUR] [--json]
+  bj --kill --all [--queue NAME] [--failed-deps] [--signal SIG] [--grace DUR] [--json]
+
+Terminates a running job. Sends SIGTERM (or --signal) to the process group,
+stopping the entire job tree, and waits for it to exit. If it's still around
+after the grace period, bj sends SIGKILL. The signal that actually ended the
+job is recorded. If no ID is specified, kills the most recent running job.
+A job still waiting in its --queue is just called off and shows "cancelled".
+
+Arguments:
+  id|name   Job ID or name to kill (optional, defaults to latest running)
+
+Options:
+  --signal SIG  Signal to send first: HUP, INT, QUIT, KILL, USR1, USR2, ALRM,
+                TERM or a number (default: TERM)
+  --grace DUR   How long to wait before using SIGKILL (default: 5s)
+  --all         Kill every unfinished job (running, waiting or queued)
+  --queue NAME  With --all, only kill jobs in this queue
+  --failed-deps With --all, only kill jobs started --after or --after-any a
+                job that was ruined
+  --json        Output killed job info as JSON
+
+Examples:
+  bj --kill                      Stop the latest job mid-stroke
+  bj --kill 5                    Pull out of job #5 specifically
+  bj --kill api                  Stop the running job named "api"
+  bj --kill api --signal INT     Ask nicely with Ctrl-C instead
+  bj --kill 5 --grace 30s        Give job #5 time to clean up
+  bj --kill --all                Stop everything bj is doing
+  bj --kill --all --queue shards Call off the rest of a queue
+  bj --kill --all --failed-deps  Stop whatever was riding on a ruined job`,
+
+	// Help text - wait
+	"help.wait": `bj --wait - Wait for bj to finish
 
-Terminates a running job. Sends SIGTERM to the process group, stopping
-the entire job tree. If no ID is specified, kills the most recent running job.
+Usage: bj --wait [id|name...] [--timeout DUR] [--json]
+
+Blocks until the given jobs have finished, printing each one as it ends and
+a summary at the end. Without arguments, waits for every unfinished job that
+was started from the current directory. Jobs whose process disappeared
+without reporting back are detected (as with --gc) and count as ruined.
+
+Exit status is 0 if every job succeeded, 1 if any were ruined, and 124 if
+--timeout ran out first. Handy as a join point for scripts that start
+several jobs in parallel.
+
+Arguments:
+  id|name   Job IDs or names to wait for (optional)
+
+Options:
+  --timeout DUR   Give up waiting after DUR (e.g. 30s, 10m)
+  --json          Output the results as JSON
+
+Examples:
+  bj --wait                    Wait for everything started here
+  bj --wait 3 4 5              Wait for jobs #3, #4 and #5
+  bj --wait build --timeout 5m Wait up to 5 minutes for "build"
+  bj --wait && ./deploy.sh     Deploy only if everything succeeded`,
+
+	// Help text - pause
+	"help.pause": `bj --pause / --resume - Give bj a breather
+
+Usage:
+  bj --pause [id|name] [--json]
+  bj --resume [id|name] [--json]
+
+--pause freezes a running job by sending SIGSTOP to its process group, giving
+the CPU back without losing any progress. --resume sends SIGCONT so it picks
+up right where it left off. Paused jobs show as "paused" in --list, and time
+spent paused doesn't count towards the job's duration.
+
+Without an ID, --pause picks the most recent running job and --resume the
+most recent paused one.
 
 Arguments:
-  id        Job ID to kill (optional, defaults to latest running)
+  id|name   Job ID or name (optional)
 
 Options:
-  --json    Output killed job info as JSON
+  --json    Output job info as JSON
+
+Examples:
+  bj --pause            Pause the latest job
+  bj --pause build      Pause the job named "build"
+  bj --resume           Pick up the latest paused job again
+  bj --resume 5         Resume job #5`,
+
+	// Help text - show
+	"help.show": `bj --show - Look at one job up close
+
+Usage: bj --show [id|name] [--lines N] [--format FORMAT] [--json]
+
+Shows everything bj knows about one job (the latest if you don't say which),
+with nothing truncated: the full command and the directory it ran in, its
+status, PID and whether that process is still alive, exact start and end
+times, duration, and the exit code along with what it means ("command not
+found", "killed by SIGTERM", ...).
+
+It also shows how the job was launched (retry or restart policy, timeout,
+dependencies, queue, --ok-codes, --fail-if-output), its log files and their
+size, the limits it runs under (--nice, --ionice, --max-memory,
+--max-cpu-time, --max-open-files) and the resources it has used: peak memory
+(RSS), user and system CPU time, and wall time, added up over every run.
+Fields that don't apply to the job are left out.
+
+Last comes the end of the job's log, 10 lines unless you say otherwise.
+
+--format prints just the job's own line in any --list format, like
+--format '{{.PWD}}' to get the directory it ran in.
+
+Options:
+  --lines N   Show the last N lines of output (0 for none)
+  --format FORMAT
+              Print the job as table, wide, csv, tsv, jsonl or a Go template
+              (see bj --list --help)
+  --json      Output the job's data as JSON, plus its status, whether it's
+              alive, duration, log size, exit code meaning and last lines
+  --reveal-env
+              Include environment variable values (hidden as *** otherwise)
+
+Examples:
+  bj --show             Show the latest job
+  bj --show api         Show the job named "api"
+  bj --show 5 --lines 50
+                        Show job #5 and the last 50 lines of its output`,
+
+	// Help text - tui
+	"help.tui": `bj --tui - Manage jobs full-screen
+
+Usage: bj --tui [--running] [--failed] [--done] [--here]
+
+Takes over the terminal with the job list on top and the selected job's
+latest output below. Both update live as jobs start, finish and write
+output. The filters work like they do for --list.
+
+Keys:
+  ↑/↓ or k/j        Select a job (PgUp/PgDn, Home/End or g/G to jump)
+  enter or v        View the job's whole log in the viewer from bj.toml
+  x                 Kill the job (asks first, --signal and --grace apply)
+  r                 Retry a ruined job once, like bj --retry=1 --id
+  p                 Pause the job, or resume it if it's paused
+  P                 Prune the finished jobs on screen (asks first)
+  q or Ctrl-C       Quit
 
 Examples:
-  bj --kill         Stop the latest job mid-stroke
-  bj --kill 5       Pull out of job #5 specifically`,
+  bj --tui            Keep an eye on everything
+  bj --tui --here     Just the jobs started in this directory`,
 
 	// Help text - gc
 	"help.gc": `bj --gc - Find jobs that ended unexpectedly
@@ -212,21 +673,45 @@ Examples:
 	// Help text - restart
 	"help.restart": `bj --restart - Keep a command running forever
 
-Usage: bj --restart <command>
+Usage: bj --restart[=always] [--restart-limit N [--restart-window DUR]] <command>
 
 Runs a command in the background and automatically restarts it if it fails.
-Unlike --retry, there's no limit to restarts - the command keeps trying
-until it succeeds. After each failure, bj waits 5 seconds before trying again.
+Unlike --retry, there's no limit to restarts unless you set one - the
+command keeps trying until it succeeds. After each failure, bj waits 5
+seconds before trying again (set restart_delay in the config to change
+that, or --delay for one job).
+With --restart=always it also restarts after the command exits successfully,
+like a service supervisor would.
+
+A command that crashes right away would otherwise restart forever. With
+--restart-limit N, bj gives up when the command would need more than N
+restarts within the --restart-window, and marks the job "crashloop".
 
 Perfect for long-running services that should stay up indefinitely.
 
 Options:
-  --json    Output job info as JSON
+  --restart=always     Restart after every exit, not just failures
+  --restart-limit N    Give up after N restarts within the window
+  --restart-window DUR Window --restart-limit counts restarts in (default 1m)
+  --delay S            Wait S seconds before each restart
+  --backoff MODE       Grow the delay after each failure: fixed, linear or
+                       exponential
+  --max-delay DUR      Cap the growing delay (default 5m)
+  --jitter             Randomize each delay so crashing jobs don't restart in sync
+  --timeout DUR        Kill a run that takes longer than DUR and restart it
+  --max-log-size SIZE  Rotate the log once it reaches SIZE (e.g. 50M), so a
+                       server that runs for weeks can't fill the disk
+  --json               Output job info as JSON
 
 Examples:
   bj --restart ./server          Keep your server coming back for more
   bj --restart python worker.py  Worker that never says die
   bj --restart npm run watch     Dev server that restarts on crash
+  bj --restart --backoff exponential --max-delay 1m ./server
+                                 Back off 5s, 10s, 20s... up to a minute
+  bj --restart=always ./poll.sh  Run a script again every time it finishes
+  bj --restart --restart-limit 5 --restart-window 1m ./server
+                                 Give up if it crashes 6 times in a minute
 
 Note: Unlike --retry, --restart doesn't work with existing jobs. It only
 works with new commands. To stop a restarting job, use bj --kill.`,
@@ -246,15 +731,26 @@ Options:
   --retry         Keep teasing until success (no limit)
   --retry=N       Stop after N attempts (deny after N tries)
   --delay S       Wait S seconds between attempts (default: 1)
-  --id ID         Specify which ruined job to retry (defaults to most recent)
+  --backoff MODE  How the delay grows after each failure:
+                  fixed (default), linear (S, 2S, 3S...) or
+                  exponential (S, 2S, 4S...)
+  --max-delay DUR Cap the growing delay (default 5m)
+  --jitter        Wait a random time between half and all of the delay
+  --timeout DUR   Kill an attempt that runs longer than DUR (counts as ruined)
+  --id ID         Specify which ruined job to retry by ID or name
+                  (defaults to most recent)
   --json          Output job info as JSON
 
 Examples:
   bj --retry npm test              Keep running tests until they pass
   bj --retry=3 make build          Try building up to 3 times
   bj --retry --delay 5 curl ...    Wait 5 seconds between attempts
+  bj --retry --backoff exponential --jitter curl ...
+                                   Back off 1s, 2s, 4s... with some randomness
+  bj --retry=3 --timeout 1m ./t.sh Give each attempt a minute to finish
   bj --retry                       Retry the most recent ruined job
   bj --retry --id 5                Retry job #5 until success
+  bj --retry --id api              Retry the latest job named "api"
   bj --retry=3 --delay 10 --id 5   Retry job #5 up to 3 times, 10s apart`,
 
 	// Help text - completion
@@ -352,26 +848,396 @@ on individual commands. They'll treat you right.
 .B \-\-list
 See what bj has been up to. Add
 .BR \-\-running ", " \-\-failed ", or " \-\-done
-to filter by... performance.
+to filter by... performance, and
+.B \-\-here
+for just the jobs started in (or below) the current directory.
+.B \-\-wide
+adds each job's peak memory and CPU time. Retry and restart jobs show how many runs
+they've had in the
+.B ATTEMPTS
+column.
+.TP
+.BI \-\-watch " [interval]"
+With
+.BR \-\-list ,
+keep the table on screen and redraw it in place every
+.I interval
+(default 2s), highlighting jobs that just changed state, until Ctrl-C.
+When stdout isn't a terminal, the full table is printed again each time.
+.TP
+.BI \-\-format " format"
+With
+.BR \-\-list ", " \-\-show " or " \-\-ids ,
+print jobs as
+.B table
+(the default),
+.B wide
+(like
+.BR \-\-wide ),
+.B csv
+or
+.B tsv
+(a header row, then one row per job),
+.B jsonl
+(one JSON object per line), or a Go
+.I text/template
+run for each job, like
+.BR "'{{.ID}} {{.Command}}'" .
+Templates see every job field and can call
+.BR status ", " duration ", " ago ", " truncate " and " json ;
+.B \et
+and
+.B \en
+stand for a tab and a newline.
+.TP
+.BI \-\-sort " field" ", \-\-reverse, \-\-limit" " n"
+With
+.BR \-\-list ", " \-\-ids " or " \-\-prune ,
+order jobs by
+.B start
+(newest first, the default),
+.B duration
+(longest first),
+.B id
+(lowest first) or
+.B status
+(unfinished, then ruined, then successful),
+.B \-\-reverse
+the order, and keep only the first
+.I n
+jobs.
+.TP
+.BI \-\-cmd " text" ", \-\-exit\-code" " n" ", \-\-dir" " path"
+With
+.BR \-\-list ", " \-\-ids " or " \-\-prune ,
+only pick jobs whose command contains
+.IR text ,
+that exited with code
+.IR n ,
+or that were started in (or below)
+.IR path .
+Killed jobs exit with minus the signal number, like \-15,
+and lost or skipped ones with \-1.
+.B \-\-since
+.I time
+picks jobs started at or after
+.IR time .
+These narrow down
+.BR \-\-running ", " \-\-failed ", " \-\-done " and " \-\-here ,
+so
+.B bj \-\-prune \-\-failed \-\-cmd make
+only prunes ruined make jobs.
+.TP
+.BI \-\-name " name"
+Give a job a name so you can call it something other than a number.
+Works anywhere a job ID does. Only one running job can answer to a
+given name at a time.
 .TP
-.BI \-\-logs " [id]"
+.BI \-\-grep " pattern"
+Looking for something? Searches every job's logs for lines matching the
+regular expression
+.I pattern
+and prints them prefixed with the job's ID and command. Narrow it down with
+.BR \-\-running ", " \-\-failed " or " \-\-done .
+Exits 1 if nothing matches.
+.TP
+.BI \-\-logs " [id|name]"
 Watch bj's output. Every moan, groan, and triumphant success message.
 Defaults to the most recent job if you can't remember which one.
 .TP
-.BI \-\-kill " [id]"
+.BR \-f ", " \-\-follow
+With
+.BR \-\-logs ,
+stream the output live until the job finishes, then exit with its exit
+code. Perfect for
+.BR "bj \-\-logs 5 \-f && notify" .
+.TP
+.BR \-\-stdout ", " \-\-stderr
+With
+.BR \-\-logs ,
+show only what the command wrote to that stream instead of both
+interleaved. Combine with
+.B \-f
+to follow just the errors.
+.TP
+.BI \-\-attempt " n"
+With
+.B \-\-logs
+on a
+.B \-\-retry
+or
+.B \-\-restart
+job, show only the output of its nth run. Each run is recorded with its
+start and end time, exit code and where its output sits in the log.
+.TP
+.BI \-\-since " time" ", \-\-until" " time"
+With
+.B \-\-logs
+on a job started with
+.BR \-\-timestamps ,
+show only the lines written in that window. Takes a duration ago
+.RB ( 10m ),
+a time today
+.RB ( 03:00 )
+or a date
+.RB ( "2026-01-02 03:00" ).
+.TP
+.BI \-\-kill " [id|name]"
 Sometimes you need to stop things abruptly. No judgment.
+Sends SIGTERM to the job's process group and waits for it to leave. If
+it's still hanging around after the grace period, SIGKILL settles it.
+The signal that actually ended the job is recorded.
+.TP
+.BI \-\-signal " sig"
+With
+.BR \-\-kill ,
+the signal to send first, by name (\fBINT\fR, \fBSIGHUP\fR) or number.
+.TP
+.BI \-\-grace " duration"
+With
+.BR \-\-kill ,
+how long to wait before reaching for SIGKILL (default 5s).
+.TP
+.B \-\-all
+With
+.BR \-\-kill ,
+stop every unfinished job at once. Narrow it down with
+.BR \-\-queue .
 .TP
 .BR \-\-retry [ =\fIN\fR ]
 bj doesn't give up easily. Use alone to retry a ruined job, or with a
 command to keep trying until satisfaction (or N attempts, whichever
 comes first).
 .TP
+.BR \-\-restart [ =always ]
+For the ones that never quit. Runs the command and restarts it whenever
+it fails, after
+.B restart_delay
+seconds (default 5). With
+.BR =always ,
+it's restarted after a clean exit too.
+.TP
+.BI \-\-restart\-limit " n"
+With
+.BR \-\-restart ,
+give up once the command has been restarted more than
+.I n
+times within the restart window, and mark the job
+.BR crashloop .
+.TP
+.BI \-\-restart\-window " duration"
+The window
+.B \-\-restart\-limit
+counts restarts in (default 1m).
+.TP
 .BI \-\-delay " secs"
-Pace yourself. Wait between retry attempts.
+Pace yourself. Wait between retry attempts (default 1s) or restarts
+(default
+.BR restart_delay ,
+5s).
+.TP
+.BI \-\-backoff " fixed|linear|exponential"
+With
+.B \-\-retry
+or
+.BR \-\-restart ,
+how the delay grows after each failure:
+.B fixed
+waits the same every time,
+.B linear
+adds the delay once more per failure and
+.B exponential
+doubles it. The banner in the log shows each computed delay.
+.TP
+.BI \-\-max\-delay " duration"
+The longest a growing delay gets (default 5m).
+.TP
+.B \-\-jitter
+Keep it unpredictable. Each delay becomes a random time between half
+and all of the computed one, so jobs that failed together don't all
+come back at once.
+.TP
+.BI \-\-after " id[,id...]"
+Good things come to those who wait. The job sits in the
+.B waiting
+state until every listed job (by ID or name) has finished, then runs
+only if they all succeeded. Otherwise it's marked
+.B skipped
+without running.
+.TP
+.BI \-\-after\-any " id[,id...]"
+Like
+.BR \-\-after ,
+but the job runs once the listed jobs finish, whether they succeeded or not.
+.TP
+.BI \-\-queue " name"
+Take a number. Only
+.B max_parallel
+jobs from the same queue run at once (set in
+.BR [queues.\fIname\fB] ,
+default 1); the rest are
+.B queued
+and start in order as slots free up.
+.TP
+.BI \-\-timeout " duration"
+Everyone has limits. Gives each run a deadline like
+.B 30s
+or
+.BR 5m .
+Past it, the whole process group gets SIGTERM, then SIGKILL if it
+still won't take the hint, and the job is marked
+.BR timeout .
+With
+.BR \-\-retry ,
+a timeout counts as a ruined attempt.
+.TP
+.BI \-\-ok\-codes " n[,n...]"
+Not every exit is a failure. Counts these exit codes as success instead of
+just 0, for
+.BR \-\-retry ,
+.BR \-\-restart ,
+.BR \-\-after ,
+the colors in
+.B \-\-list
+and the
+.B \-\-failed
+and
+.B \-\-done
+filters. Codes other than 0 show as
+.BR done(\fIn\fB) .
+.TP
+.BI \-\-fail\-if\-output " regex"
+Exit codes can lie. Counts a run as ruined if a line of its output matches
+.IR regex ,
+even when it exits cleanly. A job that finishes that way is marked
+.BR bad\-output .
+.TP
+.BI \-\-cwd " dir"
+Run the job in
+.I dir
+instead of the current directory. No need to
+.B cd
+first.
+.TP
+.BI \-\-nice " n"
+Run the job at niceness
+.I n
+(\-20 to 19) so it doesn't hog the CPU.
+.TP
+.BI \-\-ionice " class"
+Run the job in I/O scheduling class
+.BR idle ", " best\-effort " or " realtime
+(Linux only).
+.TP
+.BI \-\-max\-memory " size"
+Limit the job's address space
+.RB ( RLIMIT_AS ),
+like
+.BR 2G .
+Allocations past it fail.
+.TP
+.BI \-\-max\-cpu\-time " duration"
+Kill a run once it has used this much CPU time
+.RB ( RLIMIT_CPU ).
+.TP
+.BI \-\-max\-open\-files " n"
+Limit how many files the job can have open at once
+.RB ( RLIMIT_NOFILE ).
+.IP
+Limits apply to the command and everything it starts, are recorded on the
+job so
+.B \-\-retry \-\-id
+reruns it the same way, and default to the
+.BR nice ", " ionice ", " max_memory ", " max_cpu_time " and " max_open_files
+config options.
+.TP
+.BI \-\-show " [id|name]"
+Show everything bj knows about one job, untruncated: the full command and
+directory, status, PID and whether it's alive, exact start and end times,
+the exit code and what it means, how it was launched, its log files and their
+size, its limits, peak memory, CPU and wall time, and the last lines of its
+output.
+.TP
+.BI \-\-lines " n"
+With
+.BR \-\-show ,
+print the last
+.I n
+lines of the job's output (default 10, 0 for none).
+.TP
+.B \-\-tui
+Manage jobs full-screen: the job list on top and the selected job's latest
+output below, updated live. Keys select a job (arrows or j/k), view its log
+in the configured
+.B viewer
+(enter), kill it (x), retry it (r), pause or resume it (p), prune the
+finished jobs shown (P) and quit (q). Needs a terminal.
+.TP
+.BI \-\-env " key=value"
+Set an environment variable for the job. Repeat it for more.
+.TP
+.BI \-\-env\-file " file"
+Load environment variables from a
+.I .env
+file (KEY=VALUE lines, # comments). Later
+.B \-\-env
+flags win.
+.IP
+Either way, bj records the job's environment when it's launched (the
+variables matching
+.BR env_allowlist ,
+plus these) and puts it back for every run, so
+.B \-\-retry \-\-id
+reruns a job the way it was started.
+.TP
+.B \-\-timestamps
+Kiss and tell. Prefixes every line the job writes with the exact time it
+was written (also on for every job with
+.BR "log_timestamps = true" ).
+Lets
+.BR \-\-logs " " \-\-since " and " \-\-until
+pick out what happened at 3am.
+.TP
+.BI \-\-max\-log\-size " size"
+Size matters. Once a log reaches
+.I size
+(like
+.B 500K
+or
+.BR 50M )
+it's rotated into numbered segments, so a
+.B \-\-restart
+server can't fill up the disk. Defaults to
+.BR max_log_size .
+.TP
+.BI \-\-max\-log\-files " n"
+How many rotated segments to keep per log before the oldest goes (default
+.BR max_log_files ,
+or 5).
+.B \-\-logs
+stitches the segments back together.
+.TP
+.BI \-\-wait " [id|name...]"
+Patience. Blocks until the jobs finish (by default, everything started
+from the current directory), then exits non-zero if any of them were
+ruined. With
+.BR \-\-timeout ,
+gives up after a while and exits 124.
+.TP
+.BI \-\-pause " [id|name]"
+Need a breather? Freezes the job with SIGSTOP without losing any
+progress. Paused time doesn't count towards its duration.
+.TP
+.BI \-\-resume " [id|name]"
+Back at it. Sends SIGCONT to a paused job.
 .TP
 .B \-\-prune
 Clean up when bj is finished. Removes completed jobs and their logs.
-A tidy bj is a happy bj.
+A tidy bj is a happy bj. Takes the same filters,
+.BR \-\-sort ", " \-\-reverse " and " \-\-limit
+as
+.B \-\-list
+to prune just some of them.
 .TP
 .B \-\-gc
 Find jobs that were unexpectedly ruined. Sometimes things end badly
@@ -423,6 +1289,18 @@ Don't be weird about it.
 log_dir = "logs"
 viewer = "less"
 auto_prune_hours = 24
+log_timestamps = false
+max_log_size = "50M"
+max_log_files = 5
+restart_delay = 5
+env_allowlist = ["PATH", "HOME", "AWS_*"]
+nice = 10
+max_memory = "4G"
+compress_logs = true
+compress_min_size = "64K"
+
+[queues.shards]
+max_parallel = 4
 .fi
 .RE
 .PP
@@ -490,12 +1368,64 @@ complete -c bj -l list -d "See what bj is working on"
 complete -c bj -l running -d "Filter: only running jobs"
 complete -c bj -l failed -d "Filter: only ruined jobs"
 complete -c bj -l done -d "Filter: only successful jobs"
+complete -c bj -n "__fish_seen_argument -l list" -l wide -d "Add memory and CPU columns"
+complete -c bj -n "__fish_seen_argument -l list" -l watch -d "Redraw the list until Ctrl-C"
+complete -c bj -n "__fish_seen_argument -l list -l show -l ids" -l format -d "Print jobs as a named format or Go template" -xa "table wide csv tsv jsonl"
+complete -c bj -l here -d "Filter: only jobs started in this directory"
+complete -c bj -n "__fish_seen_argument -l list -l ids -l prune" -l since -d "Filter: only jobs started since (e.g. 2h)" -x
+complete -c bj -n "__fish_seen_argument -l list -l ids -l prune" -l cmd -d "Filter: only commands containing this" -x
+complete -c bj -n "__fish_seen_argument -l list -l ids -l prune" -l exit-code -d "Filter: only jobs that exited with this code" -x
+complete -c bj -n "__fish_seen_argument -l list -l ids -l prune" -l dir -d "Filter: only jobs started in this directory" -xa "(__fish_complete_directories)"
+complete -c bj -n "__fish_seen_argument -l list -l ids -l prune" -l sort -d "Order jobs by" -xa "start duration id status"
+complete -c bj -n "__fish_seen_argument -l list -l ids -l prune" -l reverse -d "Flip the order"
+complete -c bj -n "__fish_seen_argument -l list -l ids -l prune" -l limit -d "Only the first N jobs" -x
 complete -c bj -l logs -d "Watch bj's performance"
+complete -c bj -l grep -d "Search every job's logs" -x
+complete -c bj -n "__fish_seen_argument -l logs" -s f -l follow -d "Stream output until the job finishes"
+complete -c bj -n "__fish_seen_argument -l logs" -l stdout -d "Show only stdout"
+complete -c bj -n "__fish_seen_argument -l logs" -l stderr -d "Show only stderr"
+complete -c bj -n "__fish_seen_argument -l show" -l lines -d "Show the last N lines of output" -x
+complete -c bj -n "__fish_seen_argument -l list -l show -l logs -l grep" -l reveal-env -d "Include environment values in JSON"
+complete -c bj -n "__fish_seen_argument -l logs" -l attempt -d "Show only one run of a retry/restart job" -x
+complete -c bj -n "__fish_seen_argument -l logs" -l since -d "Only lines written since (e.g. 10m, 15:04)" -x
+complete -c bj -n "__fish_seen_argument -l logs" -l until -d "Only lines written until (e.g. 10m, 15:04)" -x
 complete -c bj -l kill -d "Stop a job mid-action"
-complete -c bj -l restart -d "Restart on failure with 5s delay"
+complete -c bj -l pause -d "Pause a running job"
+complete -c bj -l wait -d "Wait for jobs to finish"
+complete -c bj -l resume -d "Resume a paused job"
+complete -c bj -l restart -d "Restart on failure, or after every exit with =always"
+complete -c bj -l restart-limit -d "Give up after this many restarts in the window" -x
+complete -c bj -l restart-window -d "Window for --restart-limit (e.g. 1m)" -x
 complete -c bj -l retry -d "Keep going until bj finishes"
-complete -c bj -l delay -d "Seconds to wait between retry attempts"
-complete -c bj -l id -d "Specify job ID for --retry" -xa "(bj --ids --failed 2>/dev/null)"
+complete -c bj -l delay -d "Seconds to wait between retries or restarts"
+complete -c bj -l backoff -d "How the delay grows after each failure" -xa "fixed linear exponential"
+complete -c bj -l max-delay -d "Cap the growing delay (e.g. 1m)" -x
+complete -c bj -l jitter -d "Randomize each delay"
+complete -c bj -l id -d "Specify job ID or name for --retry" -xa "(bj --ids --failed 2>/dev/null; bj --names --failed 2>/dev/null)"
+complete -c bj -l name -d "Name the job" -x
+complete -c bj -l timeout -d "Kill a run after this long (e.g. 30s, 5m)" -x
+complete -c bj -l ok-codes -d "Exit codes that count as success (e.g. 0,1)" -x
+complete -c bj -l fail-if-output -d "Fail a run whose output matches this regex" -x
+complete -c bj -l show -d "Show one job's details"
+complete -c bj -l tui -d "Manage jobs full-screen"
+complete -c bj -l nice -d "Run at this niceness (-20 to 19)" -x
+complete -c bj -l ionice -d "Run in this I/O class" -xa "idle best-effort realtime"
+complete -c bj -l max-memory -d "Limit memory (e.g. 2G)" -x
+complete -c bj -l max-cpu-time -d "Limit CPU time per run (e.g. 10m)" -x
+complete -c bj -l max-open-files -d "Limit open files" -x
+complete -c bj -l cwd -d "Run the job in this directory" -xa "(__fish_complete_directories)"
+complete -c bj -l env -d "Set an environment variable (KEY=VALUE)" -x
+complete -c bj -l env-file -d "Load environment variables from a .env file" -rF
+complete -c bj -l timestamps -d "Prefix each output line with when it was written"
+complete -c bj -l max-log-size -d "Rotate logs at this size (e.g. 50M)" -x
+complete -c bj -l max-log-files -d "Rotated log segments to keep" -x
+complete -c bj -l after -d "Wait for jobs to succeed first" -xa "(bj --ids 2>/dev/null; bj --names 2>/dev/null)"
+complete -c bj -l after-any -d "Wait for jobs to finish first" -xa "(bj --ids 2>/dev/null; bj --names 2>/dev/null)"
+complete -c bj -l queue -d "Run in a concurrency-limited queue" -x
+complete -c bj -l signal -d "Signal for --kill" -xa "TERM INT HUP QUIT KILL USR1 USR2 ALRM"
+complete -c bj -l grace -d "Wait this long before SIGKILL" -x
+complete -c bj -l all -d "Kill every unfinished job"
+complete -c bj -n "__fish_seen_argument -l all" -l failed-deps -d "Only jobs after a failed job"
 complete -c bj -l prune -d "Clean up when bj is finished"
 complete -c bj -l gc -d "Find ruined jobs after a crash"
 complete -c bj -l json -d "Output in JSON format"
@@ -505,9 +1435,17 @@ complete -c bj -l completion -d "Output shell completions" -xa "fish zsh"
 complete -c bj -l init -d "Output prompt integration" -xa "fish zsh"
 complete -c bj -l man -d "Output manual page"
 
-# Job ID completion for --logs and --kill
+# Job ID and name completion for --logs and --kill
 complete -c bj -n "__fish_seen_argument -l logs" -a "(bj --ids 2>/dev/null)" -d "Job ID"
+complete -c bj -n "__fish_seen_argument -l logs" -a "(bj --names 2>/dev/null)" -d "Job name"
 complete -c bj -n "__fish_seen_argument -l kill" -a "(bj --ids --running 2>/dev/null)" -d "Running job ID"
+complete -c bj -n "__fish_seen_argument -l kill" -a "(bj --names --running 2>/dev/null)" -d "Running job name"
+complete -c bj -n "__fish_seen_argument -l wait" -a "(bj --ids --running 2>/dev/null)" -d "Running job ID"
+complete -c bj -n "__fish_seen_argument -l wait" -a "(bj --names --running 2>/dev/null)" -d "Running job name"
+complete -c bj -n "__fish_seen_argument -l pause" -a "(bj --ids --running 2>/dev/null)" -d "Running job ID"
+complete -c bj -n "__fish_seen_argument -l pause" -a "(bj --names --running 2>/dev/null)" -d "Running job name"
+complete -c bj -n "__fish_seen_argument -l resume" -a "(bj --ids --running 2>/dev/null)" -d "Running job ID"
+complete -c bj -n "__fish_seen_argument -l resume" -a "(bj --names --running 2>/dev/null)" -d "Running job name"
 `,
 
 	// Shell completions - zsh
@@ -517,21 +1455,27 @@ complete -c bj -n "__fish_seen_argument -l kill" -a "(bj --ids --running 2>/dev/
 #          (ensure ~/.zsh/completions is in your fpath)
 
 _bj_job_ids() {
-    local -a job_ids
+    local -a job_ids job_names
     job_ids=(${(f)"$(bj --ids 2>/dev/null)"})
+    job_names=(${(f)"$(bj --names 2>/dev/null)"})
     _describe -t job-ids 'job ID' job_ids
+    _describe -t job-names 'job name' job_names
 }
 
 _bj_running_job_ids() {
-    local -a job_ids
+    local -a job_ids job_names
     job_ids=(${(f)"$(bj --ids --running 2>/dev/null)"})
+    job_names=(${(f)"$(bj --names --running 2>/dev/null)"})
     _describe -t job-ids 'running job ID' job_ids
+    _describe -t job-names 'running job name' job_names
 }
 
 _bj_failed_job_ids() {
-    local -a job_ids
+    local -a job_ids job_names
     job_ids=(${(f)"$(bj --ids --failed 2>/dev/null)"})
+    job_names=(${(f)"$(bj --names --failed 2>/dev/null)"})
     _describe -t job-ids 'ruined job ID' job_ids
+    _describe -t job-names 'ruined job name' job_names
 }
 
 _bj() {
@@ -540,12 +1484,63 @@ _bj() {
         '--running[Filter: only running jobs]' \
         '--failed[Filter: only ruined jobs]' \
         '--done[Filter: only successful jobs]' \
+        '--wide[Add memory and CPU columns]' \
+        '--watch[Redraw the list until Ctrl-C]::interval:' \
+        '--format[Print jobs as a named format or Go template]:format:(table wide csv tsv jsonl)' \
+        '--show[Show one job'\''s details]:job ID:_bj_job_ids' \
+        '--tui[Manage jobs full-screen]' \
+        '--lines[Show the last N lines of output]:lines:' \
+        '--here[Filter: only jobs started in this directory]' \
+        '--cmd[Filter: only commands containing this]:text:' \
+        '--exit-code[Filter: only jobs that exited with this code]:exit code:' \
+        '--dir[Filter: only jobs started in this directory]:directory:_directories' \
+        '--sort[Order jobs by]:field:(start duration id status)' \
+        '--reverse[Flip the order]' \
+        '--limit[Only the first N jobs]:count:' \
         '--logs[Watch bj'\''s performance]:job ID:_bj_job_ids' \
+        '--grep[Search every job'\''s logs]:pattern:' \
+        {-f,--follow}'[Stream output until the job finishes]' \
+        '(--stderr)--stdout[Show only stdout]' \
+        '(--stdout)--stderr[Show only stderr]' \
+        '--attempt[Show only one run of a retry/restart job]:attempt:' \
+        '--since[Only lines written or jobs started since]:time:' \
+        '--until[Only lines written until]:time:' \
+        '--reveal-env[Include environment values in JSON]' \
         '--kill[Stop a job mid-action]:job ID:_bj_running_job_ids' \
-        '--restart[Restart on failure with 5s delay]' \
+        '--wait[Wait for jobs to finish]:*:job ID:_bj_running_job_ids' \
+        '--pause[Pause a running job]:job ID:_bj_running_job_ids' \
+        '--resume[Resume a paused job]:job ID:_bj_running_job_ids' \
+        '--restart=-[Restart on failure, or after every exit with =always]::policy:(always on-failure)' \
+        '--restart-limit[Give up after this many restarts in the window]:count:' \
+        '--restart-window[Window for --restart-limit]:duration:' \
         '--retry=-[Keep going until bj finishes]:max attempts:' \
-        '--delay[Seconds between retry attempts]:seconds:' \
-        '--id[Specify job ID for --retry]:job ID:_bj_failed_job_ids' \
+        '--delay[Seconds between retries or restarts]:seconds:' \
+        '--backoff[How the delay grows after each failure]:backoff:(fixed linear exponential)' \
+        '--max-delay[Cap the growing delay]:duration:' \
+        '--jitter[Randomize each delay]' \
+        '--id[Specify job ID or name for --retry]:job ID:_bj_failed_job_ids' \
+        '--name[Name the job]:name:' \
+        '--timeout[Kill a run after this long]:duration:' \
+        '--ok-codes[Exit codes that count as success]:exit codes:' \
+        '--fail-if-output[Fail a run whose output matches this regex]:pattern:' \
+        '--nice[Run at this niceness]:niceness:' \
+        '--ionice[Run in this I/O class]:class:(idle best-effort realtime)' \
+        '--max-memory[Limit memory]:size:' \
+        '--max-cpu-time[Limit CPU time per run]:duration:' \
+        '--max-open-files[Limit open files]:count:' \
+        '--cwd[Run the job in this directory]:directory:_directories' \
+        '*--env[Set an environment variable]:KEY=VALUE:' \
+        '*--env-file[Load environment variables from a .env file]:env file:_files' \
+        '--timestamps[Prefix each output line with when it was written]' \
+        '--max-log-size[Rotate logs at this size]:size:' \
+        '--max-log-files[Rotated log segments to keep]:count:' \
+        '--after[Wait for jobs to succeed first]:job ID:_bj_job_ids' \
+        '--after-any[Wait for jobs to finish first]:job ID:_bj_job_ids' \
+        '--queue[Run in a concurrency-limited queue]:queue:' \
+        '--signal[Signal for --kill]:signal:(TERM INT HUP QUIT KILL USR1 USR2 ALRM)' \
+        '--grace[Wait this long before SIGKILL]:duration:' \
+        '--all[Kill every unfinished job]' \
+        '--failed-deps[With --all, only jobs after a failed job]' \
         '--prune[Clean up when bj is finished]' \
         '--gc[Find ruined jobs after a crash]' \
         '--json[Output in JSON format]' \
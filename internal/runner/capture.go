@@ -0,0 +1,279 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/metruzanca/bj/internal/tracker"
+)
+
+// TimestampFormat is the layout of the timestamp that prefixes each output
+// line of jobs started with timestamps, followed by a single space. The fixed
+// width keeps the log columns aligned.
+const TimestampFormat = "2006-01-02T15:04:05.000000-07:00"
+
+// maxPendingLine caps how much of an unterminated line is held back before
+// it's written anyway (progress bars and the like never send a newline)
+const maxPendingLine = 64 * 1024
+
+// PartialLineDelay is how long an unterminated line is held back waiting for
+// the rest of it, so prompts and progress bars still show up in the logs
+const PartialLineDelay = 250 * time.Millisecond
+
+// streamWriter receives one of a command's output streams and writes it to
+// that stream's own log and to the combined log shared with the other stream.
+// Output is passed on a whole line at a time under a lock shared by both
+// writers, so lines from stdout and stderr interleave without being split,
+// unless a line stays unfinished for PartialLineDelay.
+type streamWriter struct {
+	mu         *sync.Mutex // shared by the stdout and stderr writers of a run
+	combined   io.Writer
+	stream     io.Writer
+	timestamps bool           // prefix each line with the time it was written
+	failIf     *regexp.Regexp // output lines matching this fail the run
+	matched    *bool          // set once a line matches failIf, shared like mu
+
+	pendingMu sync.Mutex  // guards the fields below, the idle flush runs on its own goroutine
+	pending   []byte      // output not written yet, the start of an unfinished line
+	idle      *time.Timer // writes out pending once the command stops writing for a while
+	partial   []byte      // the start of the current line, already written but not yet matched
+	midLine   bool        // the last output written stopped partway through a line
+}
+
+func (w *streamWriter) Write(p []byte) (int, error) {
+	w.pendingMu.Lock()
+	defer w.pendingMu.Unlock()
+
+	w.pending = append(w.pending, p...)
+
+	end := bytes.LastIndexByte(w.pending, '\n') + 1
+	if end == 0 && len(w.pending) >= maxPendingLine {
+		end = len(w.pending)
+	}
+	if end > 0 {
+		if err := w.emit(w.pending[:end], false); err != nil {
+			return 0, err
+		}
+		w.pending = append(w.pending[:0], w.pending[end:]...)
+	}
+
+	if len(w.pending) > 0 {
+		if w.idle == nil {
+			w.idle = time.AfterFunc(PartialLineDelay, w.flushIdle)
+		} else {
+			w.idle.Reset(PartialLineDelay)
+		}
+	}
+	return len(p), nil
+}
+
+// flushIdle writes out an unfinished line the command has stopped adding to
+func (w *streamWriter) flushIdle() {
+	w.pendingMu.Lock()
+	defer w.pendingMu.Unlock()
+	if len(w.pending) > 0 {
+		w.emit(w.pending, false)
+		w.pending = w.pending[:0]
+	}
+}
+
+// Flush writes out a final line that didn't end with a newline
+func (w *streamWriter) Flush() error {
+	w.pendingMu.Lock()
+	defer w.pendingMu.Unlock()
+	if w.idle != nil {
+		w.idle.Stop()
+	}
+
+	err := w.emit(w.pending, true)
+	w.pending = w.pending[:0]
+	return err
+}
+
+// emit writes b to both logs. final means no more output follows, so an
+// unfinished line is matched against failIf as it is.
+func (w *streamWriter) emit(b []byte, final bool) error {
+	matched := w.failIf != nil && w.match(b, final)
+	if w.timestamps {
+		b = w.stamp(b)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if matched {
+		*w.matched = true
+	}
+	if len(b) == 0 {
+		return nil
+	}
+
+	if _, err := w.combined.Write(b); err != nil {
+		return err
+	}
+	_, err := w.stream.Write(b)
+	return err
+}
+
+// match reports whether any line finished by b matches failIf. A line written
+// in pieces is only matched once it's complete, or once it's as long as
+// maxPendingLine or final.
+func (w *streamWriter) match(b []byte, final bool) bool {
+	text := append(w.partial, b...)
+	w.partial = nil
+
+	matched := false
+	for _, line := range bytes.SplitAfter(text, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		if line[len(line)-1] != '\n' && !final && len(line) < maxPendingLine {
+			w.partial = bytes.Clone(line)
+			continue
+		}
+		if w.failIf.Match(bytes.TrimSuffix(line, []byte("\n"))) {
+			matched = true
+		}
+	}
+	return matched
+}
+
+// stamp prefixes each line in b with the current time, except for the rest of
+// a line that was started by an earlier write
+func (w *streamWriter) stamp(b []byte) []byte {
+	prefix := time.Now().Format(TimestampFormat) + " "
+
+	stamped := make([]byte, 0, len(b)+len(prefix))
+	for len(b) > 0 {
+		if !w.midLine {
+			stamped = append(stamped, prefix...)
+		}
+		end := bytes.IndexByte(b, '\n') + 1
+		if end == 0 {
+			end = len(b)
+		}
+		stamped = append(stamped, b[:end]...)
+		w.midLine = b[end-1] != '\n'
+		b = b[end:]
+	}
+	return stamped
+}
+
+// jobLogs are the log files a job writes to, opened once by Exec and shared by
+// all of its runs: the combined log with both streams and bj's banners, and
+// one log per stream
+type jobLogs struct {
+	mu         sync.Mutex
+	combined   *rotatingLog
+	stdout     io.WriteCloser
+	stderr     io.WriteCloser
+	timestamps bool
+	dropped    int64          // bytes of the combined log rotation deleted before it was opened
+	failIf     *regexp.Regexp // the job's FailIfOutput pattern, if it has one
+	matched    bool           // the current run's output matched failIf
+}
+
+// openJobLogs opens a job's logs for appending, rotating each one separately
+// once it reaches the job's size limit. onDrop is called with the size of each
+// segment of the combined log that rotation deletes.
+func openJobLogs(job tracker.Job, onDrop func(n int64)) (*jobLogs, error) {
+	logs := &jobLogs{timestamps: job.Timestamps, dropped: job.LogDropped}
+	if job.FailIfOutput != "" {
+		re, err := regexp.Compile(job.FailIfOutput)
+		if err != nil {
+			return nil, fmt.Errorf("invalid output pattern: %w", err)
+		}
+		logs.failIf = re
+	}
+
+	open := func(path string) (io.WriteCloser, error) {
+		if path == "" {
+			// Jobs launched before separate capture only have the combined log
+			return nopWriteCloser{io.Discard}, nil
+		}
+		return openRotatingLog(path, job.MaxLogSize, job.MaxLogFiles)
+	}
+
+	var err error
+	if logs.combined, err = openRotatingLog(job.LogFile, job.MaxLogSize, job.MaxLogFiles); err != nil {
+		return nil, fmt.Errorf("failed to open log: %w", err)
+	}
+	logs.combined.onDrop = onDrop
+	if logs.stdout, err = open(job.StdoutFile); err != nil {
+		logs.Close()
+		return nil, fmt.Errorf("failed to open stdout log: %w", err)
+	}
+	if logs.stderr, err = open(job.StderrFile); err != nil {
+		logs.Close()
+		return nil, fmt.Errorf("failed to open stderr log: %w", err)
+	}
+	return logs, nil
+}
+
+// attach points a command's stdout and stderr at the logs. The returned
+// function writes out any unterminated last lines once the command has exited.
+func (l *jobLogs) attach(cmd *exec.Cmd) func() {
+	l.mu.Lock()
+	l.matched = false
+	l.mu.Unlock()
+
+	stdout := &streamWriter{mu: &l.mu, combined: l.combined, stream: l.stdout, timestamps: l.timestamps, failIf: l.failIf, matched: &l.matched}
+	stderr := &streamWriter{mu: &l.mu, combined: l.combined, stream: l.stderr, timestamps: l.timestamps, failIf: l.failIf, matched: &l.matched}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	// Without this, a background process holding the pipes open would keep
+	// Wait from returning long after the command itself has exited
+	cmd.WaitDelay = OutputDrainTimeout
+
+	return func() {
+		stdout.Flush()
+		stderr.Flush()
+	}
+}
+
+// offset returns where the next write to the combined log lands, counted from
+// the start of the log including anything rotation has deleted
+func (l *jobLogs) offset() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.dropped + l.combined.written
+}
+
+// outputFailed reports whether the last run's output matched the job's
+// FailIfOutput pattern
+func (l *jobLogs) outputFailed() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.matched
+}
+
+// banner writes one of bj's own status lines to the combined log
+func (l *jobLogs) banner(format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintf(l.combined, "=== "+format+" ===\n", args...)
+}
+
+func (l *jobLogs) Close() {
+	if l.combined != nil {
+		l.combined.Close()
+	}
+	for _, w := range []io.WriteCloser{l.stdout, l.stderr} {
+		if w != nil {
+			w.Close()
+		}
+	}
+}
+
+// nopWriteCloser adds a no-op Close to a writer
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
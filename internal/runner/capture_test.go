@@ -0,0 +1,131 @@
+package runner
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testWriter returns a streamWriter whose logs are in-memory buffers, read
+// them under mu while the writer may still be flushing
+func testWriter(failIf string) (w *streamWriter, combined, stream *bytes.Buffer, mu *sync.Mutex) {
+	mu = &sync.Mutex{}
+	combined, stream = &bytes.Buffer{}, &bytes.Buffer{}
+	w = &streamWriter{mu: mu, combined: combined, stream: stream, matched: new(bool)}
+	if failIf != "" {
+		w.failIf = regexp.MustCompile(failIf)
+	}
+	return w, combined, stream, mu
+}
+
+func locked(mu *sync.Mutex, buf *bytes.Buffer) string {
+	mu.Lock()
+	defer mu.Unlock()
+	return buf.String()
+}
+
+func TestStreamWriterHoldsPartialLines(t *testing.T) {
+	w, combined, stream, mu := testWriter("")
+
+	w.Write([]byte("one\ntw"))
+	if got := locked(mu, combined); got != "one\n" {
+		t.Errorf("combined = %q, want only the finished line", got)
+	}
+
+	w.Write([]byte("o\n"))
+	if got := locked(mu, combined); got != "one\ntwo\n" {
+		t.Errorf("combined = %q, want both lines", got)
+	}
+	if got := locked(mu, stream); got != "one\ntwo\n" {
+		t.Errorf("stream = %q, want both lines", got)
+	}
+}
+
+func TestStreamWriterFlushesIdlePartialLine(t *testing.T) {
+	w, combined, _, mu := testWriter("")
+
+	// A prompt never gets its newline, it still has to show up
+	w.Write([]byte("Password: "))
+	time.Sleep(PartialLineDelay + 200*time.Millisecond)
+	if got := locked(mu, combined); got != "Password: " {
+		t.Errorf("combined = %q after going idle, want the prompt", got)
+	}
+
+	// Progress bars redraw with \r and only end with a newline
+	w.Write([]byte("\r 50%"))
+	time.Sleep(PartialLineDelay + 200*time.Millisecond)
+	w.Write([]byte("\r100%\n"))
+	if got := locked(mu, combined); got != "Password: \r 50%\r100%\n" {
+		t.Errorf("combined = %q, want every piece once and in order", got)
+	}
+}
+
+func TestStreamWriterFlush(t *testing.T) {
+	w, combined, _, mu := testWriter("")
+
+	w.Write([]byte("no newline"))
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if got := locked(mu, combined); got != "no newline" {
+		t.Errorf("combined = %q, want the unfinished line", got)
+	}
+
+	// Nothing is left for the idle flush to write again
+	time.Sleep(PartialLineDelay + 100*time.Millisecond)
+	if got := locked(mu, combined); got != "no newline" {
+		t.Errorf("combined = %q after Flush went idle, want it unchanged", got)
+	}
+}
+
+func TestStreamWriterTimestampsPartialLines(t *testing.T) {
+	w, combined, _, mu := testWriter("")
+	w.timestamps = true
+
+	w.Write([]byte("start "))
+	time.Sleep(PartialLineDelay + 200*time.Millisecond)
+	w.Write([]byte("end\nnext\n"))
+
+	lines := strings.Split(strings.TrimSuffix(locked(mu, combined), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %q", len(lines), lines)
+	}
+	// The rest of a line that was flushed early isn't stamped again
+	for i, want := range []string{"start end", "next"} {
+		ts, rest, _ := strings.Cut(lines[i], " ")
+		if _, err := time.Parse(TimestampFormat, ts); err != nil || rest != want {
+			t.Errorf("line %d = %q, want a timestamp and %q", i, lines[i], want)
+		}
+	}
+}
+
+func TestStreamWriterMatchesLinesWrittenInPieces(t *testing.T) {
+	w, _, _, _ := testWriter(`^FAILED$`)
+
+	w.Write([]byte("FAIL"))
+	time.Sleep(PartialLineDelay + 200*time.Millisecond)
+	if *w.matched {
+		t.Fatal("matched the start of a line before it was finished")
+	}
+
+	w.Write([]byte("ED\n"))
+	if !*w.matched {
+		t.Error("a line flushed in pieces didn't match once finished")
+	}
+}
+
+func TestStreamWriterMatchesFinalLine(t *testing.T) {
+	w, _, _, _ := testWriter(`FAILED$`)
+
+	w.Write([]byte("ok\nFAILED"))
+	if *w.matched {
+		t.Fatal("matched an unfinished line")
+	}
+	w.Flush()
+	if !*w.matched {
+		t.Error("the last line didn't match once the output ended")
+	}
+}
@@ -0,0 +1,79 @@
+package runner
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// SnapshotEnv returns the current environment as KEY=VAL entries to record on
+// a job, keeping only the variables matching allow (shell patterns like
+// "AWS_*", or "*" for all of them)
+func SnapshotEnv(allow []string) []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		key, _, _ := strings.Cut(kv, "=")
+		for _, pattern := range allow {
+			if ok, _ := path.Match(pattern, key); ok {
+				env = append(env, kv)
+				break
+			}
+		}
+	}
+	return env
+}
+
+// MergeEnv returns base with the entries of extra added, replacing any
+// variable base already sets. Later entries win.
+func MergeEnv(base []string, extra ...string) []string {
+	merged := make([]string, 0, len(base)+len(extra))
+	index := make(map[string]int, len(base)+len(extra))
+	for _, kv := range append(append([]string{}, base...), extra...) {
+		key, _, _ := strings.Cut(kv, "=")
+		if i, ok := index[key]; ok {
+			merged[i] = kv
+			continue
+		}
+		index[key] = len(merged)
+		merged = append(merged, kv)
+	}
+	return merged
+}
+
+// ParseEnvFile reads a .env file into KEY=VAL entries. Blank lines and lines
+// starting with # are skipped, a leading "export " is allowed, and values may
+// be wrapped in single or double quotes.
+func ParseEnvFile(filename string) ([]string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var env []string
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, val, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", n)
+		}
+		val = strings.TrimSpace(val)
+		if len(val) >= 2 && (val[0] == '"' || val[0] == '\'') && val[len(val)-1] == val[0] {
+			val = val[1 : len(val)-1]
+		}
+		env = append(env, key+"="+val)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
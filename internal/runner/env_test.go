@@ -0,0 +1,62 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSnapshotEnv(t *testing.T) {
+	t.Setenv("BJ_KEEP_ONE", "1")
+	t.Setenv("BJ_KEEP_TWO", "2")
+	t.Setenv("BJ_SECRET", "hunter2")
+
+	env := SnapshotEnv([]string{"BJ_KEEP_*"})
+	for _, want := range []string{"BJ_KEEP_ONE=1", "BJ_KEEP_TWO=2"} {
+		if !slices.Contains(env, want) {
+			t.Errorf("snapshot doesn't include %s: %v", want, env)
+		}
+	}
+	if slices.Contains(env, "BJ_SECRET=hunter2") {
+		t.Errorf("snapshot includes BJ_SECRET, which doesn't match")
+	}
+
+	// No patterns records nothing, "*" records everything
+	if env := SnapshotEnv(nil); len(env) != 0 {
+		t.Errorf("SnapshotEnv(nil) = %v, want nothing", env)
+	}
+	if env := SnapshotEnv([]string{"*"}); !slices.Contains(env, "BJ_SECRET=hunter2") {
+		t.Errorf(`SnapshotEnv("*") doesn't include BJ_SECRET`)
+	}
+}
+
+func TestMergeEnv(t *testing.T) {
+	got := MergeEnv([]string{"A=1", "B=2"}, "B=3", "C=4", "A=5")
+	want := []string{"A=5", "B=3", "C=4"}
+	if !slices.Equal(got, want) {
+		t.Errorf("MergeEnv = %v, want %v", got, want)
+	}
+}
+
+func TestParseEnvFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	content := "# settings\n\nexport GREETING=\"hello there\"\nTARGET='single'\nPLAIN = value \nEMPTY=\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ParseEnvFile(path)
+	if err != nil {
+		t.Fatalf("ParseEnvFile: %v", err)
+	}
+	want := []string{"GREETING=hello there", "TARGET=single", "PLAIN=value", "EMPTY="}
+	if !slices.Equal(got, want) {
+		t.Errorf("ParseEnvFile = %q, want %q", got, want)
+	}
+
+	os.WriteFile(path, []byte("OK=1\nnot an assignment\n"), 0600)
+	if _, err := ParseEnvFile(path); err == nil || err.Error() != "line 2: expected KEY=VALUE" {
+		t.Errorf("ParseEnvFile error = %v, want one for line 2", err)
+	}
+}
@@ -0,0 +1,33 @@
+package runner
+
+import (
+	"syscall"
+
+	"github.com/metruzanca/bj/internal/tracker"
+)
+
+// ioprio_set(2) arguments
+const (
+	ioprioWhoProcess  = 1
+	ioprioClassShift  = 13
+	ioprioDefaultData = 4 // the middle of the 0-7 priority levels, as ionice(1) uses
+)
+
+var ioprioClasses = map[string]int{
+	tracker.IOClassRealtime:   1,
+	tracker.IOClassBestEffort: 2,
+	tracker.IOClassIdle:       3,
+}
+
+// setIOClass sets the current process's I/O scheduling class
+func setIOClass(class string) error {
+	prio := ioprioClasses[class] << ioprioClassShift
+	if class != tracker.IOClassIdle {
+		prio |= ioprioDefaultData
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOPRIO_SET, ioprioWhoProcess, 0, uintptr(prio))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package runner
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// setIOClass is only supported on Linux, which has per-process I/O classes
+func setIOClass(class string) error {
+	return fmt.Errorf("I/O classes aren't supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,103 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"syscall"
+	"time"
+
+	"github.com/metruzanca/bj/internal/tracker"
+)
+
+// Confine applies a job's resource limits to the current process and then
+// replaces it with the job's command, so the limits cover the command and
+// everything it starts. It only returns if something went wrong.
+func (r *Runner) Confine(jobID int) error {
+	// Niceness and the I/O class are set per thread on Linux, so stay on the
+	// thread that sets them until it execs the command
+	runtime.LockOSThread()
+
+	job, err := r.tracker.Get(jobID)
+	if err != nil {
+		return fmt.Errorf("failed to load job: %w", err)
+	}
+	if job == nil {
+		return tracker.ErrJobNotFound
+	}
+
+	if err := applyLimits(job.Limits); err != nil {
+		return err
+	}
+
+	shell, err := exec.LookPath(userShell())
+	if err != nil {
+		return fmt.Errorf("failed to find shell: %w", err)
+	}
+	return syscall.Exec(shell, []string{shell, "-c", job.Command}, os.Environ())
+}
+
+// applyLimits sets the current process's niceness, I/O class and rlimits.
+// The caller must have locked its goroutine to the OS thread.
+func applyLimits(l tracker.Limits) error {
+	if l.Nice != 0 {
+		if err := syscall.Setpriority(syscall.PRIO_PROCESS, 0, l.Nice); err != nil {
+			return fmt.Errorf("failed to set niceness %d: %w", l.Nice, err)
+		}
+	}
+	if l.IOClass != "" {
+		if err := setIOClass(l.IOClass); err != nil {
+			return fmt.Errorf("failed to set I/O class %s: %w", l.IOClass, err)
+		}
+	}
+
+	// RLIMIT_CPU counts whole seconds, round up so a limit never becomes 0
+	cpuSecs := (l.MaxCPUTime + time.Second - 1) / time.Second
+	for _, limit := range []struct {
+		name     string
+		resource int
+		value    uint64
+	}{
+		{"memory", syscall.RLIMIT_AS, uint64(l.MaxMemory)},
+		{"CPU time", syscall.RLIMIT_CPU, uint64(cpuSecs)},
+		{"open files", syscall.RLIMIT_NOFILE, uint64(l.MaxOpenFiles)},
+	} {
+		if limit.value == 0 {
+			continue
+		}
+		if err := setRlimit(limit.resource, limit.value); err != nil {
+			return fmt.Errorf("failed to limit %s: %w", limit.name, err)
+		}
+	}
+	return nil
+}
+
+// setRlimit lowers both the soft and hard limit of a resource to value, so
+// the command can't raise it again. A hard limit that's already lower stays.
+func setRlimit(resource int, value uint64) error {
+	var rlim syscall.Rlimit
+	if err := syscall.Getrlimit(resource, &rlim); err != nil {
+		return err
+	}
+	rlim.Cur = min(value, rlim.Max)
+	rlim.Max = rlim.Cur
+	return syscall.Setrlimit(resource, &rlim)
+}
+
+// runUsage converts the resource usage the kernel reported for a finished run
+// (which includes everything the run waited for) into a tracker.Usage
+func runUsage(state *os.ProcessState, wall time.Duration) tracker.Usage {
+	usage := tracker.Usage{
+		UserTime: state.UserTime(),
+		SysTime:  state.SystemTime(),
+		WallTime: wall,
+	}
+	if ru, ok := state.SysUsage().(*syscall.Rusage); ok {
+		usage.PeakRSS = int64(ru.Maxrss)
+		if runtime.GOOS != "darwin" {
+			usage.PeakRSS *= 1024 // Linux reports kilobytes, macOS bytes
+		}
+	}
+	return usage
+}
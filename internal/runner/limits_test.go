@@ -0,0 +1,84 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/metruzanca/bj/internal/tracker"
+)
+
+// TestLimitsHelper isn't a real test: TestApplyLimits runs the test binary
+// again with it, since limits can't be lifted from a process once applied
+func TestLimitsHelper(t *testing.T) {
+	if os.Getenv("BJ_LIMITS_HELPER") != "1" {
+		t.Skip("helper process for TestApplyLimits")
+	}
+	runtime.LockOSThread()
+	err := applyLimits(tracker.Limits{
+		Nice:         5,
+		MaxMemory:    1 << 30,
+		MaxCPUTime:   1500 * time.Millisecond,
+		MaxOpenFiles: 64,
+	})
+	if err != nil {
+		fmt.Println("error:", err)
+		os.Exit(1)
+	}
+
+	var as, cpu, nofile syscall.Rlimit
+	syscall.Getrlimit(syscall.RLIMIT_AS, &as)
+	syscall.Getrlimit(syscall.RLIMIT_CPU, &cpu)
+	syscall.Getrlimit(syscall.RLIMIT_NOFILE, &nofile)
+	nice, _ := syscall.Getpriority(syscall.PRIO_PROCESS, 0)
+	fmt.Printf("as=%d/%d cpu=%d/%d nofile=%d/%d\n%d\n", as.Cur, as.Max, cpu.Cur, cpu.Max, nofile.Cur, nofile.Max, nice)
+	os.Exit(0)
+}
+
+func TestApplyLimits(t *testing.T) {
+	cmd := exec.Command(os.Args[0], "-test.run=^TestLimitsHelper$")
+	cmd.Env = append(os.Environ(), "BJ_LIMITS_HELPER=1")
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("helper failed: %v\n%s", err, out)
+	}
+
+	// Soft and hard limits both drop so the command can't raise them again,
+	// and CPU time rounds up to whole seconds
+	limits, priority, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	want := fmt.Sprintf("as=%d/%d cpu=2/2 nofile=64/64", 1<<30, 1<<30)
+	if limits != want {
+		t.Errorf("helper has limits %q, want %q", limits, want)
+	}
+
+	// Linux reports 20 - nice, the BSDs the niceness itself
+	base, _ := syscall.Getpriority(syscall.PRIO_PROCESS, 0)
+	if p, _ := strconv.Atoi(priority); p != base-5 && p != base+5 {
+		t.Errorf("helper has priority %d, want 5 nicer than %d", p, base)
+	}
+}
+
+func TestRunUsage(t *testing.T) {
+	cmd := exec.Command("sh", "-c", "i=0; while [ $i -lt 20000 ]; do i=$((i+1)); done")
+	start := time.Now()
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	usage := runUsage(cmd.ProcessState, time.Since(start))
+
+	if usage.PeakRSS < 1<<10 {
+		t.Errorf("PeakRSS = %d, want it in bytes", usage.PeakRSS)
+	}
+	if usage.UserTime+usage.SysTime <= 0 {
+		t.Errorf("CPU time = %s + %s, want some", usage.UserTime, usage.SysTime)
+	}
+	if usage.WallTime <= 0 {
+		t.Errorf("WallTime = %s, want the time given", usage.WallTime)
+	}
+}
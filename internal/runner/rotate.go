@@ -0,0 +1,115 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+)
+
+// rotatingLog appends to a log file, moving it aside into numbered segments
+// once it reaches maxSize: path.1 is the most recent segment, path.2 the one
+// before it, and so on up to maxFiles. Older segments are deleted.
+type rotatingLog struct {
+	path     string
+	maxSize  int64 // 0 = never rotate
+	maxFiles int   // rotated segments kept next to the live file
+	f        *os.File
+	size     int64
+	written  int64         // bytes in every segment when opened, plus everything written since
+	onDrop   func(n int64) // called with the size of each segment rotation deletes
+}
+
+// openRotatingLog opens a log for appending, rotating it once it reaches
+// maxSize bytes (0 = no limit)
+func openRotatingLog(path string, maxSize int64, maxFiles int) (*rotatingLog, error) {
+	l := &rotatingLog{path: path, maxSize: maxSize, maxFiles: maxFiles}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	for i := 1; i <= maxFiles; i++ {
+		if info, err := os.Stat(fmt.Sprintf("%s.%d", path, i)); err == nil {
+			l.written += info.Size()
+		}
+	}
+	l.written += l.size
+	return l, nil
+}
+
+func (l *rotatingLog) open() error {
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	l.f = f
+	l.size = info.Size()
+	return nil
+}
+
+// Write appends p to the live file, rotating whenever it would grow past the
+// size limit. Segments are only split between lines, except for a single line
+// longer than the limit, which gets a segment to itself.
+func (l *rotatingLog) Write(p []byte) (int, error) {
+	written := 0
+	for l.maxSize > 0 && l.size+int64(len(p)) > l.maxSize {
+		// Fill the live file with as many whole lines as still fit
+		room := l.maxSize - l.size
+		if room < 0 {
+			room = 0
+		}
+		cut := bytes.LastIndexByte(p[:room], '\n') + 1
+		if cut == 0 && l.size == 0 {
+			if cut = bytes.IndexByte(p, '\n') + 1; cut == 0 {
+				cut = len(p)
+			}
+		}
+
+		n, err := l.f.Write(p[:cut])
+		written += n
+		l.size += int64(n)
+		l.written += int64(n)
+		if err != nil {
+			return written, err
+		}
+		if p = p[cut:]; len(p) == 0 {
+			return written, nil
+		}
+
+		if err := l.rotate(); err != nil {
+			return written, fmt.Errorf("failed to rotate log: %w", err)
+		}
+	}
+
+	n, err := l.f.Write(p)
+	l.size += int64(n)
+	l.written += int64(n)
+	return written + n, err
+}
+
+// rotate shifts every segment up by one, dropping the oldest, and starts a
+// new live file
+func (l *rotatingLog) rotate() error {
+	if err := l.f.Close(); err != nil {
+		return err
+	}
+
+	oldest := fmt.Sprintf("%s.%d", l.path, l.maxFiles)
+	if info, err := os.Stat(oldest); err == nil && os.Remove(oldest) == nil && l.onDrop != nil {
+		l.onDrop(info.Size())
+	}
+	for i := l.maxFiles - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", l.path, i), fmt.Sprintf("%s.%d", l.path, i+1))
+	}
+	if err := os.Rename(l.path, l.path+".1"); err != nil {
+		return err
+	}
+	return l.open()
+}
+
+func (l *rotatingLog) Close() error {
+	return l.f.Close()
+}
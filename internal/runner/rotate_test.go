@@ -0,0 +1,103 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRotatingLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "job.log")
+	l, err := openRotatingLog(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dropped int64
+	l.onDrop = func(n int64) { dropped += n }
+
+	for i := 1; i <= 6; i++ {
+		fmt.Fprintf(l, "line %d\n", i) // 7 bytes, so one line per segment
+	}
+	l.Close()
+
+	// Only maxFiles segments survive, newest first
+	for file, want := range map[string]string{
+		path:        "line 6\n",
+		path + ".1": "line 5\n",
+		path + ".2": "line 4\n",
+	} {
+		if got := readFile(t, file); got != want {
+			t.Errorf("%s = %q, want %q", filepath.Base(file), got, want)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Error("kept more segments than max_log_files")
+	}
+	if dropped != 21 {
+		t.Errorf("dropped %d bytes, want the 21 in the deleted segments", dropped)
+	}
+	if l.written != 42 {
+		t.Errorf("written = %d, want every byte written", l.written)
+	}
+}
+
+func TestRotatingLogSplitsBetweenLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "job.log")
+	l, _ := openRotatingLog(path, 16, 5)
+
+	// Lines aren't split across segments, even when written together
+	l.Write([]byte("aaaa\nbbbb\ncccc\ndddd\n"))
+	// A line longer than the limit gets a segment to itself
+	l.Write([]byte(strings.Repeat("x", 20) + "\nend\n"))
+	l.Close()
+
+	want := []string{"aaaa\nbbbb\ncccc\n", "dddd\n", strings.Repeat("x", 20) + "\n", "end\n"}
+	got := []string{readFile(t, path+".3"), readFile(t, path+".2"), readFile(t, path+".1"), readFile(t, path)}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("segment %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestRotatingLogReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "job.log")
+	os.WriteFile(path+".1", []byte("older\n"), 0644)
+	os.WriteFile(path, []byte("live\n"), 0644)
+
+	// A later run picks up where the last one left off
+	l, err := openRotatingLog(path, 100, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.written != 11 {
+		t.Errorf("written = %d, want the 11 bytes already in the segments", l.written)
+	}
+	l.Write([]byte("more\n"))
+	l.Close()
+	if got := readFile(t, path); got != "live\nmore\n" {
+		t.Errorf("live log = %q, want it appended to", got)
+	}
+}
+
+func TestRotatingLogUnlimited(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "job.log")
+	l, _ := openRotatingLog(path, 0, 0)
+	l.Write([]byte(strings.Repeat("line\n", 1000)))
+	l.Close()
+
+	if _, err := os.Stat(path + ".1"); !os.IsNotExist(err) {
+		t.Error("rotated a log without a size limit")
+	}
+}
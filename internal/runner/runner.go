@@ -1,10 +1,14 @@
 package runner
 
 import (
+	"errors"
 	"fmt"
+	"math/rand/v2"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -13,12 +17,67 @@ import (
 	"github.com/metruzanca/bj/internal/tracker"
 )
 
+// TimeoutGracePeriod is how long a timed out command gets to exit after
+// SIGTERM before its process group is sent SIGKILL
+const TimeoutGracePeriod = 10 * time.Second
+
+// OutputDrainTimeout is how long Exec keeps reading output after the command
+// exits, for background processes it left behind that still hold its stdout
+// or stderr open
+const OutputDrainTimeout = 2 * time.Second
+
+// DefaultMaxDelay caps linear and exponential backoff when no --max-delay is given
+const DefaultMaxDelay = 5 * time.Minute
+
+// DefaultRestartWindow is the window --restart-limit counts restarts in when
+// no --restart-window is given
+const DefaultRestartWindow = time.Minute
+
+// bannerTimeFormat matches date(1), used by the banners when a job starts
+const bannerTimeFormat = "Mon Jan _2 15:04:05 MST 2006"
+
+// AwaitPollInterval is how often a waiting job checks on its dependencies
+const AwaitPollInterval = time.Second
+
+// stopSignals are the signals that tell Exec the job is being stopped. bj --kill
+// sends them to the wrapper's process group as well as the command's, so Exec
+// outlives them to keep capturing what the command writes while it shuts down.
+var stopSignals = []os.Signal{syscall.SIGTERM, syscall.SIGINT, syscall.SIGHUP, syscall.SIGQUIT}
+
 // Runner handles spawning and tracking background jobs
 type Runner struct {
 	config  *config.Config
 	tracker *tracker.Tracker
 }
 
+// Options holds per-job settings shared by every launch mode
+type Options struct {
+	Name     string        // optional name the job can be addressed by instead of its ID
+	Timeout  time.Duration // kill each run after this long (0 = no timeout)
+	After    []int         // jobs that must succeed before the command runs
+	AfterAny []int         // jobs that must finish (any result) before the command runs
+	Queue    string        // queue to run in, waiting for a free slot if it's full
+
+	Backoff  string        // retry and restart modes: how the delay grows (tracker.Backoff*)
+	MaxDelay time.Duration // retry and restart modes: upper bound on the delay (0 = DefaultMaxDelay)
+	Jitter   bool          // retry and restart modes: randomize each delay
+
+	RestartAlways bool          // restart mode: rerun after a clean exit too
+	RestartLimit  int           // restart mode: give up after this many restarts within RestartWindow (0 = never)
+	RestartWindow time.Duration // restart mode: window RestartLimit counts in (0 = DefaultRestartWindow)
+
+	OkCodes      []int  // exit codes that count as success (nil = just 0)
+	FailIfOutput string // regexp that fails a run when its output matches, even if it exited ok
+
+	Env []string // KEY=VAL entries recorded on the job and set for the command
+
+	Timestamps  bool  // prefix every output line with the time it was written
+	MaxLogSize  int64 // rotate each log once it reaches this many bytes (0 = never)
+	MaxLogFiles int   // rotated segments kept per log
+
+	Limits tracker.Limits // niceness, I/O class and rlimits every run is started with
+}
+
 // New creates a new Runner
 func New(cfg *config.Config, t *tracker.Tracker) *Runner {
 	return &Runner{
@@ -27,225 +86,403 @@ func New(cfg *config.Config, t *tracker.Tracker) *Runner {
 	}
 }
 
-// Run spawns a command in a detached background process
-func (r *Runner) Run(command string) (int, error) {
-	// Get current working directory
-	pwd, err := os.Getwd()
-	if err != nil {
-		return 0, fmt.Errorf("failed to get working directory: %w", err)
-	}
+// Run spawns a command in a detached background process, running it in pwd
+func (r *Runner) Run(command string, pwd string, opts Options) (int, error) {
+	return r.launch(tracker.Job{Command: command, PWD: pwd}, opts)
+}
 
-	// Ensure log directory exists
-	if err := r.config.EnsureLogDir(); err != nil {
-		return 0, fmt.Errorf("failed to create log directory: %w", err)
-	}
+// RunWithRetry spawns a command that will retry on failure
+// maxAttempts of 0 means unlimited retries until success
+// delaySecs is the delay between retries in seconds
+func (r *Runner) RunWithRetry(command string, pwd string, maxAttempts int, delaySecs int, opts Options) (int, error) {
+	return r.launch(tracker.Job{
+		Command:     command,
+		PWD:         pwd,
+		Mode:        tracker.ModeRetry,
+		MaxAttempts: maxAttempts,
+		RetryDelay:  delaySecs,
+	}, opts)
+}
 
-	logDir, err := r.config.LogDirPath()
-	if err != nil {
-		return 0, fmt.Errorf("failed to get log directory: %w", err)
-	}
+// RunWithRestart spawns a command that will restart on failure after a delay
+// The command runs in a loop: on non-zero exit (or any exit with
+// RestartAlways), wait delaySecs and restart, until it hits its RestartLimit
+func (r *Runner) RunWithRestart(command string, pwd string, delaySecs int, opts Options) (int, error) {
+	return r.launch(tracker.Job{
+		Command:    command,
+		PWD:        pwd,
+		Mode:       tracker.ModeRestart,
+		RetryDelay: delaySecs,
+	}, opts)
+}
 
-	// Add job to tracker first to get ID (needed for log filename)
-	jobID, err := r.tracker.Add(command, pwd, "")
+// Complete marks a job as completed (called by the wrapper)
+// If the job belonged to a queue, the next queued job takes its slot.
+func (r *Runner) Complete(jobID int, exitCode int) error {
+	job, err := r.tracker.Get(jobID)
 	if err != nil {
-		return 0, fmt.Errorf("failed to track job: %w", err)
+		return fmt.Errorf("failed to load job: %w", err)
 	}
-
-	// Create log file with timestamp and job ID
-	timestamp := time.Now().Format("20060102-150405")
-	logFileName := fmt.Sprintf("%s-%d.log", timestamp, jobID)
-	logPath := filepath.Join(logDir, logFileName)
-
-	// Update tracker with actual log path
-	if err := r.tracker.UpdateLogPath(jobID, logPath); err != nil {
-		return 0, fmt.Errorf("failed to update log path: %w", err)
+	if err := r.tracker.Complete(jobID, exitCode); err != nil {
+		return err
 	}
-
-	// Create the log file
-	logFile, err := os.Create(logPath)
-	if err != nil {
-		return 0, fmt.Errorf("failed to create log file: %w", err)
-	}
-
-	// Get user's shell from environment for running the command
-	userShell := os.Getenv("SHELL")
-	if userShell == "" {
-		userShell = "/bin/sh"
+	if job != nil && job.Queue != "" {
+		return r.Promote(job.Queue)
 	}
+	return nil
+}
 
-	// Get the path to our own executable
-	selfPath, err := os.Executable()
+// Promote starts queued jobs from the named queue while it has free slots
+func (r *Runner) Promote(queue string) error {
+	jobs, err := r.tracker.Promote(queue, r.config.QueueLimit(queue))
 	if err != nil {
-		logFile.Close()
-		return 0, fmt.Errorf("failed to get executable path: %w", err)
+		return fmt.Errorf("failed to promote queued jobs: %w", err)
 	}
 
-	// Create a wrapper that:
-	// 1. Runs the command in the user's shell
-	// 2. Captures exit code
-	// 3. Calls bj --complete
-	// We use /bin/sh for the wrapper since it needs POSIX syntax for variable assignment
-	wrapperCmd := fmt.Sprintf(`%s -c %s; exitcode=$?; %s --complete %d $exitcode`,
-		userShell, shellQuote(command), shellQuote(selfPath), jobID)
-
-	cmd := exec.Command("/bin/sh", "-c", wrapperCmd)
-	cmd.Dir = pwd
-	cmd.Stdout = logFile
-	cmd.Stderr = logFile
-
-	// Detach the process so it survives parent exit
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		Setsid: true,
+	for _, job := range jobs {
+		// The log was created when the job was queued
+		logFile, err := os.OpenFile(job.LogFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open log file: %w", err)
+		}
+		if err := r.start(job, logFile); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	if err := cmd.Start(); err != nil {
-		logFile.Close()
-		return 0, fmt.Errorf("failed to start command: %w", err)
+// PromoteAll gives every queue with queued jobs a chance to fill free slots,
+// for when jobs end without going through Complete (killed or collected)
+func (r *Runner) PromoteAll() error {
+	jobs, err := r.tracker.List()
+	if err != nil {
+		return fmt.Errorf("failed to load jobs: %w", err)
 	}
 
-	// Save PID for potential kill later
-	if err := r.tracker.UpdatePID(jobID, cmd.Process.Pid); err != nil {
-		// Non-fatal - job will still run, just can't be killed
-		logFile.Close()
-		return jobID, nil
+	seen := make(map[string]bool)
+	for _, job := range jobs {
+		if job.Status != tracker.StatusQueued || seen[job.Queue] {
+			continue
+		}
+		seen[job.Queue] = true
+		if err := r.Promote(job.Queue); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	// Close our handle to the log file - the child process has its own fd
-	logFile.Close()
-
-	return jobID, nil
+// Await blocks until a job's dependencies have finished. It returns true if the
+// command should run; otherwise the job has already been completed as skipped.
+// It's invoked by the wrapper script (bj --await) before the command runs.
+func (r *Runner) Await(jobID int) (bool, error) {
+	job, err := r.tracker.Get(jobID)
+	if err != nil {
+		return false, fmt.Errorf("failed to load job: %w", err)
+	}
+	if job == nil {
+		return false, tracker.ErrJobNotFound
+	}
+
+	fmt.Printf("=== Waiting for job(s) %s ===\n", joinIDs(append(job.After, job.AfterAny...)))
+
+	for {
+		// A dependency whose process died without reporting back would keep us
+		// waiting forever, so mark orphans as failed first
+		r.tracker.GarbageCollect()
+
+		jobs, err := r.tracker.List()
+		if err != nil {
+			return false, fmt.Errorf("failed to load jobs: %w", err)
+		}
+		byID := make(map[int]tracker.Job, len(jobs))
+		for _, j := range jobs {
+			byID[j.ID] = j
+		}
+
+		pending := false
+		for _, id := range job.After {
+			dep, ok := byID[id]
+			switch {
+			case !ok:
+				return false, r.skip(jobID, fmt.Sprintf("job %d is gone", id))
+			case dep.ExitCode == nil:
+				pending = true
+			case !dep.Succeeded():
+				return false, r.skip(jobID, fmt.Sprintf("job %d failed (exit %d)", id, *dep.ExitCode))
+			}
+		}
+		for _, id := range job.AfterAny {
+			if dep, ok := byID[id]; ok && dep.ExitCode == nil {
+				pending = true
+			}
+		}
+
+		if !pending {
+			break
+		}
+		time.Sleep(AwaitPollInterval)
+	}
+
+	if err := r.tracker.Start(jobID); err != nil {
+		return false, fmt.Errorf("failed to start job: %w", err)
+	}
+	fmt.Println("=== Dependencies finished, starting ===")
+	return true, nil
 }
 
-// RunWithRetry spawns a command that will retry on failure
-// maxAttempts of 0 means unlimited retries until success
-// delaySecs is the delay between retries in seconds
-func (r *Runner) RunWithRetry(command string, pwd string, maxAttempts int, delaySecs int) (int, error) {
-	// Ensure log directory exists
-	if err := r.config.EnsureLogDir(); err != nil {
-		return 0, fmt.Errorf("failed to create log directory: %w", err)
+// skip completes a waiting job without running its command
+func (r *Runner) skip(jobID int, reason string) error {
+	fmt.Printf("=== Skipped: %s ===\n", reason)
+	if err := r.tracker.SetStatus(jobID, tracker.StatusSkipped); err != nil {
+		return fmt.Errorf("failed to mark job skipped: %w", err)
 	}
+	return r.Complete(jobID, tracker.ExitSkipped)
+}
 
-	logDir, err := r.config.LogDirPath()
+// Exec runs a job's command in its launch mode, retrying or restarting it as
+// needed, and returns the final exit code. It's invoked by the wrapper script
+// (bj --exec) and owns the job's logs for as long as it runs, writing bj's
+// banners, capturing stdout and stderr separately and rotating the logs.
+func (r *Runner) Exec(jobID int) (int, error) {
+	job, err := r.tracker.Get(jobID)
 	if err != nil {
-		return 0, fmt.Errorf("failed to get log directory: %w", err)
+		return 0, fmt.Errorf("failed to load job: %w", err)
+	}
+	if job == nil {
+		return 0, tracker.ErrJobNotFound
 	}
 
-	// Add job to tracker first to get ID (needed for log filename)
-	jobID, err := r.tracker.Add(command, pwd, "")
+	logs, err := openJobLogs(*job, func(n int64) { r.tracker.AddLogDropped(jobID, n) })
 	if err != nil {
-		return 0, fmt.Errorf("failed to track job: %w", err)
+		return 0, err
+	}
+	defer logs.Close()
+
+	// The command's process group gets the same signal, so there's nothing to
+	// forward - just don't run it again once it has exited
+	stopping := make(chan os.Signal, 1)
+	signal.Notify(stopping, stopSignals...)
+	defer signal.Stop(stopping)
+
+	// Retry and restart jobs keep a record of each run and where its output is
+	endAttempt := func(exitCode int) {}
+	if job.Mode != "" {
+		endAttempt = func(exitCode int) { r.tracker.EndAttempt(jobID, exitCode, logs.offset()) }
+	}
+
+	var exits []time.Time // when a restart job's recent runs ended, for its restart limit
+	for attempt := 1; ; attempt++ {
+		if job.Mode != "" {
+			r.tracker.StartAttempt(jobID, logs.offset())
+		}
+
+		switch {
+		case job.Mode == tracker.ModeRestart:
+			logs.banner("Starting: %s", time.Now().Format(bannerTimeFormat))
+		case job.Mode == tracker.ModeRetry && job.MaxAttempts == 0:
+			logs.banner("Attempt %d", attempt)
+		case job.Mode == tracker.ModeRetry:
+			logs.banner("Attempt %d of %d", attempt, job.MaxAttempts)
+		}
+
+		exitCode, err := r.runOnce(*job, logs, attempt > 1)
+		if err != nil {
+			return 0, err
+		}
+
+		// An ok exit code still fails the run if its output gave it away
+		ok := job.OkExit(exitCode)
+		badOutput := ok && logs.outputFailed()
+		if badOutput {
+			ok = false
+			logs.banner("Output matched %q, counting the run as ruined", job.FailIfOutput)
+		}
+		finish := func() (int, error) {
+			endAttempt(exitCode)
+			if badOutput {
+				r.tracker.SetStatus(jobID, tracker.StatusBadOutput)
+			}
+			return exitCode, nil
+		}
+
+		if job.Mode == "" || (ok && !job.RestartAlways) || stopped(stopping) {
+			return finish()
+		}
+
+		if job.Mode == tracker.ModeRetry && job.MaxAttempts > 0 && attempt >= job.MaxAttempts {
+			logs.banner("All %d attempts ruined", job.MaxAttempts)
+			return finish()
+		}
+
+		// A restart job that keeps exiting is crash looping, so stop feeding it
+		if job.Mode == tracker.ModeRestart && job.RestartLimit > 0 {
+			now := time.Now()
+			exits = append(exits, now)
+			for len(exits) > 0 && now.Sub(exits[0]) > job.RestartWindow {
+				exits = exits[1:]
+			}
+			if len(exits) > job.RestartLimit {
+				logs.banner("Exited %d times within %s, giving up", len(exits), job.RestartWindow)
+				endAttempt(exitCode)
+				r.tracker.SetStatus(jobID, tracker.StatusCrashloop)
+				return exitCode, nil
+			}
+		}
+
+		delay := backoffDelay(*job, attempt)
+		switch {
+		case job.Mode == tracker.ModeRestart && ok:
+			logs.banner("Exited cleanly, restarting in %s...", formatDelay(delay))
+		case job.Mode == tracker.ModeRestart:
+			// Restart on failure forever, until the command succeeds
+			logs.banner("Failed with exit %d, restarting in %s...", exitCode, formatDelay(delay))
+		default:
+			logs.banner("Attempt %d ruined (exit %d), trying again in %s...", attempt, exitCode, formatDelay(delay))
+		}
+		endAttempt(exitCode)
+		select {
+		case <-stopping:
+			return exitCode, nil
+		case <-time.After(delay):
+		}
 	}
+}
 
-	// Create log file with timestamp and job ID
-	timestamp := time.Now().Format("20060102-150405")
-	logFileName := fmt.Sprintf("%s-%d.log", timestamp, jobID)
-	logPath := filepath.Join(logDir, logFileName)
-
-	// Update tracker with actual log path
-	if err := r.tracker.UpdateLogPath(jobID, logPath); err != nil {
-		return 0, fmt.Errorf("failed to update log path: %w", err)
+// stopped reports whether one of stopSignals has arrived
+func stopped(stopping <-chan os.Signal) bool {
+	select {
+	case <-stopping:
+		return true
+	default:
+		return false
 	}
+}
 
-	// Create the log file
-	logFile, err := os.Create(logPath)
-	if err != nil {
-		return 0, fmt.Errorf("failed to create log file: %w", err)
+// backoffDelay returns how long a retry or restart job waits after its nth
+// failed run, growing the job's base delay according to its backoff strategy
+func backoffDelay(job tracker.Job, failures int) time.Duration {
+	base := time.Duration(job.RetryDelay) * time.Second
+	limit := job.MaxDelay
+	if limit == 0 && job.Backoff != "" && job.Backoff != tracker.BackoffFixed {
+		limit = DefaultMaxDelay
 	}
 
-	// Get user's shell from environment for running the command
-	userShell := os.Getenv("SHELL")
-	if userShell == "" {
-		userShell = "/bin/sh"
+	delay := base
+	switch job.Backoff {
+	case tracker.BackoffLinear:
+		delay = base * time.Duration(failures)
+	case tracker.BackoffExponential:
+		// Stop doubling once past the limit so the delay can't overflow
+		for i := 1; i < failures && delay < limit; i++ {
+			delay *= 2
+		}
+	}
+	if limit > 0 && (delay > limit || delay < 0) {
+		delay = limit
 	}
 
-	// Get the path to our own executable
-	selfPath, err := os.Executable()
-	if err != nil {
-		logFile.Close()
-		return 0, fmt.Errorf("failed to get executable path: %w", err)
-	}
-
-	// Create a wrapper that retries until success or max attempts
-	// The wrapper script handles the retry logic
-	var wrapperCmd string
-	if maxAttempts == 0 {
-		// Unlimited retries - keep going until success
-		wrapperCmd = fmt.Sprintf(`
-attempt=1
-while true; do
-  echo "=== Attempt $attempt ===" 
-  %s -c %s
-  exitcode=$?
-  if [ $exitcode -eq 0 ]; then
-    %s --complete %d 0
-    exit 0
-  fi
-  echo "=== Attempt $attempt ruined (exit $exitcode), trying again... ===" 
-  attempt=$((attempt + 1))
-  sleep %d
-done`,
-			userShell, shellQuote(command), shellQuote(selfPath), jobID, delaySecs)
-	} else {
-		// Limited retries
-		wrapperCmd = fmt.Sprintf(`
-attempt=1
-max=%d
-while [ $attempt -le $max ]; do
-  echo "=== Attempt $attempt of $max ===" 
-  %s -c %s
-  exitcode=$?
-  if [ $exitcode -eq 0 ]; then
-    %s --complete %d 0
-    exit 0
-  fi
-  if [ $attempt -lt $max ]; then
-    echo "=== Attempt $attempt ruined (exit $exitcode), trying again... ===" 
-  fi
-  attempt=$((attempt + 1))
-  sleep %d
-done
-echo "=== All %d attempts ruined ===" 
-%s --complete %d $exitcode`,
-			maxAttempts, userShell, shellQuote(command), shellQuote(selfPath), jobID,
-			delaySecs, maxAttempts, shellQuote(selfPath), jobID)
+	// Jitter picks a random delay between half and all of the computed one,
+	// so jobs that failed together don't all come back at the same moment
+	if job.Jitter && delay > 0 {
+		delay = delay/2 + rand.N(delay/2+1)
 	}
+	return delay
+}
 
-	cmd := exec.Command("/bin/sh", "-c", wrapperCmd)
-	cmd.Dir = pwd
-	cmd.Stdout = logFile
-	cmd.Stderr = logFile
+// formatDelay formats a delay for the banners, dropping jitter's sub-millisecond noise
+func formatDelay(d time.Duration) string {
+	return d.Round(time.Millisecond).String()
+}
 
-	// Detach the process so it survives parent exit
+// runOnce runs a job's command a single time in its own process group and
+// returns its exit code
+func (r *Runner) runOnce(job tracker.Job, logs *jobLogs, rerun bool) (int, error) {
+	// A previous attempt may have timed out - this run starts with a clean slate
+	if rerun && job.Timeout > 0 {
+		if err := r.tracker.SetStatus(job.ID, ""); err != nil {
+			return 0, fmt.Errorf("failed to reset status: %w", err)
+		}
+	}
+
+	cmd := exec.Command(userShell(), "-c", job.Command)
+	if !job.Limits.IsZero() {
+		// bj --confine applies the limits to itself, then becomes the command
+		selfPath, err := os.Executable()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get executable path: %w", err)
+		}
+		cmd = exec.Command(selfPath, "--confine", strconv.Itoa(job.ID))
+	}
+	cmd.Stdin = os.Stdin
+
+	// Restore the environment the job was launched with over whatever this bj
+	// inherited, which differs when a queued job is started by another bj
+	if job.Env != nil {
+		cmd.Env = MergeEnv(os.Environ(), job.Env...)
+	}
+	flush := logs.attach(cmd)
+	defer flush()
+
+	// Give the command its own process group so a timeout can take down the
+	// whole command tree without killing the wrapper that's supervising it
 	cmd.SysProcAttr = &syscall.SysProcAttr{
-		Setsid: true,
+		Setpgid: true,
 	}
 
+	started := time.Now()
 	if err := cmd.Start(); err != nil {
-		logFile.Close()
 		return 0, fmt.Errorf("failed to start command: %w", err)
 	}
+	pgid := cmd.Process.Pid
 
-	// Save PID for potential kill later
-	if err := r.tracker.UpdatePID(jobID, cmd.Process.Pid); err != nil {
-		// Non-fatal - job will still run, just can't be killed
-		logFile.Close()
-		return jobID, nil
+	// Record the group so bj --kill can reach it (non-fatal if this fails)
+	r.tracker.UpdatePGID(job.ID, pgid)
+
+	timedOut := make(chan struct{})
+	if job.Timeout > 0 {
+		timer := time.AfterFunc(job.Timeout, func() {
+			close(timedOut)
+			logs.banner("Timed out after %s, terminating", job.Timeout)
+			syscall.Kill(-pgid, syscall.SIGTERM)
+
+			// Escalate if the command ignores SIGTERM
+			time.AfterFunc(TimeoutGracePeriod, func() {
+				syscall.Kill(-pgid, syscall.SIGKILL)
+			})
+		})
+		defer timer.Stop()
 	}
 
-	// Close our handle to the log file - the child process has its own fd
-	logFile.Close()
+	err := cmd.Wait()
+	if errors.Is(err, exec.ErrWaitDelay) {
+		// Something the command started in the background outlived it
+		err = nil
+	}
+	if cmd.ProcessState != nil {
+		r.tracker.AddUsage(job.ID, runUsage(cmd.ProcessState, time.Since(started)))
+	}
 
-	return jobID, nil
-}
+	select {
+	case <-timedOut:
+		if err := r.tracker.SetStatus(job.ID, tracker.StatusTimeout); err != nil {
+			return 0, fmt.Errorf("failed to record timeout: %w", err)
+		}
+		return tracker.ExitTimeout, nil
+	default:
+	}
 
-// Complete marks a job as completed (called by the wrapper)
-func (r *Runner) Complete(jobID int, exitCode int) error {
-	return r.tracker.Complete(jobID, exitCode)
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return 0, fmt.Errorf("failed to wait for command: %w", err)
+	}
+	return shellExitCode(cmd.ProcessState), nil
 }
 
-// RunWithRestart spawns a command that will restart on failure after a delay
-// The command runs in an infinite loop: on non-zero exit, wait 5s and restart
-func (r *Runner) RunWithRestart(command string, pwd string) (int, error) {
+// launch registers a job with the tracker, creates its log file and starts it,
+// unless its queue is full, in which case it stays queued until promoted
+func (r *Runner) launch(job tracker.Job, opts Options) (int, error) {
 	// Ensure log directory exists
 	if err := r.config.EnsureLogDir(); err != nil {
 		return 0, fmt.Errorf("failed to create log directory: %w", err)
@@ -256,56 +493,109 @@ func (r *Runner) RunWithRestart(command string, pwd string) (int, error) {
 		return 0, fmt.Errorf("failed to get log directory: %w", err)
 	}
 
+	job.Name = opts.Name
+	job.Timeout = opts.Timeout
+	job.After = opts.After
+	job.AfterAny = opts.AfterAny
+	job.Queue = opts.Queue
+	job.Backoff = opts.Backoff
+	job.MaxDelay = opts.MaxDelay
+	job.Jitter = opts.Jitter
+	job.RestartAlways = opts.RestartAlways
+	job.RestartLimit = opts.RestartLimit
+	job.RestartWindow = opts.RestartWindow
+	if job.RestartLimit > 0 && job.RestartWindow == 0 {
+		job.RestartWindow = DefaultRestartWindow
+	}
+	job.OkCodes = opts.OkCodes
+	job.FailIfOutput = opts.FailIfOutput
+	job.Env = opts.Env
+	job.Limits = opts.Limits
+	job.Timestamps = opts.Timestamps
+	job.MaxLogSize = opts.MaxLogSize
+	job.MaxLogFiles = opts.MaxLogFiles
+
+	// Jobs with dependencies sit in the waiting state until bj --await lets them go
+	if len(job.After) > 0 || len(job.AfterAny) > 0 {
+		job.Status = tracker.StatusWaiting
+	}
+
 	// Add job to tracker first to get ID (needed for log filename)
-	jobID, err := r.tracker.Add(command, pwd, "")
+	var jobID int
+	if job.Queue != "" {
+		jobID, err = r.tracker.Enqueue(job, r.config.QueueLimit(job.Queue))
+	} else {
+		jobID, err = r.tracker.Add(job)
+	}
 	if err != nil {
 		return 0, fmt.Errorf("failed to track job: %w", err)
 	}
 
-	// Create log file with timestamp and job ID
-	timestamp := time.Now().Format("20060102-150405")
-	logFileName := fmt.Sprintf("%s-%d.log", timestamp, jobID)
-	logPath := filepath.Join(logDir, logFileName)
+	// Create log files with timestamp and job ID: the combined log, plus one
+	// per stream so stdout and stderr can be viewed on their own
+	base := filepath.Join(logDir, fmt.Sprintf("%s-%d", time.Now().Format("20060102-150405"), jobID))
+	logPath := base + ".log"
+	stdoutPath := base + ".stdout.log"
+	stderrPath := base + ".stderr.log"
 
-	// Update tracker with actual log path
-	if err := r.tracker.UpdateLogPath(jobID, logPath); err != nil {
+	// Update tracker with actual log paths
+	if err := r.tracker.UpdateLogPaths(jobID, logPath, stdoutPath, stderrPath); err != nil {
 		return 0, fmt.Errorf("failed to update log path: %w", err)
 	}
 
-	// Create the log file
-	logFile, err := os.Create(logPath)
+	// Create the stream logs up front so they exist even if the command never runs
+	for _, path := range []string{stdoutPath, stderrPath} {
+		f, err := os.Create(path)
+		if err != nil {
+			return 0, fmt.Errorf("failed to create log file: %w", err)
+		}
+		f.Close()
+	}
+
+	// Create the log file. It becomes the wrapper's output while bj --exec
+	// writes to it through its own descriptor, so both must append.
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC|os.O_APPEND, 0644)
 	if err != nil {
 		return 0, fmt.Errorf("failed to create log file: %w", err)
 	}
 
-	// Get user's shell from environment for running the command
-	userShell := os.Getenv("SHELL")
-	if userShell == "" {
-		userShell = "/bin/sh"
+	// Re-read the job to pick up its ID, log path and whether it was queued
+	added, err := r.tracker.Get(jobID)
+	if err != nil {
+		logFile.Close()
+		return 0, fmt.Errorf("failed to load job: %w", err)
 	}
+	if added == nil {
+		logFile.Close()
+		return 0, tracker.ErrJobNotFound
+	}
+	if added.Status == tracker.StatusQueued {
+		// A slot may have freed up while the log was being created
+		logFile.Close()
+		return jobID, r.Promote(job.Queue)
+	}
+
+	if err := r.start(*added, logFile); err != nil {
+		return 0, err
+	}
+	return jobID, nil
+}
 
-	// Get the path to our own executable
+// start spawns the wrapper script for a job, built from its tracker record
+func (r *Runner) start(job tracker.Job, logFile *os.File) error {
 	selfPath, err := os.Executable()
 	if err != nil {
 		logFile.Close()
-		return 0, fmt.Errorf("failed to get executable path: %w", err)
-	}
-
-	// Create a wrapper that restarts on failure with 5 second delay
-	// Runs forever until success, then exits
-	wrapperCmd := fmt.Sprintf(`
-while true; do
-  echo "=== Starting: $(date) ==="
-  %s -c %s
-  exitcode=$?
-  if [ $exitcode -eq 0 ]; then
-    %s --complete %d 0
-    exit 0
-  fi
-  echo "=== Failed with exit $exitcode, restarting in 5s... ==="
-  sleep 5
-done`,
-		userShell, shellQuote(command), shellQuote(selfPath), jobID)
+		return fmt.Errorf("failed to get executable path: %w", err)
+	}
+
+	return r.spawn(job.ID, job.PWD, logFile, wrapperScript(selfPath, job))
+}
+
+// spawn starts the wrapper script detached from the terminal and records its PID
+func (r *Runner) spawn(jobID int, pwd string, logFile *os.File, wrapperCmd string) error {
+	// Close our handle to the log file - the child process has its own fd
+	defer logFile.Close()
 
 	cmd := exec.Command("/bin/sh", "-c", wrapperCmd)
 	cmd.Dir = pwd
@@ -318,21 +608,63 @@ done`,
 	}
 
 	if err := cmd.Start(); err != nil {
-		logFile.Close()
-		return 0, fmt.Errorf("failed to start command: %w", err)
+		return fmt.Errorf("failed to start command: %w", err)
 	}
 
 	// Save PID for potential kill later
-	if err := r.tracker.UpdatePID(jobID, cmd.Process.Pid); err != nil {
-		// Non-fatal - job will still run, just can't be killed
-		logFile.Close()
-		return jobID, nil
+	// Non-fatal if this fails - job will still run, just can't be killed
+	r.tracker.UpdatePID(jobID, cmd.Process.Pid)
+
+	return nil
+}
+
+// wrapperScript returns the POSIX shell script that runs a job through
+// bj --exec and calls bj --complete with the final exit code.
+// We use /bin/sh for the wrapper since it needs POSIX syntax for variable assignment
+func wrapperScript(selfPath string, job tracker.Job) string {
+	self := shellQuote(selfPath)
+	script := fmt.Sprintf(`%s --exec %d; exitcode=$?; %s --complete %d $exitcode`, self, job.ID, self, job.ID)
+	return stopTrap + dependencyGate(selfPath, job) + script
+}
+
+// stopTrap makes the wrapper exit without calling bj --complete once it's been
+// signalled, since bj --kill records the job's result itself. The trap only
+// runs after bj --exec has finished, whichever shell /bin/sh is.
+const stopTrap = "trap 'exit' TERM INT HUP QUIT\n"
+
+// dependencyGate returns the wrapper preamble that blocks until the job's
+// dependencies finish, exiting early if bj --await skipped the job
+func dependencyGate(selfPath string, job tracker.Job) string {
+	if len(job.After) == 0 && len(job.AfterAny) == 0 {
+		return ""
 	}
+	return fmt.Sprintf("%s --await %d || exit 0\n", shellQuote(selfPath), job.ID)
+}
 
-	// Close our handle to the log file - the child process has its own fd
-	logFile.Close()
+// joinIDs formats job IDs as a comma separated list
+func joinIDs(ids []int) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.Itoa(id)
+	}
+	return strings.Join(parts, ", ")
+}
 
-	return jobID, nil
+// userShell returns the user's shell from the environment for running commands
+func userShell() string {
+	if shell := os.Getenv("SHELL"); shell != "" {
+		return shell
+	}
+	return "/bin/sh"
+}
+
+// shellExitCode converts a process state to the exit code a shell would report
+// (128+N for a process killed by signal N)
+func shellExitCode(state *os.ProcessState) int {
+	if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return state.ExitCode()
 }
 
 // shellQuote properly quotes a string for shell execution
@@ -0,0 +1,72 @@
+package runner
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/metruzanca/bj/internal/tracker"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		job      tracker.Job
+		failures int
+		want     time.Duration
+	}{
+		{"fixed", tracker.Job{RetryDelay: 2}, 5, 2 * time.Second},
+		{"fixed ignores the default cap", tracker.Job{RetryDelay: 600}, 1, 10 * time.Minute},
+		{"linear", tracker.Job{RetryDelay: 2, Backoff: tracker.BackoffLinear}, 3, 6 * time.Second},
+		{"exponential", tracker.Job{RetryDelay: 1, Backoff: tracker.BackoffExponential}, 4, 8 * time.Second},
+		{"first failure waits the base delay", tracker.Job{RetryDelay: 3, Backoff: tracker.BackoffExponential}, 1, 3 * time.Second},
+		{"max delay", tracker.Job{RetryDelay: 1, Backoff: tracker.BackoffExponential, MaxDelay: 5 * time.Second}, 10, 5 * time.Second},
+		{"default cap", tracker.Job{RetryDelay: 1, Backoff: tracker.BackoffLinear}, 1000, DefaultMaxDelay},
+		{"no overflow", tracker.Job{RetryDelay: 1, Backoff: tracker.BackoffExponential}, 200, DefaultMaxDelay},
+		{"no delay", tracker.Job{Backoff: tracker.BackoffExponential}, 5, 0},
+	}
+	for _, tt := range tests {
+		if got := backoffDelay(tt.job, tt.failures); got != tt.want {
+			t.Errorf("%s: backoffDelay = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBackoffDelayJitter(t *testing.T) {
+	job := tracker.Job{RetryDelay: 4, Backoff: tracker.BackoffLinear, Jitter: true}
+	for range 100 {
+		// Between half and all of the 8s the second failure waits
+		if got := backoffDelay(job, 2); got < 4*time.Second || got > 8*time.Second {
+			t.Fatalf("backoffDelay with jitter = %s, want 4s to 8s", got)
+		}
+	}
+}
+
+func TestWrapperScript(t *testing.T) {
+	script := wrapperScript("/opt/b j", tracker.Job{ID: 7})
+	if strings.Contains(script, "--await") {
+		t.Errorf("a job without dependencies awaits them:\n%s", script)
+	}
+	if !strings.HasSuffix(script, `'/opt/b j' --exec 7; exitcode=$?; '/opt/b j' --complete 7 $exitcode`) {
+		t.Errorf("wrapper doesn't exec and complete job 7:\n%s", script)
+	}
+
+	// Dependencies gate everything else, and a skipped job never runs
+	for _, job := range []tracker.Job{{ID: 8, After: []int{1}}, {ID: 8, AfterAny: []int{1, 2}}} {
+		script := wrapperScript("bj", job)
+		gate := strings.Index(script, "'bj' --await 8 || exit 0\n")
+		if gate < 0 || gate > strings.Index(script, "--exec") {
+			t.Errorf("wrapper for %v doesn't await before running:\n%s", job, script)
+		}
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	for _, s := range []string{"plain", "with space", "it's", `"double" $HOME \n`, ""} {
+		out, err := exec.Command("sh", "-c", "printf %s "+shellQuote(s)).Output()
+		if err != nil || string(out) != s {
+			t.Errorf("shellQuote(%q) came back from sh as %q, %v", s, out, err)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// Package term puts a terminal into raw mode and reads its size, using
+// nothing but ioctl(2), for bj's full-screen UI
+package term
+
+import (
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// ReadTimeout is how long a read from a raw terminal waits for input. The
+// terminal counts it in tenths of a second.
+const ReadTimeout = 100 * time.Millisecond
+
+// State is a terminal's settings, saved by MakeRaw so Restore can put them back
+type State struct {
+	termios syscall.Termios
+}
+
+// winsize is the terminal size reported by TIOCGWINSZ
+type winsize struct {
+	Rows, Cols, XPixels, YPixels uint16
+}
+
+// IsTerminal reports whether fd refers to a terminal
+func IsTerminal(fd int) bool {
+	var t syscall.Termios
+	return ioctl(fd, ioctlGetTermios, unsafe.Pointer(&t)) == nil
+}
+
+// MakeRaw puts the terminal into raw mode: input arrives a byte at a time
+// without echo, line editing or signals (Ctrl-C is just a key), and output is
+// passed through untouched. Reads give up after ReadTimeout with nothing, so
+// a caller can poll for keys between other work. Returns the previous state
+// for Restore.
+func MakeRaw(fd int) (*State, error) {
+	var old syscall.Termios
+	if err := ioctl(fd, ioctlGetTermios, unsafe.Pointer(&old)); err != nil {
+		return nil, err
+	}
+
+	raw := old
+	raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP |
+		syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	raw.Oflag &^= syscall.OPOST
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 0
+	raw.Cc[syscall.VTIME] = uint8(ReadTimeout / (100 * time.Millisecond))
+	if err := ioctl(fd, ioctlSetTermios, unsafe.Pointer(&raw)); err != nil {
+		return nil, err
+	}
+	return &State{termios: old}, nil
+}
+
+// Restore puts the terminal back the way it was before MakeRaw
+func Restore(fd int, state *State) error {
+	return ioctl(fd, ioctlSetTermios, unsafe.Pointer(&state.termios))
+}
+
+// Size returns the terminal's width and height in characters
+func Size(fd int) (width, height int, err error) {
+	var ws winsize
+	if err := ioctl(fd, syscall.TIOCGWINSZ, unsafe.Pointer(&ws)); err != nil {
+		return 0, 0, err
+	}
+	return int(ws.Cols), int(ws.Rows), nil
+}
+
+func ioctl(fd int, req uintptr, arg unsafe.Pointer) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), req, uintptr(arg)); errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package term
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"testing"
+	"unsafe"
+)
+
+// openPTY opens a pseudo-terminal and returns its terminal side, the end a
+// program like bj would have as its stdin
+func openPTY(t *testing.T) *os.File {
+	t.Helper()
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+	if err != nil {
+		t.Skipf("no pseudo-terminals: %v", err)
+	}
+	t.Cleanup(func() { master.Close() })
+
+	var n uint32
+	if err := ioctl(int(master.Fd()), syscall.TIOCGPTN, unsafe.Pointer(&n)); err != nil {
+		t.Fatalf("TIOCGPTN: %v", err)
+	}
+	var unlock int32
+	if err := ioctl(int(master.Fd()), syscall.TIOCSPTLCK, unsafe.Pointer(&unlock)); err != nil {
+		t.Fatalf("TIOCSPTLCK: %v", err)
+	}
+
+	tty, err := os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("can't open the terminal side: %v", err)
+	}
+	t.Cleanup(func() { tty.Close() })
+	return tty
+}
+
+func TestIsTerminal(t *testing.T) {
+	tty := openPTY(t)
+	if !IsTerminal(int(tty.Fd())) {
+		t.Error("a pseudo-terminal isn't a terminal")
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	if IsTerminal(int(r.Fd())) {
+		t.Error("a pipe is a terminal")
+	}
+}
+
+func TestMakeRawAndRestore(t *testing.T) {
+	fd := int(openPTY(t).Fd())
+
+	var before syscall.Termios
+	ioctl(fd, ioctlGetTermios, unsafe.Pointer(&before))
+
+	state, err := MakeRaw(fd)
+	if err != nil {
+		t.Fatalf("MakeRaw: %v", err)
+	}
+	var raw syscall.Termios
+	ioctl(fd, ioctlGetTermios, unsafe.Pointer(&raw))
+	if raw.Lflag&(syscall.ECHO|syscall.ICANON|syscall.ISIG) != 0 {
+		t.Errorf("raw mode still echoes, edits lines or sends signals: lflag %#o", raw.Lflag)
+	}
+	if raw.Cc[syscall.VMIN] != 0 || raw.Cc[syscall.VTIME] != 1 {
+		t.Errorf("VMIN, VTIME = %d, %d, want reads to time out after a tenth of a second", raw.Cc[syscall.VMIN], raw.Cc[syscall.VTIME])
+	}
+
+	if err := Restore(fd, state); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	var after syscall.Termios
+	ioctl(fd, ioctlGetTermios, unsafe.Pointer(&after))
+	if after != before {
+		t.Errorf("Restore left the terminal as %+v, want %+v", after, before)
+	}
+}
+
+func TestSize(t *testing.T) {
+	fd := int(openPTY(t).Fd())
+
+	ws := winsize{Rows: 40, Cols: 132}
+	if err := ioctl(fd, syscall.TIOCSWINSZ, unsafe.Pointer(&ws)); err != nil {
+		t.Fatalf("TIOCSWINSZ: %v", err)
+	}
+	width, height, err := Size(fd)
+	if err != nil || width != 132 || height != 40 {
+		t.Errorf("Size = %d x %d, %v, want 132 x 40", width, height, err)
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	if _, _, err := Size(int(r.Fd())); err == nil {
+		t.Error("a pipe has a size")
+	}
+}
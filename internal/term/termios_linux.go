@@ -0,0 +1,9 @@
+package term
+
+import "syscall"
+
+// ioctl requests for reading and writing terminal settings
+const (
+	ioctlGetTermios = syscall.TCGETS
+	ioctlSetTermios = syscall.TCSETS
+)
@@ -0,0 +1,115 @@
+package tracker
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+)
+
+// gzipSuffix is added to the name of a compressed log file
+const gzipSuffix = ".gz"
+
+// SetLogCompression makes the tracker gzip the logs of jobs as they finish,
+// skipping files smaller than minSize bytes (0 = never compress)
+func (t *Tracker) SetLogCompression(minSize int64) {
+	t.compressMinSize = minSize
+}
+
+// compressLogs gzips a finished job's logs, including rotated segments, and
+// records the new paths. Logs that fail to compress are left as they were.
+func (t *Tracker) compressLogs(id int) {
+	if t.compressMinSize <= 0 {
+		return
+	}
+	job, err := t.Get(id)
+	if err != nil || job == nil || job.ExitCode == nil {
+		return
+	}
+
+	compress := func(path string) string {
+		if path == "" {
+			return path
+		}
+		compressed := path
+		for _, segment := range LogSegments(path) {
+			if strings.HasSuffix(segment, gzipSuffix) {
+				continue
+			}
+			if ok, _ := compressFile(segment, t.compressMinSize); ok && segment == strings.TrimSuffix(path, gzipSuffix) {
+				compressed = segment + gzipSuffix
+			}
+		}
+		return compressed
+	}
+	logFile := compress(job.LogFile)
+	stdoutFile := compress(job.StdoutFile)
+	stderrFile := compress(job.StderrFile)
+
+	if logFile != job.LogFile || stdoutFile != job.StdoutFile || stderrFile != job.StderrFile {
+		t.UpdateLogPaths(id, logFile, stdoutFile, stderrFile)
+	}
+}
+
+// compressFile replaces a file with a gzipped copy if it's at least minSize
+// bytes, reporting whether it did
+func compressFile(path string, minSize int64) (bool, error) {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() < minSize {
+		return false, err
+	}
+
+	in, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer in.Close()
+
+	out, err := os.Create(path + gzipSuffix)
+	if err != nil {
+		return false, err
+	}
+	zw := gzip.NewWriter(out)
+	_, err = io.Copy(zw, in)
+	if cerr := zw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path + gzipSuffix)
+		return false, err
+	}
+
+	return true, os.Remove(path)
+}
+
+// OpenLog opens a log file for reading, decompressing it if it was gzipped
+func OpenLog(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, gzipSuffix) {
+		return f, nil
+	}
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &gzipFile{Reader: zr, file: f}, nil
+}
+
+// gzipFile closes both the decompressor and the file underneath it
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (g *gzipFile) Close() error {
+	g.Reader.Close()
+	return g.file.Close()
+}
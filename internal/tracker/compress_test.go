@@ -0,0 +1,100 @@
+package tracker
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// readLog reads a log through OpenLog
+func readLog(t *testing.T, path string) string {
+	t.Helper()
+	r, err := OpenLog(path)
+	if err != nil {
+		t.Fatalf("OpenLog(%s): %v", path, err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("reading %s: %v", path, err)
+	}
+	return string(data)
+}
+
+func TestCompressLogs(t *testing.T) {
+	tr := newTestTracker(t)
+	tr.SetLogCompression(100)
+
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "job.log")
+	stdoutFile := filepath.Join(dir, "job.stdout.log")
+	stderrFile := filepath.Join(dir, "job.stderr.log")
+
+	big := strings.Repeat("output line\n", 50)
+	os.WriteFile(logFile, []byte(big), 0644)
+	os.WriteFile(logFile+".1", []byte(big), 0644) // a rotated segment
+	os.WriteFile(stdoutFile, []byte(big), 0644)
+	os.WriteFile(stderrFile, []byte("tiny\n"), 0644)
+
+	id, _ := tr.Add(Job{Command: "true", LogFile: logFile, StdoutFile: stdoutFile, StderrFile: stderrFile})
+	if err := tr.Complete(id, 0); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+
+	job, _ := tr.Get(id)
+	if job.LogFile != logFile+".gz" || job.StdoutFile != stdoutFile+".gz" {
+		t.Errorf("log paths = %s, %s, want them compressed", job.LogFile, job.StdoutFile)
+	}
+	if job.StderrFile != stderrFile {
+		t.Errorf("stderr log = %s, want it left alone below the minimum size", job.StderrFile)
+	}
+
+	for _, path := range []string{logFile, logFile + ".1", stdoutFile} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s is still there after compressing it", path)
+		}
+	}
+	segments := LogSegments(job.LogFile)
+	if len(segments) != 2 || segments[0] != logFile+".1.gz" {
+		t.Fatalf("LogSegments = %v, want the compressed segment then the live log", segments)
+	}
+	for _, path := range append(segments, job.StdoutFile) {
+		if got := readLog(t, path); got != big {
+			t.Errorf("%s reads back %d bytes, want the original %d", path, len(got), len(big))
+		}
+	}
+	if got := readLog(t, job.StderrFile); got != "tiny\n" {
+		t.Errorf("stderr log = %q, want it unchanged", got)
+	}
+}
+
+func TestCompressLogsOff(t *testing.T) {
+	tr := newTestTracker(t)
+
+	logFile := filepath.Join(t.TempDir(), "job.log")
+	os.WriteFile(logFile, []byte(strings.Repeat("x", 1<<20)), 0644)
+
+	id, _ := tr.Add(Job{Command: "true", LogFile: logFile})
+	tr.Complete(id, 0)
+
+	if job, _ := tr.Get(id); job.LogFile != logFile {
+		t.Errorf("log file = %s, want it left alone with compression off", job.LogFile)
+	}
+}
+
+func TestLogSegmentsMidCompression(t *testing.T) {
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "job.log")
+	for _, name := range []string{"job.log", "job.log.1", "job.log.1.gz", "job.log.2.gz", "job.log.old"} {
+		os.WriteFile(filepath.Join(dir, name), nil, 0644)
+	}
+
+	// The original is the complete copy while its .gz is still being written
+	got := LogSegments(logFile)
+	want := []string{logFile + ".2.gz", logFile + ".1", logFile}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("LogSegments = %v, want %v", got, want)
+	}
+}
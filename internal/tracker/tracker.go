@@ -6,7 +6,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -16,22 +19,282 @@ import (
 // ErrJobNotFound is returned when a job ID doesn't exist
 var ErrJobNotFound = errors.New("job not found")
 
+// ErrJobFinished is returned when trying to kill a job that has already ended
+var ErrJobFinished = errors.New("already finished")
+
+// ErrAlreadyPaused is returned when pausing a job that is already paused
+var ErrAlreadyPaused = errors.New("already paused")
+
+// ErrNotPaused is returned when resuming a job that isn't paused
+var ErrNotPaused = errors.New("not paused")
+
+// ErrNameInUse is returned when a running job already has the requested name
+var ErrNameInUse = errors.New("name already in use by a running job")
+
+// Job statuses that can't be derived from the exit code alone
+const (
+	StatusTimeout = "timeout" // the command ran past its deadline and was killed
+	StatusWaiting = "waiting" // the job is blocked until its dependencies finish
+	StatusSkipped = "skipped" // a dependency failed so the command never ran
+	StatusQueued  = "queued"  // the job's queue is full, it starts when a slot frees up
+
+	StatusCancelled = "cancelled" // the job was killed while queued, so the command never ran
+
+	StatusCrashloop = "crashloop"  // a restart job exited too often within its restart window and was given up on
+	StatusBadOutput = "bad-output" // the command's output matched its FailIfOutput pattern
+)
+
+// Launch modes, recorded so a queued job can be started later the same way
+const (
+	ModeRetry   = "retry"   // rerun the command on failure, up to MaxAttempts
+	ModeRestart = "restart" // rerun the command on failure forever
+)
+
+// Backoff strategies for the delay between runs in retry and restart modes
+const (
+	BackoffFixed       = "fixed"       // wait RetryDelay every time
+	BackoffLinear      = "linear"      // wait RetryDelay times the number of failures so far
+	BackoffExponential = "exponential" // double the delay after every failure
+)
+
+// I/O scheduling classes for Limits.IOClass, as in ionice(1)
+const (
+	IOClassIdle       = "idle"        // only get disk time when nothing else wants it
+	IOClassBestEffort = "best-effort" // the default class, at the default priority
+	IOClassRealtime   = "realtime"    // go first (needs root)
+)
+
+// Output streams captured to their own log next to the combined one
+const (
+	StreamStdout = "stdout"
+	StreamStderr = "stderr"
+)
+
+// ExitTimeout is the exit code recorded for a timed out run (matches timeout(1))
+const ExitTimeout = 124
+
+// ExitSkipped is the exit code recorded for a job that never ran
+const ExitSkipped = -1
+
+// DefaultKillGrace is how long Kill waits for a job to exit before using SIGKILL
+const DefaultKillGrace = 5 * time.Second
+
+// KillSettleTime is how long Kill waits for a job to disappear after SIGKILL
+const KillSettleTime = time.Second
+
+// killPollInterval is how often Kill checks whether a job's processes are gone
+const killPollInterval = 50 * time.Millisecond
+
+// fileMode is the permissions of jobs.json and its lock file
+const fileMode = 0600
+
 // Job represents a background job
 type Job struct {
-	ID        int        `json:"id"`
-	Command   string     `json:"cmd"`
-	PWD       string     `json:"pwd"`
+	ID            int           `json:"id"`
+	Name          string        `json:"name,omitempty"`
+	Command       string        `json:"cmd"`
+	PWD           string        `json:"pwd"`
+	StartTime     time.Time     `json:"start_time"`
+	EndTime       *time.Time    `json:"end_time,omitempty"`
+	ExitCode      *int          `json:"exit_code,omitempty"`
+	Status        string        `json:"status,omitempty"`
+	Signal        string        `json:"signal,omitempty"`        // signal that ended the job when it was killed
+	LogFile       string        `json:"log_file"`                // stdout and stderr interleaved, plus bj's own banners
+	StdoutFile    string        `json:"stdout_file,omitempty"`   // the command's stdout only
+	StderrFile    string        `json:"stderr_file,omitempty"`   // the command's stderr only
+	Timestamps    bool          `json:"timestamps,omitempty"`    // output lines are prefixed with the time they were written
+	MaxLogSize    int64         `json:"max_log_size,omitempty"`  // each log is rotated once it reaches this many bytes
+	MaxLogFiles   int           `json:"max_log_files,omitempty"` // rotated segments kept per log
+	PID           int           `json:"pid,omitempty"`
+	PGID          int           `json:"pgid,omitempty"` // process group of the current run, when it has its own
+	Timeout       time.Duration `json:"timeout,omitempty"`
+	After         []int         `json:"after,omitempty"`     // jobs that must succeed before this one runs
+	AfterAny      []int         `json:"after_any,omitempty"` // jobs that must finish (any result) before this one runs
+	Queue         string        `json:"queue,omitempty"`
+	Mode          string        `json:"mode,omitempty"`           // "" (run once), ModeRetry or ModeRestart
+	MaxAttempts   int           `json:"max_attempts,omitempty"`   // retry mode: 0 = unlimited
+	RetryDelay    int           `json:"retry_delay,omitempty"`    // retry and restart modes: seconds before running again
+	Backoff       string        `json:"backoff,omitempty"`        // how the delay grows with each failure ("" = BackoffFixed)
+	MaxDelay      time.Duration `json:"max_delay,omitempty"`      // upper bound on the delay between runs
+	Jitter        bool          `json:"jitter,omitempty"`         // randomize each delay to spread out reruns
+	RestartAlways bool          `json:"restart_always,omitempty"` // restart mode: rerun after a clean exit too
+	RestartLimit  int           `json:"restart_limit,omitempty"`  // restart mode: give up after this many restarts within RestartWindow (0 = never)
+	RestartWindow time.Duration `json:"restart_window,omitempty"` // restart mode: how far back RestartLimit counts
+	OkCodes       []int         `json:"ok_codes,omitempty"`       // exit codes that count as success (nil = just 0)
+	FailIfOutput  string        `json:"fail_if_output,omitempty"` // a run whose output matches this regexp fails
+	Env           []string      `json:"env,omitempty"`            // KEY=VAL entries captured at launch, restored for every run
+	Limits                      // resource limits every run is started with
+	Usage         *Usage        `json:"usage,omitempty"`       // resources the runs have used so far
+	Attempts      []Attempt     `json:"attempts,omitempty"`    // retry and restart modes: one record per run
+	LogDropped    int64         `json:"log_dropped,omitempty"` // bytes of the combined log deleted by rotation
+	PausedAt      *time.Time    `json:"paused_at,omitempty"`   // set while the job is paused
+	PausedFor     time.Duration `json:"paused_for,omitempty"`  // total time spent paused before PausedAt
+}
+
+// Limits are the resource limits a job's command runs under, inherited by
+// everything it starts. Zero values leave a limit alone.
+type Limits struct {
+	Nice         int           `json:"nice,omitempty"`           // scheduling niceness, -20 (greedy) to 19 (polite)
+	IOClass      string        `json:"ionice,omitempty"`         // I/O scheduling class (IOClass*)
+	MaxMemory    int64         `json:"max_memory,omitempty"`     // address space limit in bytes (RLIMIT_AS)
+	MaxCPUTime   time.Duration `json:"max_cpu_time,omitempty"`   // CPU time limit per run (RLIMIT_CPU)
+	MaxOpenFiles int           `json:"max_open_files,omitempty"` // open file descriptor limit (RLIMIT_NOFILE)
+}
+
+// IsZero reports whether no limits are set
+func (l Limits) IsZero() bool {
+	return l == Limits{}
+}
+
+// Usage is what a job's runs have used, added up as each run ends
+type Usage struct {
+	PeakRSS  int64         `json:"peak_rss"`  // largest resident set size of any run, in bytes
+	UserTime time.Duration `json:"user_time"` // CPU time spent running the command's own code
+	SysTime  time.Duration `json:"sys_time"`  // CPU time the kernel spent on the command's behalf
+	WallTime time.Duration `json:"wall_time"` // time the command was running, not counting retry and restart delays
+}
+
+// Attempt records a single run of a retry or restart job. Its output is the
+// range of the combined log from LogStart to LogEnd, counted in bytes from the
+// start of the job's log including anything rotation has since deleted.
+type Attempt struct {
 	StartTime time.Time  `json:"start_time"`
 	EndTime   *time.Time `json:"end_time,omitempty"`
 	ExitCode  *int       `json:"exit_code,omitempty"`
-	LogFile   string     `json:"log_file"`
-	PID       int        `json:"pid,omitempty"`
+	LogStart  int64      `json:"log_start"`
+	LogEnd    int64      `json:"log_end,omitempty"` // 0 = up to the end of the log
+}
+
+// StreamLog returns the log file holding only the given stream (StreamStdout
+// or StreamStderr), or "" if the job predates separate capture
+func (j Job) StreamLog(stream string) string {
+	switch stream {
+	case StreamStdout:
+		return j.StdoutFile
+	case StreamStderr:
+		return j.StderrFile
+	}
+	return ""
+}
+
+// LogFiles returns every log file the job has written to, including the
+// segments its logs were rotated into
+func (j Job) LogFiles() []string {
+	var files []string
+	for _, f := range []string{j.LogFile, j.StdoutFile, j.StderrFile} {
+		if f != "" {
+			files = append(files, LogSegments(f)...)
+		}
+	}
+	return files
+}
+
+// LogSegments returns the files a log is made of, oldest first: the segments
+// it was rotated into (path.N down to path.1) followed by the live file.
+// Each may have been compressed (with a .gz suffix), and path may name the
+// log either way. Files that don't exist are left out.
+func LogSegments(path string) []string {
+	base := strings.TrimSuffix(path, gzipSuffix)
+	matches, _ := filepath.Glob(base + ".*")
+
+	numbered := make(map[int]string)
+	var nums []int
+	for _, m := range matches {
+		suffix := strings.TrimSuffix(strings.TrimPrefix(m, base+"."), gzipSuffix)
+		n, err := strconv.Atoi(suffix)
+		if err != nil || n < 1 {
+			continue
+		}
+		// Mid-compression both versions exist, the original is the complete one
+		if _, seen := numbered[n]; !seen {
+			nums = append(nums, n)
+		} else if strings.HasSuffix(m, gzipSuffix) {
+			continue
+		}
+		numbered[n] = m
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(nums)))
+
+	segments := make([]string, 0, len(nums)+1)
+	for _, n := range nums {
+		segments = append(segments, numbered[n])
+	}
+	for _, live := range []string{base, base + gzipSuffix} {
+		if _, err := os.Stat(live); err == nil {
+			segments = append(segments, live)
+			break
+		}
+	}
+	return segments
+}
+
+// OkExit reports whether an exit code counts as success for the job: one of
+// its OkCodes, or 0 if it has none
+func (j Job) OkExit(code int) bool {
+	if len(j.OkCodes) == 0 {
+		return code == 0
+	}
+	for _, ok := range j.OkCodes {
+		if code == ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Succeeded reports whether the job finished with an ok exit code and without
+// a failure status such as StatusCrashloop or StatusBadOutput
+func (j Job) Succeeded() bool {
+	return j.ExitCode != nil && j.OkExit(*j.ExitCode) && j.Status == ""
+}
+
+// Failed reports whether the job finished without succeeding
+func (j Job) Failed() bool {
+	return j.ExitCode != nil && !j.Succeeded()
+}
+
+// Paused reports whether the job is currently stopped by Pause
+func (j Job) Paused() bool {
+	return j.PausedAt != nil && j.ExitCode == nil
+}
+
+// Alive reports whether the job's process, or anything left in its process
+// group, is still running
+func (j Job) Alive() bool {
+	if j.PID <= 0 {
+		return false
+	}
+	return groupAlive(j.PID) || (j.PGID != 0 && groupAlive(j.PGID))
+}
+
+// Duration returns how long the job has been (or was) running, excluding any
+// time it spent paused
+func (j Job) Duration() time.Duration {
+	end := time.Now()
+	if j.EndTime != nil {
+		end = *j.EndTime
+	}
+	d := end.Sub(j.StartTime) - j.PausedFor
+	if j.PausedAt != nil {
+		d -= end.Sub(*j.PausedAt)
+	}
+	return d
+}
+
+// closeAttempt ends the job's current run, if one is still open
+func (j *Job) closeAttempt(exitCode int, now time.Time) {
+	if n := len(j.Attempts); n > 0 && j.Attempts[n-1].EndTime == nil {
+		j.Attempts[n-1].EndTime = &now
+		j.Attempts[n-1].ExitCode = &exitCode
+	}
 }
 
 // Tracker manages job metadata
 type Tracker struct {
 	path     string
 	lockPath string
+
+	compressMinSize int64 // gzip finished jobs' logs at least this big (0 = never)
 }
 
 // MaxJobHistory is the maximum number of completed jobs to retain
@@ -49,15 +312,24 @@ func New() (*Tracker, error) {
 		return nil, err
 	}
 
-	return &Tracker{
+	t := &Tracker{
 		path:     filepath.Join(configDir, "jobs.json"),
 		lockPath: filepath.Join(configDir, "jobs.lock"),
-	}, nil
+	}
+
+	// Jobs record their environment, so only the user may read them. Files
+	// written by older versions of bj were readable by everyone.
+	for _, path := range []string{t.path, t.lockPath} {
+		if info, err := os.Stat(path); err == nil && info.Mode().Perm()&0077 != 0 {
+			os.Chmod(path, fileMode)
+		}
+	}
+	return t, nil
 }
 
 // lock acquires a file lock for cross-process safety
 func (t *Tracker) lock() (*os.File, error) {
-	f, err := os.OpenFile(t.lockPath, os.O_CREATE|os.O_RDWR, 0644)
+	f, err := os.OpenFile(t.lockPath, os.O_CREATE|os.O_RDWR, fileMode)
 	if err != nil {
 		return nil, err
 	}
@@ -103,7 +375,7 @@ func (t *Tracker) save(jobs []Job) error {
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(t.path, data, 0644)
+	return os.WriteFile(t.path, data, fileMode)
 }
 
 // nextID returns the next available job ID
@@ -117,8 +389,27 @@ func (t *Tracker) nextID(jobs []Job) int {
 	return maxID + 1
 }
 
-// Add creates a new job entry and returns its ID
-func (t *Tracker) Add(cmd, pwd, logFile string) (int, error) {
+// Add creates a new job entry from the given template and returns its ID
+// ID and StartTime are assigned here. If the job is named, no running job
+// may share that name.
+func (t *Tracker) Add(job Job) (int, error) {
+	lockFile, err := t.lock()
+	if err != nil {
+		return 0, fmt.Errorf("failed to acquire lock: %w", err)
+	}
+	defer t.unlock(lockFile)
+
+	jobs, err := t.load()
+	if err != nil {
+		return 0, fmt.Errorf("failed to load jobs: %w", err)
+	}
+
+	return t.insert(jobs, job)
+}
+
+// Enqueue adds a job to its queue like Add, but marks it queued instead of
+// running when the queue already has maxParallel active jobs
+func (t *Tracker) Enqueue(job Job, maxParallel int) (int, error) {
 	lockFile, err := t.lock()
 	if err != nil {
 		return 0, fmt.Errorf("failed to acquire lock: %w", err)
@@ -130,14 +421,26 @@ func (t *Tracker) Add(cmd, pwd, logFile string) (int, error) {
 		return 0, fmt.Errorf("failed to load jobs: %w", err)
 	}
 
-	job := Job{
-		ID:        t.nextID(jobs),
-		Command:   cmd,
-		PWD:       pwd,
-		StartTime: time.Now(),
-		LogFile:   logFile,
+	if queueActive(jobs, job.Queue) >= maxParallel {
+		job.Status = StatusQueued
+	}
+
+	return t.insert(jobs, job)
+}
+
+// insert assigns an ID and start time to a job and saves it (lock must be held)
+func (t *Tracker) insert(jobs []Job, job Job) (int, error) {
+	if job.Name != "" {
+		for _, j := range jobs {
+			if j.ExitCode == nil && j.Name == job.Name {
+				return 0, fmt.Errorf("%w: %s (job %d)", ErrNameInUse, job.Name, j.ID)
+			}
+		}
 	}
 
+	job.ID = t.nextID(jobs)
+	job.StartTime = time.Now()
+
 	jobs = append(jobs, job)
 	if err := t.save(jobs); err != nil {
 		return 0, fmt.Errorf("failed to save jobs: %w", err)
@@ -146,8 +449,93 @@ func (t *Tracker) Add(cmd, pwd, logFile string) (int, error) {
 	return job.ID, nil
 }
 
+// Promote takes queued jobs off the named queue, oldest first, until it has
+// maxParallel active jobs. The promoted jobs are returned for the caller to
+// start; their start time is reset to now.
+func (t *Tracker) Promote(queue string, maxParallel int) ([]Job, error) {
+	lockFile, err := t.lock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire lock: %w", err)
+	}
+	defer t.unlock(lockFile)
+
+	jobs, err := t.load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load jobs: %w", err)
+	}
+
+	// Lowest IDs first - they've been waiting longest. Jobs without a log file
+	// are still being launched; the launcher promotes them once it's ready.
+	var waiting []int
+	for i := range jobs {
+		if jobs[i].Queue == queue && jobs[i].ExitCode == nil && jobs[i].Status == StatusQueued && jobs[i].LogFile != "" {
+			waiting = append(waiting, i)
+		}
+	}
+	sort.Slice(waiting, func(a, b int) bool {
+		return jobs[waiting[a]].ID < jobs[waiting[b]].ID
+	})
+
+	var promoted []Job
+	free := maxParallel - queueActive(jobs, queue)
+	for _, i := range waiting {
+		if free <= 0 {
+			break
+		}
+		jobs[i].Status = ""
+		if len(jobs[i].After) > 0 || len(jobs[i].AfterAny) > 0 {
+			jobs[i].Status = StatusWaiting
+		}
+		jobs[i].StartTime = time.Now()
+		promoted = append(promoted, jobs[i])
+		free--
+	}
+
+	if len(promoted) > 0 {
+		if err := t.save(jobs); err != nil {
+			return nil, fmt.Errorf("failed to save jobs: %w", err)
+		}
+	}
+
+	return promoted, nil
+}
+
+// QueuePosition returns a queued job's place in line (1 = next to start),
+// or 0 if the job isn't queued
+func QueuePosition(jobs []Job, job Job) int {
+	if job.Status != StatusQueued || job.ExitCode != nil {
+		return 0
+	}
+	pos := 1
+	for _, j := range jobs {
+		if j.Queue == job.Queue && j.Status == StatusQueued && j.ExitCode == nil && j.ID < job.ID {
+			pos++
+		}
+	}
+	return pos
+}
+
+// queueActive counts the jobs in a queue that have been started and not finished
+func queueActive(jobs []Job, queue string) int {
+	active := 0
+	for _, j := range jobs {
+		if j.Queue == queue && j.ExitCode == nil && j.Status != StatusQueued {
+			active++
+		}
+	}
+	return active
+}
+
 // Complete marks a job as completed with exit code and end time
 func (t *Tracker) Complete(id int, exitCode int) error {
+	if err := t.complete(id, exitCode); err != nil {
+		return err
+	}
+	t.compressLogs(id)
+	return nil
+}
+
+func (t *Tracker) complete(id int, exitCode int) error {
 	lockFile, err := t.lock()
 	if err != nil {
 		return fmt.Errorf("failed to acquire lock: %w", err)
@@ -164,6 +552,7 @@ func (t *Tracker) Complete(id int, exitCode int) error {
 			now := time.Now()
 			jobs[i].EndTime = &now
 			jobs[i].ExitCode = &exitCode
+			jobs[i].closeAttempt(exitCode, now)
 
 			// Prune old completed jobs if we exceed MaxJobHistory
 			jobs = t.pruneOldJobs(jobs)
@@ -219,6 +608,45 @@ func (t *Tracker) Get(id int) (*Job, error) {
 	return nil, nil
 }
 
+// Find returns a job by reference: either a numeric ID or a job name
+// A name resolves to the running job with that name, or failing that the most
+// recently started one. Returns nil if nothing matches.
+func (t *Tracker) Find(ref string) (*Job, error) {
+	if id, err := strconv.Atoi(ref); err == nil {
+		return t.Get(id)
+	}
+
+	jobs, err := t.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var match *Job
+	for i := range jobs {
+		if jobs[i].Name != ref {
+			continue
+		}
+		if jobs[i].ExitCode == nil {
+			return &jobs[i], nil
+		}
+		if match == nil {
+			match = &jobs[i]
+		}
+	}
+
+	return match, nil
+}
+
+// ModTime returns when the job list was last written (the zero time if it
+// hasn't been yet), so a caller polling it can tell when to reload
+func (t *Tracker) ModTime() time.Time {
+	info, err := os.Stat(t.path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
 // Latest returns the most recently started job
 func (t *Tracker) Latest() (*Job, error) {
 	jobs, err := t.List()
@@ -233,8 +661,8 @@ func (t *Tracker) Latest() (*Job, error) {
 	return &jobs[0], nil
 }
 
-// UpdateLogPath updates the log file path for a job
-func (t *Tracker) UpdateLogPath(id int, logPath string) error {
+// update applies fn to a single job under the lock and saves the result
+func (t *Tracker) update(id int, fn func(*Job)) error {
 	lockFile, err := t.lock()
 	if err != nil {
 		return fmt.Errorf("failed to acquire lock: %w", err)
@@ -248,7 +676,7 @@ func (t *Tracker) UpdateLogPath(id int, logPath string) error {
 
 	for i := range jobs {
 		if jobs[i].ID == id {
-			jobs[i].LogFile = logPath
+			fn(&jobs[i])
 			if err := t.save(jobs); err != nil {
 				return fmt.Errorf("failed to save jobs: %w", err)
 			}
@@ -259,78 +687,323 @@ func (t *Tracker) UpdateLogPath(id int, logPath string) error {
 	return ErrJobNotFound
 }
 
+// UpdateLogPaths records where a job's combined, stdout and stderr logs live
+func (t *Tracker) UpdateLogPaths(id int, logPath, stdoutPath, stderrPath string) error {
+	return t.update(id, func(j *Job) {
+		j.LogFile = logPath
+		j.StdoutFile = stdoutPath
+		j.StderrFile = stderrPath
+	})
+}
+
 // UpdatePID updates the process ID for a job
 func (t *Tracker) UpdatePID(id int, pid int) error {
+	return t.update(id, func(j *Job) { j.PID = pid })
+}
+
+// UpdatePGID records the process group of the job's current run
+func (t *Tracker) UpdatePGID(id int, pgid int) error {
+	return t.update(id, func(j *Job) { j.PGID = pgid })
+}
+
+// Start marks a waiting job as running, resetting its start time so durations
+// reflect when the command actually began
+func (t *Tracker) Start(id int) error {
+	return t.update(id, func(j *Job) {
+		j.Status = ""
+		j.StartTime = time.Now()
+		j.PausedFor = 0
+	})
+}
+
+// StartAttempt records the start of a new run of a retry or restart job
+// whose output begins at logOffset in the combined log
+func (t *Tracker) StartAttempt(id int, logOffset int64) error {
+	return t.update(id, func(j *Job) {
+		j.Attempts = append(j.Attempts, Attempt{StartTime: time.Now(), LogStart: logOffset})
+	})
+}
+
+// EndAttempt records how the job's current run ended and where its output
+// stops in the combined log
+func (t *Tracker) EndAttempt(id int, exitCode int, logOffset int64) error {
+	return t.update(id, func(j *Job) {
+		if n := len(j.Attempts); n > 0 && j.Attempts[n-1].EndTime == nil {
+			j.Attempts[n-1].LogEnd = logOffset
+		}
+		j.closeAttempt(exitCode, time.Now())
+	})
+}
+
+// AddLogDropped records that rotation deleted n more bytes of the job's combined log
+func (t *Tracker) AddLogDropped(id int, n int64) error {
+	return t.update(id, func(j *Job) { j.LogDropped += n })
+}
+
+// AddUsage adds a finished run's resource usage to the job's totals
+func (t *Tracker) AddUsage(id int, run Usage) error {
+	return t.update(id, func(j *Job) {
+		if j.Usage == nil {
+			j.Usage = &Usage{}
+		}
+		j.Usage.PeakRSS = max(j.Usage.PeakRSS, run.PeakRSS)
+		j.Usage.UserTime += run.UserTime
+		j.Usage.SysTime += run.SysTime
+		j.Usage.WallTime += run.WallTime
+	})
+}
+
+// SetStatus sets or clears (with "") a job's special status
+func (t *Tracker) SetStatus(id int, status string) error {
+	return t.update(id, func(j *Job) { j.Status = status })
+}
+
+// Pause stops a running job's process groups with SIGSTOP until Resume
+func (t *Tracker) Pause(id int) (*Job, error) {
+	return t.suspend(id, true)
+}
+
+// Resume continues a paused job's process groups with SIGCONT
+func (t *Tracker) Resume(id int) (*Job, error) {
+	return t.suspend(id, false)
+}
+
+// suspend pauses or resumes a job, keeping count of the time it spends paused
+func (t *Tracker) suspend(id int, pause bool) (*Job, error) {
 	lockFile, err := t.lock()
 	if err != nil {
-		return fmt.Errorf("failed to acquire lock: %w", err)
+		return nil, fmt.Errorf("failed to acquire lock: %w", err)
 	}
 	defer t.unlock(lockFile)
 
 	jobs, err := t.load()
 	if err != nil {
-		return fmt.Errorf("failed to load jobs: %w", err)
+		return nil, fmt.Errorf("failed to load jobs: %w", err)
 	}
 
 	for i := range jobs {
-		if jobs[i].ID == id {
-			jobs[i].PID = pid
-			if err := t.save(jobs); err != nil {
-				return fmt.Errorf("failed to save jobs: %w", err)
-			}
-			return nil
+		if jobs[i].ID != id {
+			continue
 		}
+
+		job := &jobs[i]
+		switch {
+		case job.ExitCode != nil:
+			return nil, fmt.Errorf("job %d %w", id, ErrJobFinished)
+		case job.Status == StatusQueued || job.PID == 0:
+			return nil, fmt.Errorf("job %d hasn't started yet", id)
+		case pause && job.PausedAt != nil:
+			return nil, fmt.Errorf("job %d %w", id, ErrAlreadyPaused)
+		case !pause && job.PausedAt == nil:
+			return nil, fmt.Errorf("job %d %w", id, ErrNotPaused)
+		}
+
+		sig := syscall.SIGCONT
+		if pause {
+			sig = syscall.SIGSTOP
+		}
+		if err := syscall.Kill(-job.PID, sig); err != nil {
+			return nil, fmt.Errorf("failed to signal process: %w", err)
+		}
+		// Ignore errors - the group is gone if the run already ended
+		if job.PGID > 0 {
+			syscall.Kill(-job.PGID, sig)
+		}
+
+		now := time.Now()
+		if pause {
+			job.PausedAt = &now
+		} else {
+			job.PausedFor += now.Sub(*job.PausedAt)
+			job.PausedAt = nil
+		}
+
+		if err := t.save(jobs); err != nil {
+			return nil, fmt.Errorf("failed to save jobs: %w", err)
+		}
+		result := *job
+		return &result, nil
 	}
 
-	return ErrJobNotFound
+	return nil, ErrJobNotFound
 }
 
-// Kill terminates a running job by sending SIGTERM to its process group
+// Kill terminates a running job by signalling its process group, waiting up
+// to grace for it to exit and escalating to SIGKILL if it hasn't. The job is
+// recorded with the signal that actually ended it (exit code -N for signal N),
+// or as cancelled if it was still queued.
 // Returns the job that was killed, or error if not found/not running
-func (t *Tracker) Kill(id int) (*Job, error) {
-	lockFile, err := t.lock()
+func (t *Tracker) Kill(id int, sig syscall.Signal, grace time.Duration) (*Job, error) {
+	job, err := t.Get(id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to acquire lock: %w", err)
+		return nil, err
+	}
+	if job == nil {
+		return nil, ErrJobNotFound
 	}
-	defer t.unlock(lockFile)
 
-	jobs, err := t.load()
-	if err != nil {
-		return nil, fmt.Errorf("failed to load jobs: %w", err)
+	// Check if job is still running
+	if job.ExitCode != nil {
+		return nil, fmt.Errorf("job %d %w", id, ErrJobFinished)
 	}
 
-	for i := range jobs {
-		if jobs[i].ID == id {
-			// Check if job is still running
-			if jobs[i].ExitCode != nil {
-				return nil, fmt.Errorf("job %d already finished", id)
-			}
+	// Queued jobs haven't been started, so there's nothing to signal
+	if job.Status != StatusQueued {
+		if job.PID == 0 {
+			return nil, fmt.Errorf("job %d has no PID recorded", id)
+		}
+
+		// Send the signal to the process group (negative PID)
+		// This reaches the entire process tree since we use Setsid
+		if err := syscall.Kill(-job.PID, sig); err != nil {
+			return nil, fmt.Errorf("failed to terminate process: %w", err)
+		}
+
+		// Commands run via bj --exec live in their own process group
+		// Ignore errors - the group is gone if the run already ended
+		if job.PGID > 0 {
+			syscall.Kill(-job.PGID, sig)
+		}
 
-			if jobs[i].PID == 0 {
-				return nil, fmt.Errorf("job %d has no PID recorded", id)
+		// A paused job can't act on the signal until it's continued
+		if job.PausedAt != nil {
+			syscall.Kill(-job.PID, syscall.SIGCONT)
+			if job.PGID > 0 {
+				syscall.Kill(-job.PGID, syscall.SIGCONT)
 			}
+		}
 
-			// Send SIGTERM to the process group (negative PID)
-			// This kills the entire process tree since we use Setsid
-			if err := syscall.Kill(-jobs[i].PID, syscall.SIGTERM); err != nil {
-				return nil, fmt.Errorf("failed to terminate process: %w", err)
+		if !waitForExit(job, grace) && sig != syscall.SIGKILL {
+			// It's ignoring us - stop asking nicely
+			sig = syscall.SIGKILL
+			syscall.Kill(-job.PID, sig)
+			if job.PGID > 0 {
+				syscall.Kill(-job.PGID, sig)
 			}
+			waitForExit(job, KillSettleTime)
+		}
+	}
 
-			// Mark job as killed (exit code -15 = killed by SIGTERM)
-			exitCode := -15
-			now := time.Now()
-			jobs[i].ExitCode = &exitCode
-			jobs[i].EndTime = &now
+	// The wrapper is gone so it will never call Complete - record the outcome here
+	err = t.update(id, func(j *Job) {
+		if j.ExitCode != nil {
+			return // finished on its own while we were waiting
+		}
+		now := time.Now()
+		j.EndTime = &now
+		if j.Status == StatusQueued {
+			// Nothing was signalled, the job just won't start
+			exitCode := ExitSkipped
+			j.ExitCode = &exitCode
+			j.Status = StatusCancelled
+			return
+		}
+		exitCode := -int(sig)
+		j.ExitCode = &exitCode
+		j.Signal = SignalName(sig)
+		j.Status = "" // being killed trumps waiting
+		j.closeAttempt(exitCode, now)
+		if j.PausedAt != nil {
+			j.PausedFor += now.Sub(*j.PausedAt)
+			j.PausedAt = nil
+		}
 
-			if err := t.save(jobs); err != nil {
-				return nil, fmt.Errorf("failed to save jobs: %w", err)
-			}
+	})
+	if err != nil {
+		return nil, err
+	}
 
-			return &jobs[i], nil
+	t.compressLogs(id)
+	return t.Get(id)
+}
+
+// waitForExit polls until every process in the job's process groups is gone,
+// returning false if some are still alive after d
+func waitForExit(job *Job, d time.Duration) bool {
+	deadline := time.Now().Add(d)
+	for {
+		if !groupAlive(job.PID) && (job.PGID == 0 || !groupAlive(job.PGID)) {
+			return true
 		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(killPollInterval)
 	}
+}
 
-	return nil, ErrJobNotFound
+// groupAlive reports whether any process in a process group is still running.
+// Zombies don't count: they're already dead, just not reaped yet by their
+// parent, which for a detached job is init - and not every init is prompt.
+func groupAlive(pgid int) bool {
+	if syscall.Kill(-pgid, 0) != nil {
+		return false
+	}
+
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return true // no procfs (e.g. macOS), so trust kill(2)
+	}
+	for _, e := range entries {
+		if _, err := strconv.Atoi(e.Name()); err != nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join("/proc", e.Name(), "stat"))
+		if err != nil {
+			continue
+		}
+		// Format is "pid (comm) state ppid pgrp ...", and comm may contain anything
+		end := strings.LastIndexByte(string(data), ')')
+		if end < 0 || end+2 > len(data) {
+			continue
+		}
+		fields := strings.Fields(string(data[end+2:]))
+		if len(fields) >= 3 && fields[2] == strconv.Itoa(pgid) && fields[0] != "Z" {
+			return true
+		}
+	}
+	return false
+}
+
+// signalNames maps the signals bj --kill accepts to their conventional names
+var signalNames = map[syscall.Signal]string{
+	syscall.SIGHUP:  "SIGHUP",
+	syscall.SIGINT:  "SIGINT",
+	syscall.SIGQUIT: "SIGQUIT",
+	syscall.SIGKILL: "SIGKILL",
+	syscall.SIGUSR1: "SIGUSR1",
+	syscall.SIGUSR2: "SIGUSR2",
+	syscall.SIGALRM: "SIGALRM",
+	syscall.SIGTERM: "SIGTERM",
+}
+
+// ParseSignal parses a signal given as a name (TERM, SIGTERM, term) or number
+func ParseSignal(s string) (syscall.Signal, error) {
+	if n, err := strconv.Atoi(s); err == nil {
+		if _, ok := signalNames[syscall.Signal(n)]; ok {
+			return syscall.Signal(n), nil
+		}
+		return 0, fmt.Errorf("unsupported signal: %s", s)
+	}
+
+	name := strings.ToUpper(s)
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+	for sig, n := range signalNames {
+		if n == name {
+			return sig, nil
+		}
+	}
+	return 0, fmt.Errorf("unsupported signal: %s", s)
+}
+
+// SignalName returns the conventional name of a signal, like SIGTERM
+func SignalName(sig syscall.Signal) string {
+	if name, ok := signalNames[sig]; ok {
+		return name
+	}
+	return fmt.Sprintf("signal %d", int(sig))
 }
 
 // LatestRunning returns the most recently started job that is still running
@@ -395,8 +1068,8 @@ func (t *Tracker) Prune() (int, error) {
 	for _, j := range jobs {
 		if j.ExitCode != nil {
 			// Job is completed (any exit code) - prune it
-			// Delete the log file (ignore errors - file may already be gone)
-			os.Remove(j.LogFile)
+			// Delete its log files too
+			removeLogs(j)
 			pruned++
 		} else {
 			// Job is still running - keep it
@@ -414,15 +1087,24 @@ func (t *Tracker) Prune() (int, error) {
 // GarbageCollect finds orphaned jobs (running but process is gone) and marks them as failed
 // Returns the number of jobs cleaned up
 func (t *Tracker) GarbageCollect() (int, error) {
+	collected, err := t.garbageCollect()
+	for _, id := range collected {
+		t.compressLogs(id)
+	}
+	return len(collected), err
+}
+
+// garbageCollect marks orphaned jobs as failed and returns their IDs
+func (t *Tracker) garbageCollect() ([]int, error) {
 	lockFile, err := t.lock()
 	if err != nil {
-		return 0, fmt.Errorf("failed to acquire lock: %w", err)
+		return nil, fmt.Errorf("failed to acquire lock: %w", err)
 	}
 	defer t.unlock(lockFile)
 
 	jobs, err := t.load()
 	if err != nil {
-		return 0, fmt.Errorf("failed to load jobs: %w", err)
+		return nil, fmt.Errorf("failed to load jobs: %w", err)
 	}
 
 	// Grace period: don't GC jobs started less than 5 seconds ago
@@ -430,7 +1112,7 @@ func (t *Tracker) GarbageCollect() (int, error) {
 	gracePeriod := 5 * time.Second
 	now := time.Now()
 
-	collected := 0
+	var collected []int
 	for i := range jobs {
 		// Skip completed jobs
 		if jobs[i].ExitCode != nil {
@@ -442,6 +1124,11 @@ func (t *Tracker) GarbageCollect() (int, error) {
 			continue
 		}
 
+		// Queued jobs have no process until their queue promotes them
+		if jobs[i].Status == StatusQueued {
+			continue
+		}
+
 		// Check if process is still alive
 		if jobs[i].PID > 0 {
 			// Try to send signal 0 to check if process exists
@@ -457,18 +1144,52 @@ func (t *Tracker) GarbageCollect() (int, error) {
 		exitCode := -1
 		jobs[i].ExitCode = &exitCode
 		jobs[i].EndTime = &now
-		collected++
+		jobs[i].closeAttempt(exitCode, now)
+		collected = append(collected, jobs[i].ID)
 	}
 
-	if collected > 0 {
+	if len(collected) > 0 {
 		if err := t.save(jobs); err != nil {
-			return 0, fmt.Errorf("failed to save jobs: %w", err)
+			return nil, fmt.Errorf("failed to save jobs: %w", err)
 		}
 	}
 
 	return collected, nil
 }
 
+// PruneIDs removes the completed jobs (any exit code) among ids, deletes their
+// log files, and returns count pruned. Jobs that are still running are kept.
+func (t *Tracker) PruneIDs(ids []int) (int, error) {
+	lockFile, err := t.lock()
+	if err != nil {
+		return 0, fmt.Errorf("failed to acquire lock: %w", err)
+	}
+	defer t.unlock(lockFile)
+
+	jobs, err := t.load()
+	if err != nil {
+		return 0, fmt.Errorf("failed to load jobs: %w", err)
+	}
+
+	var kept []Job
+	pruned := 0
+	for _, j := range jobs {
+		if j.ExitCode != nil && slices.Contains(ids, j.ID) {
+			// Delete its log files too
+			removeLogs(j)
+			pruned++
+		} else {
+			kept = append(kept, j)
+		}
+	}
+
+	if err := t.save(kept); err != nil {
+		return 0, fmt.Errorf("failed to save jobs: %w", err)
+	}
+
+	return pruned, nil
+}
+
 // PruneOlderThan removes completed jobs (any exit code) older than the given duration, deletes their log files
 func (t *Tracker) PruneOlderThan(d time.Duration) (int, error) {
 	lockFile, err := t.lock()
@@ -488,8 +1209,8 @@ func (t *Tracker) PruneOlderThan(d time.Duration) (int, error) {
 	for _, j := range jobs {
 		// Prune if completed (any exit code) and ended before cutoff
 		if j.ExitCode != nil && j.EndTime != nil && j.EndTime.Before(cutoff) {
-			// Delete the log file (ignore errors - file may already be gone)
-			os.Remove(j.LogFile)
+			// Delete its log files too
+			removeLogs(j)
 			pruned++
 		} else {
 			kept = append(kept, j)
@@ -502,3 +1223,10 @@ func (t *Tracker) PruneOlderThan(d time.Duration) (int, error) {
 
 	return pruned, nil
 }
+
+// removeLogs deletes a job's log files, ignoring errors (files may already be gone)
+func removeLogs(j Job) {
+	for _, f := range j.LogFiles() {
+		os.Remove(f)
+	}
+}
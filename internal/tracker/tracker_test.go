@@ -0,0 +1,231 @@
+package tracker
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// newTestTracker returns a tracker keeping its jobs in a temporary directory
+func newTestTracker(t *testing.T) *Tracker {
+	t.Helper()
+	t.Setenv("BJ_CONFIG_DIR", t.TempDir())
+	tr, err := New()
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return tr
+}
+
+func TestEnqueue(t *testing.T) {
+	tr := newTestTracker(t)
+
+	// Two slots, so the third job waits
+	var ids []int
+	for range 3 {
+		id, err := tr.Enqueue(Job{Command: "true", Queue: "builds", LogFile: "log"}, 2)
+		if err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	jobs, _ := tr.List()
+	for _, j := range jobs {
+		wantQueued := j.ID == ids[2]
+		if (j.Status == StatusQueued) != wantQueued {
+			t.Errorf("job %d status = %q, queued should be %v", j.ID, j.Status, wantQueued)
+		}
+	}
+
+	// Other queues have their own slots
+	id, _ := tr.Enqueue(Job{Command: "true", Queue: "tests", LogFile: "log"}, 2)
+	if job, _ := tr.Get(id); job.Status == StatusQueued {
+		t.Errorf("job in an empty queue was queued")
+	}
+}
+
+func TestPromote(t *testing.T) {
+	tr := newTestTracker(t)
+
+	first, _ := tr.Enqueue(Job{Command: "true", Queue: "q", LogFile: "log"}, 1)
+	second, _ := tr.Enqueue(Job{Command: "true", Queue: "q", LogFile: "log"}, 1)
+	third, _ := tr.Enqueue(Job{Command: "true", Queue: "q", LogFile: "log", After: []int{first}}, 1)
+
+	// Nothing to promote while the queue is full
+	if promoted, err := tr.Promote("q", 1); err != nil || len(promoted) != 0 {
+		t.Fatalf("Promote with a full queue = %v, %v, want nothing", promoted, err)
+	}
+
+	jobs, _ := tr.List()
+	for _, j := range jobs {
+		want := map[int]int{first: 0, second: 1, third: 2}[j.ID]
+		if pos := QueuePosition(jobs, j); pos != want {
+			t.Errorf("QueuePosition(job %d) = %d, want %d", j.ID, pos, want)
+		}
+	}
+
+	tr.Complete(first, 0)
+	promoted, err := tr.Promote("q", 1)
+	if err != nil || len(promoted) != 1 || promoted[0].ID != second {
+		t.Fatalf("Promote = %v, %v, want job %d", promoted, err, second)
+	}
+
+	// A promoted job with dependencies goes on to wait for them
+	tr.Complete(second, 0)
+	promoted, _ = tr.Promote("q", 1)
+	if len(promoted) != 1 || promoted[0].Status != StatusWaiting {
+		t.Errorf("Promote = %v, want job %d waiting", promoted, third)
+	}
+}
+
+func TestPromoteSkipsJobsBeingLaunched(t *testing.T) {
+	tr := newTestTracker(t)
+
+	running, _ := tr.Enqueue(Job{Command: "true", Queue: "q", LogFile: "log"}, 1)
+	tr.Enqueue(Job{Command: "true", Queue: "q"}, 1) // no log file yet
+	tr.Complete(running, 0)
+
+	if promoted, _ := tr.Promote("q", 1); len(promoted) != 0 {
+		t.Errorf("promoted %v before its launcher was ready", promoted)
+	}
+}
+
+func TestAddNameInUse(t *testing.T) {
+	tr := newTestTracker(t)
+
+	id, _ := tr.Add(Job{Command: "sleep 1", Name: "api"})
+	if _, err := tr.Add(Job{Command: "sleep 1", Name: "api"}); !errors.Is(err, ErrNameInUse) {
+		t.Errorf("second running job named api: err = %v, want ErrNameInUse", err)
+	}
+
+	// Names only need to be unique among running jobs
+	tr.Complete(id, 0)
+	if _, err := tr.Add(Job{Command: "sleep 1", Name: "api"}); err != nil {
+		t.Errorf("reusing a finished job's name: %v", err)
+	}
+}
+
+// startGroup starts a command in its own process group, like a job's wrapper,
+// and returns its PID
+func startGroup(t *testing.T, script string) int {
+	t.Helper()
+	cmd := exec.Command("sh", "-c", script)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	go cmd.Wait()
+	t.Cleanup(func() { syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL) })
+	return cmd.Process.Pid
+}
+
+func TestKill(t *testing.T) {
+	tr := newTestTracker(t)
+
+	id, _ := tr.Add(Job{Command: "sleep 30"})
+	tr.UpdatePID(id, startGroup(t, "sleep 30"))
+
+	job, err := tr.Kill(id, syscall.SIGTERM, time.Second)
+	if err != nil {
+		t.Fatalf("Kill: %v", err)
+	}
+	if job.ExitCode == nil || *job.ExitCode != -int(syscall.SIGTERM) || job.Signal != "SIGTERM" {
+		t.Errorf("killed job exit = %v, signal = %q, want -15 and SIGTERM", job.ExitCode, job.Signal)
+	}
+	if job.Alive() {
+		t.Error("job's process group is still alive")
+	}
+
+	if _, err := tr.Kill(id, syscall.SIGTERM, time.Second); !errors.Is(err, ErrJobFinished) {
+		t.Errorf("killing it again: err = %v, want ErrJobFinished", err)
+	}
+	if _, err := tr.Kill(99, syscall.SIGTERM, time.Second); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("killing an unknown job: err = %v, want ErrJobNotFound", err)
+	}
+}
+
+func TestKillEscalates(t *testing.T) {
+	tr := newTestTracker(t)
+
+	id, _ := tr.Add(Job{Command: "stubborn"})
+	tr.UpdatePID(id, startGroup(t, `trap "" TERM; while :; do sleep 0.1; done`))
+	time.Sleep(100 * time.Millisecond) // let the trap be set
+
+	start := time.Now()
+	job, err := tr.Kill(id, syscall.SIGTERM, 300*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Kill: %v", err)
+	}
+	if job.Signal != "SIGKILL" || *job.ExitCode != -int(syscall.SIGKILL) {
+		t.Errorf("signal = %q, exit = %d, want SIGKILL after the grace period", job.Signal, *job.ExitCode)
+	}
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond {
+		t.Errorf("escalated after %s, before the grace period ran out", elapsed)
+	}
+}
+
+func TestKillQueued(t *testing.T) {
+	tr := newTestTracker(t)
+
+	tr.Enqueue(Job{Command: "true", Queue: "q", LogFile: "log"}, 1)
+	id, _ := tr.Enqueue(Job{Command: "true", Queue: "q", LogFile: "log"}, 1)
+
+	job, err := tr.Kill(id, syscall.SIGTERM, time.Second)
+	if err != nil {
+		t.Fatalf("Kill: %v", err)
+	}
+	if job.Status != StatusCancelled || job.Signal != "" || *job.ExitCode != ExitSkipped {
+		t.Errorf("status = %q, signal = %q, exit = %d, want cancelled without a signal", job.Status, job.Signal, *job.ExitCode)
+	}
+	if !job.Failed() {
+		t.Error("a cancelled job should count as failed")
+	}
+}
+
+func TestParseSignal(t *testing.T) {
+	for in, want := range map[string]syscall.Signal{
+		"TERM": syscall.SIGTERM, "sigint": syscall.SIGINT, "SIGHUP": syscall.SIGHUP, "9": syscall.SIGKILL,
+	} {
+		if got, err := ParseSignal(in); err != nil || got != want {
+			t.Errorf("ParseSignal(%q) = %v, %v, want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "NOPE", "0", "11", "SIGSEGV"} {
+		if _, err := ParseSignal(in); err == nil {
+			t.Errorf("ParseSignal(%q) accepted an unsupported signal", in)
+		}
+	}
+}
+
+func TestFilesArePrivate(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("BJ_CONFIG_DIR", dir)
+
+	// Files left readable by an older bj are tightened up
+	jobsFile := filepath.Join(dir, "jobs.json")
+	os.WriteFile(jobsFile, []byte("[]"), 0644)
+	os.Chmod(jobsFile, 0644)
+
+	tr, err := New()
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := tr.Add(Job{Command: "env", Env: []string{"TOKEN=secret"}}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	for _, name := range []string{"jobs.json", "jobs.lock"} {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if perm := info.Mode().Perm(); perm != 0600 {
+			t.Errorf("%s mode = %o, want 600", name, perm)
+		}
+	}
+}
@@ -1,45 +1,135 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
+	"text/template"
 	"time"
 
 	"github.com/metruzanca/bj/internal/config"
 	"github.com/metruzanca/bj/internal/locales"
 	"github.com/metruzanca/bj/internal/runner"
+	"github.com/metruzanca/bj/internal/term"
 	"github.com/metruzanca/bj/internal/tracker"
 )
 
 // ANSI color codes
 const (
-	colorReset = "\033[0m"
-	colorDim   = "\033[2m"
-	colorRed   = "\033[31m"
+	colorReset  = "\033[0m"
+	colorDim    = "\033[2m"
+	colorRed    = "\033[31m"
+	colorBold   = "\033[1m"
+	colorYellow = "\033[33m"
 )
 
 // Global flags
 var jsonOutput bool
 var helpRequested bool
-var retryFlag int    // -1 = not set, 0 = unlimited, N = max attempts
-var retryJobID int   // 0 = not set (use latest), N = specific job ID
-var retryDelay int   // delay in seconds between retries (default 1)
-var restartFlag bool // -1 = not set, true = restart on failure
+var retryFlag int              // -1 = not set, 0 = unlimited, N = max attempts
+var retryJobRef string         // "" = not set (use latest), otherwise a job ID or name
+var retryDelay int             // -1 = not set, otherwise seconds between retries or restarts
+var restartFlag bool           // -1 = not set, true = restart on failure
+var nameFlag string            // optional name for a newly launched job
+var timeoutFlag time.Duration  // 0 = no timeout, otherwise kill each run after this long
+var afterRefs []string         // jobs (IDs or names) that must succeed before a new job runs
+var afterAnyRefs []string      // jobs (IDs or names) that must finish before a new job runs
+var queueFlag string           // queue a new job runs in ("" = start immediately)
+var timestampsFlag bool        // prefix a new job's output lines with the time they were written
+var maxLogSizeFlag int64       // rotate a new job's logs at this size (0 = use config)
+var maxLogFilesFlag int        // rotated segments a new job keeps per log (0 = use config)
+var backoffFlag string         // how the delay between retries or restarts grows ("" = fixed)
+var maxDelayFlag time.Duration // upper bound on the delay between retries or restarts (0 = default)
+var jitterFlag bool            // randomize the delay between retries or restarts
+
+// Restart policy flags
+var restartAlways bool              // --restart=always: restart after a clean exit too
+var restartLimitFlag int            // give up on a restart job after this many restarts in the window (0 = never)
+var restartWindowFlag time.Duration // window --restart-limit counts restarts in (0 = default)
+
+// Success condition flags
+var okCodesFlag []int       // exit codes a new job counts as success (nil = just 0)
+var failIfOutputFlag string // fail a new job's run when a line of its output matches this regexp
+
+// Environment flags
+var envFlags []string // KEY=VAL entries from --env and --env-file, in order (later ones win)
+var revealEnv bool    // --reveal-env: print jobs' environment values instead of hiding them
+var cwdFlag string    // absolute directory a new job runs in ("" = the current directory)
+
+// Resource limit flags
+var limitsFlag tracker.Limits // --nice, --ionice and --max-memory/--max-cpu-time/--max-open-files (zero = config default)
+
+// followPollInterval is how often --logs --follow checks for new output
+const followPollInterval = 100 * time.Millisecond
+
+// waitPollInterval is how often --wait checks on the jobs it's waiting for
+const waitPollInterval = 200 * time.Millisecond
+
+// Logs flags
+var logsFollow bool     // --follow: stream the log until the job finishes
+var logsStream string   // --stdout/--stderr: show only that stream ("" = both, interleaved)
+var sinceFlag time.Time // --since: only show log lines written at or after this time
+var untilFlag time.Time // --until: only show log lines written at or before this time
+var attemptFlag int     // --attempt: show only this run of a retry or restart job (0 = all)
+
+// Show flags
+var showLines = 10 // --lines: how many of the log's last lines --show prints (0 = none)
+
+// Kill flags
+var killSignal = syscall.SIGTERM         // signal sent first by --kill
+var killGrace = tracker.DefaultKillGrace // how long --kill waits before SIGKILL
+var killAll bool                         // --kill every unfinished job
+var killFailedDeps bool                  // --failed-deps: only --kill --all jobs with a dependency that failed
 
 // List filter flags
 var listRunning bool
 var listFailed bool
 var listDone bool
+var hereDir string      // --here/--dir: only jobs started in or below this directory ("" = anywhere)
+var listSince time.Time // --since: only jobs started at or after this time
+var listCmd string      // --cmd: only jobs whose command contains this
+var listExitCode *int   // --exit-code: only jobs that exited with this code (nil = any)
+var listWide bool       // --wide: add resource usage columns to --list
+
+// Sort flags
+var listSort string  // --sort: order jobs by start, duration, id or status ("" = newest first)
+var listReverse bool // --reverse: flip the order
+var listLimit int    // --limit: only the first N jobs once sorted (0 = all)
+
+// sortKeys are the values --sort accepts
+var sortKeys = []string{"start", "duration", "id", "status"}
+
+// Watch flags
+var listWatch time.Duration // --watch: redraw --list this often until interrupted (0 = print once)
+
+// Format flags
+var formatFlag string                 // --format: how --list, --show and --ids print jobs ("" = the table)
+var formatTemplate *template.Template // the parsed --format, if it isn't one of the named formats
+
+// defaultWatchInterval is how often --list --watch redraws without an INTERVAL
+const defaultWatchInterval = 2 * time.Second
+
+// watchHighlight is how long --list --watch highlights a job whose status just
+// changed (at least one redraw, however long the interval)
+const watchHighlight = 5 * time.Second
 
 func main() {
-	// Initialize retryFlag to -1 (not set) and delay to 1 second
+	// Initialize retryFlag and retryDelay to -1 (not set)
 	retryFlag = -1
-	retryDelay = 1
+	retryDelay = -1
 	restartFlag = false
 
 	// Load config first so we can initialize locales (needed for help messages)
@@ -52,14 +142,18 @@ func main() {
 	// Initialize locales based on config
 	locales.Init(cfg.NSFW)
 
-	// Check for --json, --help, --retry[=N], --id, and --restart flags anywhere in args
-	args := filterArgs(os.Args[1:], &jsonOutput, &helpRequested, &retryFlag, &retryJobID, &restartFlag)
+	// Pick out bj's own flags, which come before the command (or "--")
+	args := filterArgs(os.Args[1:], &jsonOutput, &helpRequested, &retryFlag, &retryJobRef, &restartFlag)
 
-	// Handle help for --retry
+	// Handle help for --retry and --restart
 	if helpRequested && retryFlag >= 0 {
 		printUsage("--retry")
 		os.Exit(0)
 	}
+	if helpRequested && restartFlag {
+		printUsage("--restart")
+		os.Exit(0)
+	}
 
 	// Handle general help
 	if helpRequested || (len(args) < 1 && retryFlag < 0) {
@@ -72,15 +166,43 @@ func main() {
 	}
 
 	// Validate --id is only used with --retry
-	if retryJobID != 0 && retryFlag < 0 {
+	if retryJobRef != "" && retryFlag < 0 {
 		exitWithError(locales.Msg("err.id_only_with_retry"))
 	}
 
-	// Validate --delay is only used with --retry
-	if retryDelay != 1 && retryFlag < 0 {
+	// Validate --delay is only used with --retry or --restart
+	if retryDelay >= 0 && retryFlag < 0 && !restartFlag {
 		exitWithError(locales.Msg("err.delay_only_with_retry"))
 	}
 
+	// Backoff shapes the delay between runs, so it needs a mode that reruns
+	if (backoffFlag != "" || maxDelayFlag > 0 || jitterFlag) && retryFlag < 0 && !restartFlag {
+		exitWithError(locales.Msg("err.backoff_without_retry"))
+	}
+
+	// --follow streams plain text, there's no JSON document to build
+	if logsFollow && jsonOutput {
+		exitWithError(locales.Msg("err.follow_json"))
+	}
+
+	// --watch redraws a table, there's no single JSON document to build
+	if listWatch > 0 && jsonOutput {
+		exitWithError(locales.Msg("err.watch_json"))
+	}
+
+	// --format picks a text layout, --json already has one
+	if formatFlag != "" && jsonOutput {
+		exitWithError(locales.Msg("err.format_json"))
+	}
+
+	// The crash loop policy only applies to restart jobs
+	if (restartLimitFlag > 0 || restartWindowFlag > 0) && !restartFlag {
+		exitWithError(locales.Msg("err.limit_without_restart"))
+	}
+	if restartWindowFlag > 0 && restartLimitFlag == 0 {
+		exitWithError(locales.Msg("err.window_needs_limit"))
+	}
+
 	// Validate --restart and --retry are mutually exclusive
 	if restartFlag && retryFlag >= 0 {
 		exitWithError(locales.Msg("err.restart_and_retry"))
@@ -92,6 +214,13 @@ func main() {
 		exitWithError(locales.Msg("err.tracker_init", err))
 	}
 
+	// Finished jobs' logs get compressed by whichever bj records their end
+	compressMinSize, err := cfg.CompressThreshold()
+	if err != nil {
+		exitWithError(locales.Msg("err.invalid_size", cfg.CompressMinSize))
+	}
+	t.SetLogCompression(compressMinSize)
+
 	// Auto-prune if configured
 	if cfg.AutoPruneHours > 0 {
 		t.PruneOlderThan(time.Duration(cfg.AutoPruneHours) * time.Hour)
@@ -104,19 +233,26 @@ func main() {
 		}
 		// Run new command with restart (infinite loop on failure)
 		command := strings.Join(args, " ")
-		runCommandWithRestart(cfg, t, command)
+		delaySecs := cfg.RestartDelay
+		if retryDelay >= 0 {
+			delaySecs = retryDelay
+		}
+		runCommandWithRestart(cfg, t, command, delaySecs)
 		return
 	}
 
 	// Handle --retry as a modifier flag
 	if retryFlag >= 0 {
+		if retryDelay < 0 {
+			retryDelay = 1
+		}
 		if len(args) > 0 {
 			// Run new command with retry
 			command := strings.Join(args, " ")
 			runCommandWithRetry(cfg, t, command, retryFlag, retryDelay)
 		} else {
 			// Retry existing job
-			retryExistingJob(cfg, t, retryJobID, retryFlag, retryDelay)
+			retryExistingJob(cfg, t, retryJobRef, retryFlag, retryDelay)
 		}
 		return
 	}
@@ -144,35 +280,121 @@ func main() {
 		listJobs(t)
 
 	case arg == "--ids":
-		printJobIDs(t)
+		var ref string
+		if len(args) > 1 {
+			ref = args[1]
+		}
+		printJobIDs(t, ref)
+
+	case arg == "--names":
+		printJobNames(t)
+
+	case arg == "--show":
+		var ref string
+		if len(args) > 1 {
+			ref = args[1]
+		}
+		showJob(t, ref)
+
+	case arg == "--tui":
+		runTUI(cfg, t)
+
+	case arg == "--grep":
+		if len(args) < 2 {
+			exitWithError(locales.Msg("err.grep_needs_pattern"))
+		}
+		grepLogs(t, args[1])
 
 	case arg == "--logs":
-		var jobID int
+		var ref string
 		if len(args) > 1 {
-			id, err := strconv.Atoi(args[1])
-			if err != nil {
-				exitWithError(locales.Msg("err.invalid_number", args[1]))
-			}
-			jobID = id
+			ref = args[1]
 		}
-		viewLogs(cfg, t, jobID)
+		viewLogs(cfg, t, ref)
 
 	case arg == "--prune":
 		pruneJobs(t)
 
 	case arg == "--gc":
-		garbageCollect(t)
+		garbageCollect(cfg, t)
 
 	case arg == "--kill":
-		var jobID int
+		var ref string
 		if len(args) > 1 {
-			id, err := strconv.Atoi(args[1])
-			if err != nil {
-				exitWithError(locales.Msg("err.invalid_number", args[1]))
+			ref = args[1]
+		}
+		if killAll {
+			if ref != "" {
+				exitWithError(locales.Msg("err.kill_all_with_ref", ref))
 			}
-			jobID = id
+			killAllJobs(cfg, t)
+			break
+		}
+		if killFailedDeps {
+			exitWithError(locales.Msg("err.failed_deps_all"))
+		}
+		killJob(cfg, t, ref)
+
+	case arg == "--wait":
+		waitForJobs(t, args[1:])
+
+	case arg == "--pause", arg == "--resume":
+		var ref string
+		if len(args) > 1 {
+			ref = args[1]
+		}
+		suspendJob(t, ref, arg == "--pause")
+
+	case arg == "--exec":
+		// Internal command: run a job's command under bj's supervision
+		if len(args) < 2 {
+			fmt.Fprintf(os.Stderr, "%s\n", locales.Msg("err.exec_usage"))
+			os.Exit(1)
+		}
+		jobID, err := strconv.Atoi(args[1])
+		if err != nil {
+			exitWithError(locales.Msg("err.invalid_job_id", args[1]))
+		}
+		r := runner.New(cfg, t)
+		exitCode, err := r.Exec(jobID)
+		if err != nil {
+			exitWithError(locales.Msg("err.exec_failed", err))
+		}
+		os.Exit(exitCode)
+
+	case arg == "--confine":
+		// Internal command: apply a job's resource limits, then become its command
+		if len(args) < 2 {
+			fmt.Fprintf(os.Stderr, "%s\n", locales.Msg("err.confine_usage"))
+			os.Exit(1)
+		}
+		jobID, err := strconv.Atoi(args[1])
+		if err != nil {
+			exitWithError(locales.Msg("err.invalid_job_id", args[1]))
+		}
+		r := runner.New(cfg, t)
+		if err := r.Confine(jobID); err != nil {
+			exitWithError(locales.Msg("err.confine_failed", err))
+		}
+
+	case arg == "--await":
+		// Internal command: block until a job's dependencies finish
+		if len(args) < 2 {
+			fmt.Fprintf(os.Stderr, "%s\n", locales.Msg("err.await_usage"))
+			os.Exit(1)
+		}
+		jobID, err := strconv.Atoi(args[1])
+		if err != nil {
+			exitWithError(locales.Msg("err.invalid_job_id", args[1]))
+		}
+		r := runner.New(cfg, t)
+		ready, err := r.Await(jobID)
+		if err != nil {
+			exitWithError(locales.Msg("err.await_failed", err))
+		}
+		if !ready {
+			os.Exit(1)
 		}
-		killJob(t, jobID)
 
 	case arg == "--complete":
 		// Internal command: mark job as complete
@@ -204,11 +426,47 @@ func main() {
 	}
 }
 
-// filterArgs removes global flags, sets flag values, returns remaining args
-func filterArgs(args []string, jsonFlag *bool, helpFlag *bool, retryFlagOut *int, retryJobIDOut *int, restartFlagOut *bool) []string {
+// filterArgs removes global flags, sets flag values, returns remaining args.
+// bj's flags end at "--" or at the first argument of a command to run, everything
+// from there on belongs to the command.
+func filterArgs(args []string, jsonFlag *bool, helpFlag *bool, retryFlagOut *int, retryJobRefOut *string, restartFlagOut *bool) []string {
 	var filtered []string
+	seenAction := false
+	seenLogs := false
+	seenGrep := false
+	seenShow := false
+	seenList := false
+	seenIDs := false
+	seenPrune := false
 	for i := 0; i < len(args); i++ {
 		arg := args[i]
+		if arg == "--" {
+			return append(filtered, args[i+1:]...)
+		}
+		if !seenAction && !strings.HasPrefix(arg, "-") {
+			// Not an action's argument, so it starts the command
+			return append(filtered, args[i:]...)
+		}
+		if arg == "--logs" {
+			seenLogs = true
+		}
+		if arg == "--show" {
+			seenShow = true
+		}
+		if arg == "--grep" {
+			seenGrep = true
+		}
+		if arg == "--list" {
+			seenList = true
+		}
+		if arg == "--ids" {
+			seenIDs = true
+		}
+		if arg == "--prune" {
+			seenPrune = true
+		}
+		// Options for picking jobs only count after a command that lists them
+		selecting := seenList || seenIDs || seenPrune
 		switch {
 		case arg == "--json":
 			*jsonFlag = true
@@ -225,30 +483,276 @@ func filterArgs(args []string, jsonFlag *bool, helpFlag *bool, retryFlagOut *int
 			}
 			*retryFlagOut = n
 		case arg == "--id":
-			// --id requires a following positive number
+			// --id requires a following positive number or job name
 			if i+1 >= len(args) {
 				fmt.Fprintln(os.Stderr, locales.Msg("err.id_needs_value"))
 				os.Exit(1)
 			}
 			i++
-			id, err := strconv.Atoi(args[i])
+			if id, err := strconv.Atoi(args[i]); err == nil && id < 1 {
+				fmt.Fprintln(os.Stderr, locales.Msg("err.job_id_minimum", id))
+				os.Exit(1)
+			}
+			*retryJobRefOut = args[i]
+		case arg == "--name" || strings.HasPrefix(arg, "--name="):
+			val := flagValue(args, &i, "err.name_needs_value")
+			// Names that look like IDs would be ambiguous everywhere a job is referenced
+			if _, err := strconv.Atoi(val); err == nil {
+				fmt.Fprintln(os.Stderr, locales.Msg("err.name_numeric", val))
+				os.Exit(1)
+			}
+			nameFlag = val
+		case arg == "--timeout" || strings.HasPrefix(arg, "--timeout="):
+			val := flagValue(args, &i, "err.timeout_needs_value")
+			d, err := time.ParseDuration(val)
+			if err != nil || d <= 0 {
+				fmt.Fprintln(os.Stderr, locales.Msg("err.invalid_duration", val))
+				os.Exit(1)
+			}
+			timeoutFlag = d
+		case arg == "--after" || strings.HasPrefix(arg, "--after="):
+			afterRefs = append(afterRefs, splitRefs(flagValue(args, &i, "err.after_needs_value"))...)
+		case arg == "--after-any" || strings.HasPrefix(arg, "--after-any="):
+			afterAnyRefs = append(afterAnyRefs, splitRefs(flagValue(args, &i, "err.after_needs_value"))...)
+		case arg == "--queue" || strings.HasPrefix(arg, "--queue="):
+			queueFlag = flagValue(args, &i, "err.queue_needs_value")
+		case arg == "--signal" || strings.HasPrefix(arg, "--signal="):
+			val := flagValue(args, &i, "err.signal_needs_value")
+			sig, err := tracker.ParseSignal(val)
 			if err != nil {
-				fmt.Fprintln(os.Stderr, locales.Msg("err.invalid_job_id", args[i]))
+				fmt.Fprintln(os.Stderr, locales.Msg("err.invalid_signal", val))
 				os.Exit(1)
 			}
-			if id < 1 {
-				fmt.Fprintln(os.Stderr, locales.Msg("err.job_id_minimum", id))
+			killSignal = sig
+		case arg == "--grace" || strings.HasPrefix(arg, "--grace="):
+			val := flagValue(args, &i, "err.grace_needs_value")
+			d, err := time.ParseDuration(val)
+			if err != nil || d < 0 {
+				fmt.Fprintln(os.Stderr, locales.Msg("err.invalid_duration", val))
+				os.Exit(1)
+			}
+			killGrace = d
+		case arg == "--all":
+			killAll = true
+		case arg == "--failed-deps":
+			killFailedDeps = true
+		case arg == "--timestamps":
+			timestampsFlag = true
+		case arg == "--max-log-size" || strings.HasPrefix(arg, "--max-log-size="):
+			val := flagValue(args, &i, "err.log_size_needs_value")
+			size, err := config.ParseSize(val)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, locales.Msg("err.invalid_size", val))
+				os.Exit(1)
+			}
+			maxLogSizeFlag = size
+		case arg == "--max-log-files" || strings.HasPrefix(arg, "--max-log-files="):
+			val := flagValue(args, &i, "err.log_files_needs_value")
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				fmt.Fprintln(os.Stderr, locales.Msg("err.log_files_positive", val))
+				os.Exit(1)
+			}
+			maxLogFilesFlag = n
+		case (arg == "--since" || strings.HasPrefix(arg, "--since=")) && selecting:
+			// For --logs --since is about log lines, here it's when jobs started
+			listSince = timeFlagValue(args, &i, "err.since_needs_value")
+		case (arg == "--sort" || strings.HasPrefix(arg, "--sort=")) && selecting:
+			val := flagValue(args, &i, "err.sort_needs_value")
+			if !slices.Contains(sortKeys, val) {
+				fmt.Fprintln(os.Stderr, locales.Msg("err.invalid_sort", val))
+				os.Exit(1)
+			}
+			listSort = val
+		case arg == "--reverse" && selecting:
+			listReverse = true
+		case (arg == "--limit" || strings.HasPrefix(arg, "--limit=")) && selecting:
+			val := flagValue(args, &i, "err.max_jobs_needs_value")
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				fmt.Fprintln(os.Stderr, locales.Msg("err.invalid_max_jobs", val))
+				os.Exit(1)
+			}
+			listLimit = n
+		case (arg == "--cmd" || strings.HasPrefix(arg, "--cmd=")) && selecting:
+			listCmd = flagValue(args, &i, "err.cmd_needs_value")
+		case (arg == "--exit-code" || strings.HasPrefix(arg, "--exit-code=")) && selecting:
+			val := flagValue(args, &i, "err.exit_code_needs_value")
+			// Negative codes are how killed and lost jobs are recorded
+			n, err := strconv.Atoi(val)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, locales.Msg("err.invalid_exit_code", val))
+				os.Exit(1)
+			}
+			listExitCode = &n
+		case (arg == "--dir" || strings.HasPrefix(arg, "--dir=")) && selecting:
+			// The directory may be long gone, that's no reason not to prune its jobs
+			val := flagValue(args, &i, "err.dir_needs_value")
+			dir, err := filepath.Abs(val)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, locales.Msg("err.here_failed", err))
+				os.Exit(1)
+			}
+			hereDir = dir
+		case (arg == "--since" || strings.HasPrefix(arg, "--since=")) && seenLogs:
+			sinceFlag = timeFlagValue(args, &i, "err.since_needs_value")
+		case (arg == "--until" || strings.HasPrefix(arg, "--until=")) && seenLogs:
+			untilFlag = timeFlagValue(args, &i, "err.until_needs_value")
+		case arg == "--follow" || (arg == "-f" && seenLogs):
+			// -f is only ours after --logs, otherwise it belongs to the command (tail -f)
+			logsFollow = true
+		case (arg == "--attempt" || strings.HasPrefix(arg, "--attempt=")) && seenLogs:
+			val := flagValue(args, &i, "err.attempt_needs_value")
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				fmt.Fprintln(os.Stderr, locales.Msg("err.attempt_positive", val))
+				os.Exit(1)
+			}
+			attemptFlag = n
+		case (arg == "--lines" || strings.HasPrefix(arg, "--lines=")) && seenShow:
+			val := flagValue(args, &i, "err.lines_needs_value")
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 0 {
+				fmt.Fprintln(os.Stderr, locales.Msg("err.invalid_lines", val))
+				os.Exit(1)
+			}
+			showLines = n
+		case arg == "--watch" && seenList:
+			// The interval is optional, the next argument is only it if it isn't a flag
+			listWatch = defaultWatchInterval
+			if i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+				i++
+				d, ok := parseInterval(args[i])
+				if !ok {
+					fmt.Fprintln(os.Stderr, locales.Msg("err.invalid_interval", args[i]))
+					os.Exit(1)
+				}
+				listWatch = d
+			}
+		case strings.HasPrefix(arg, "--watch=") && seenList:
+			val := strings.TrimPrefix(arg, "--watch=")
+			d, ok := parseInterval(val)
+			if !ok {
+				fmt.Fprintln(os.Stderr, locales.Msg("err.invalid_interval", val))
+				os.Exit(1)
+			}
+			listWatch = d
+		case (arg == "--format" || strings.HasPrefix(arg, "--format=")) && (seenList || seenShow || seenIDs):
+			val := flagValue(args, &i, "err.format_needs_value")
+			tmpl, err := parseFormat(val)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, locales.Msg("err.invalid_format", err))
+				os.Exit(1)
+			}
+			if val == formatWide {
+				listWide = true
+			}
+			formatFlag = val
+			formatTemplate = tmpl
+		case (arg == "--stdout" || arg == "--stderr") && seenLogs:
+			stream := strings.TrimPrefix(arg, "--")
+			if logsStream != "" && logsStream != stream {
+				fmt.Fprintln(os.Stderr, locales.Msg("err.logs_stream_conflict"))
 				os.Exit(1)
 			}
-			*retryJobIDOut = id
+			logsStream = stream
 		case arg == "--restart":
 			*restartFlagOut = true
+		case strings.HasPrefix(arg, "--restart="):
+			switch policy := strings.TrimPrefix(arg, "--restart="); policy {
+			case "always":
+				restartAlways = true
+			case "on-failure":
+				restartAlways = false
+			default:
+				fmt.Fprintln(os.Stderr, locales.Msg("err.invalid_restart", policy))
+				os.Exit(1)
+			}
+			*restartFlagOut = true
+		case arg == "--restart-limit" || strings.HasPrefix(arg, "--restart-limit="):
+			val := flagValue(args, &i, "err.limit_needs_value")
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				fmt.Fprintln(os.Stderr, locales.Msg("err.limit_positive", val))
+				os.Exit(1)
+			}
+			restartLimitFlag = n
+		case arg == "--restart-window" || strings.HasPrefix(arg, "--restart-window="):
+			val := flagValue(args, &i, "err.window_needs_value")
+			d, err := time.ParseDuration(val)
+			if err != nil || d <= 0 {
+				fmt.Fprintln(os.Stderr, locales.Msg("err.invalid_duration", val))
+				os.Exit(1)
+			}
+			restartWindowFlag = d
 		case arg == "--running":
 			listRunning = true
 		case arg == "--failed":
 			listFailed = true
 		case arg == "--done":
 			listDone = true
+		case arg == "--wide":
+			listWide = true
+		case arg == "--nice" || strings.HasPrefix(arg, "--nice="):
+			val := flagValue(args, &i, "err.nice_needs_value")
+			n, err := strconv.Atoi(val)
+			if err != nil || n < -20 || n > 19 {
+				fmt.Fprintln(os.Stderr, locales.Msg("err.invalid_nice", val))
+				os.Exit(1)
+			}
+			limitsFlag.Nice = n
+		case arg == "--ionice" || strings.HasPrefix(arg, "--ionice="):
+			val := flagValue(args, &i, "err.ionice_needs_value")
+			if !validIOClass(val) {
+				fmt.Fprintln(os.Stderr, locales.Msg("err.invalid_ionice", val))
+				os.Exit(1)
+			}
+			limitsFlag.IOClass = val
+		case arg == "--max-memory" || strings.HasPrefix(arg, "--max-memory="):
+			val := flagValue(args, &i, "err.memory_needs_value")
+			size, err := config.ParseSize(val)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, locales.Msg("err.invalid_size", val))
+				os.Exit(1)
+			}
+			limitsFlag.MaxMemory = size
+		case arg == "--max-cpu-time" || strings.HasPrefix(arg, "--max-cpu-time="):
+			val := flagValue(args, &i, "err.cpu_time_needs_value")
+			d, err := time.ParseDuration(val)
+			if err != nil || d <= 0 {
+				fmt.Fprintln(os.Stderr, locales.Msg("err.invalid_duration", val))
+				os.Exit(1)
+			}
+			limitsFlag.MaxCPUTime = d
+		case arg == "--max-open-files" || strings.HasPrefix(arg, "--max-open-files="):
+			val := flagValue(args, &i, "err.max_files_needs_value")
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				fmt.Fprintln(os.Stderr, locales.Msg("err.open_files_positive", val))
+				os.Exit(1)
+			}
+			limitsFlag.MaxOpenFiles = n
+		case arg == "--here":
+			dir, err := os.Getwd()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, locales.Msg("err.here_failed", err))
+				os.Exit(1)
+			}
+			hereDir = dir
+		case arg == "--cwd" || strings.HasPrefix(arg, "--cwd="):
+			val := flagValue(args, &i, "err.cwd_needs_value")
+			dir, err := filepath.Abs(val)
+			if err == nil {
+				var info os.FileInfo
+				if info, err = os.Stat(dir); err == nil && !info.IsDir() {
+					err = fmt.Errorf("not a directory")
+				}
+			}
+			if err != nil {
+				fmt.Fprintln(os.Stderr, locales.Msg("err.invalid_cwd", val))
+				os.Exit(1)
+			}
+			cwdFlag = dir
 		case arg == "--delay":
 			// --delay requires a following number
 			if i+1 >= len(args) {
@@ -270,13 +774,151 @@ func filterArgs(args []string, jsonFlag *bool, helpFlag *bool, retryFlagOut *int
 				os.Exit(1)
 			}
 			retryDelay = d
+		case arg == "--backoff" || strings.HasPrefix(arg, "--backoff="):
+			val := flagValue(args, &i, "err.backoff_needs_value")
+			switch val {
+			case tracker.BackoffFixed, tracker.BackoffLinear, tracker.BackoffExponential:
+				backoffFlag = val
+			default:
+				fmt.Fprintln(os.Stderr, locales.Msg("err.invalid_backoff", val))
+				os.Exit(1)
+			}
+		case arg == "--max-delay" || strings.HasPrefix(arg, "--max-delay="):
+			val := flagValue(args, &i, "err.max_delay_needs_value")
+			d, err := time.ParseDuration(val)
+			if err != nil || d <= 0 {
+				fmt.Fprintln(os.Stderr, locales.Msg("err.invalid_duration", val))
+				os.Exit(1)
+			}
+			maxDelayFlag = d
+		case arg == "--jitter":
+			jitterFlag = true
+		case arg == "--ok-codes" || strings.HasPrefix(arg, "--ok-codes="):
+			for _, val := range splitRefs(flagValue(args, &i, "err.ok_codes_needs_value")) {
+				code, err := strconv.Atoi(val)
+				if err != nil || code < 0 || code > 255 {
+					fmt.Fprintln(os.Stderr, locales.Msg("err.invalid_ok_code", val))
+					os.Exit(1)
+				}
+				okCodesFlag = append(okCodesFlag, code)
+			}
+		case arg == "--reveal-env" && (seenList || seenShow || seenLogs || seenGrep):
+			revealEnv = true
+		case arg == "--env" || strings.HasPrefix(arg, "--env="):
+			val := flagValue(args, &i, "err.env_needs_value")
+			if key, _, ok := strings.Cut(val, "="); !ok || key == "" {
+				fmt.Fprintln(os.Stderr, locales.Msg("err.invalid_env", val))
+				os.Exit(1)
+			}
+			envFlags = append(envFlags, val)
+		case arg == "--env-file" || strings.HasPrefix(arg, "--env-file="):
+			val := flagValue(args, &i, "err.env_file_needs_value")
+			entries, err := runner.ParseEnvFile(val)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, locales.Msg("err.env_file_failed", val, err))
+				os.Exit(1)
+			}
+			envFlags = append(envFlags, entries...)
+		case arg == "--fail-if-output" || strings.HasPrefix(arg, "--fail-if-output="):
+			val := flagValue(args, &i, "err.fail_if_needs_value")
+			if _, err := regexp.Compile(val); err != nil {
+				fmt.Fprintln(os.Stderr, locales.Msg("err.grep_invalid_pattern", err))
+				os.Exit(1)
+			}
+			failIfOutputFlag = val
 		default:
+			// Any other flag is an action (--list, --logs, ...), its arguments aren't a command
+			seenAction = true
 			filtered = append(filtered, arg)
 		}
 	}
 	return filtered
 }
 
+// flagValue returns the value of a flag given as "--flag value" or "--flag=value",
+// advancing i past a separate value. Exits with needsKey if the value is missing.
+func flagValue(args []string, i *int, needsKey string) string {
+	var val string
+	if eq := strings.Index(args[*i], "="); eq >= 0 {
+		val = args[*i][eq+1:]
+	} else if *i+1 < len(args) {
+		*i++
+		val = args[*i]
+	}
+	if val == "" {
+		fmt.Fprintln(os.Stderr, locales.Msg(needsKey))
+		os.Exit(1)
+	}
+	return val
+}
+
+// timeFlagValue returns the time a flag's value refers to, exiting with an error
+// if it's missing or can't be parsed
+func timeFlagValue(args []string, i *int, needsKey string) time.Time {
+	val := flagValue(args, i, needsKey)
+	ts, err := parseTimeRef(val, time.Now())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, locales.Msg("err.invalid_time", val))
+		os.Exit(1)
+	}
+	return ts
+}
+
+// parseInterval parses a --watch interval, given as a duration (500ms, 2s) or
+// a number of seconds like watch(1) takes (2, 0.5)
+func parseInterval(s string) (time.Duration, bool) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		secs, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, false
+		}
+		d = time.Duration(secs * float64(time.Second))
+	}
+	return d, d > 0
+}
+
+// timeRefLayouts are the absolute time formats accepted by --since and --until,
+// interpreted in local time unless they include a zone
+var timeRefLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// parseTimeRef parses a point in time given either as a duration before now
+// (10m, 2h), an absolute date and time, or a time of day today (15:04, 15:04:05)
+func parseTimeRef(s string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	for _, layout := range timeRefLayouts {
+		if ts, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return ts, nil
+		}
+	}
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		if ts, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			y, m, d := now.Date()
+			return time.Date(y, m, d, ts.Hour(), ts.Minute(), ts.Second(), 0, time.Local), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized time %q", s)
+}
+
+// splitRefs splits a comma separated list of job IDs or names
+func splitRefs(val string) []string {
+	var refs []string
+	for _, ref := range strings.Split(val, ",") {
+		if ref = strings.TrimSpace(ref); ref != "" {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
 // exitWithError prints error (or JSON) and exits
 func exitWithError(msg string) {
 	if jsonOutput {
@@ -287,74 +929,319 @@ func exitWithError(msg string) {
 	os.Exit(1)
 }
 
-// outputJSON marshals and prints JSON
-func outputJSON(v interface{}) {
-	data, _ := json.MarshalIndent(v, "", "  ")
-	fmt.Println(string(data))
-}
-
-func printUsage(command string) {
-	switch command {
-	case "--list":
-		fmt.Println(locales.Msg("help.list"))
-	case "--logs":
-		fmt.Println(locales.Msg("help.logs"))
-	case "--prune":
-		fmt.Println(locales.Msg("help.prune"))
-	case "--kill":
-		fmt.Println(locales.Msg("help.kill"))
-	case "--gc":
-		fmt.Println(locales.Msg("help.gc"))
-	case "--restart":
-		fmt.Println(locales.Msg("help.restart"))
-	case "--retry":
-		fmt.Println(locales.Msg("help.retry"))
-	case "--completion":
-		fmt.Println(locales.Msg("help.completion"))
-	case "--init":
-		fmt.Println(locales.Msg("help.init"))
-	default:
-		fmt.Println(locales.Msg("help.main"))
+// exitWithRunError reports a failed launch, calling out name clashes specifically
+func exitWithRunError(key string, opts runner.Options, err error) {
+	if errors.Is(err, tracker.ErrNameInUse) {
+		exitWithError(locales.Msg("err.name_in_use", opts.Name))
 	}
+	exitWithError(locales.Msg(key, err))
 }
 
-func runCommand(cfg *config.Config, t *tracker.Tracker, command string) {
-	r := runner.New(cfg, t)
-	jobID, err := r.Run(command)
+// findJob resolves a job ID or name, exiting if it can't be found
+func findJob(t *tracker.Tracker, ref string, errKey string) *tracker.Job {
+	job, err := t.Find(ref)
 	if err != nil {
-		exitWithError(locales.Msg("err.run_failed", err))
+		exitWithError(locales.Msg(errKey, err))
 	}
-	if jsonOutput {
-		outputJSON(map[string]interface{}{
-			"id":      jobID,
-			"command": command,
-			"status":  "started",
-		})
-	} else {
-		fmt.Println(locales.Msg("job.started", jobID, command))
+	if job == nil {
+		exitWithError(locales.Msg("err.job_not_found", ref))
 	}
+	return job
 }
 
-type jobRow struct {
-	id       int
-	status   string
-	start    string
-	duration string
-	cmd      string
-	isError  bool
-	isDone   bool
+// launchDir returns the directory a new job runs in: --cwd if it was given,
+// otherwise the current directory. Exits with errKey if that can't be found.
+func launchDir(errKey string) string {
+	if cwdFlag != "" {
+		return cwdFlag
+	}
+	pwd, err := os.Getwd()
+	if err != nil {
+		exitWithError(locales.Msg(errKey, err))
+	}
+	return pwd
 }
 
-// relativeTime returns a human-friendly relative time string
-func relativeTime(t time.Time) string {
-	d := time.Since(t)
+// launchOptions collects the per-job settings from the command line,
+// resolving --after/--after-any references to job IDs
+func launchOptions(cfg *config.Config, t *tracker.Tracker) runner.Options {
+	opts := runner.Options{
+		Name:       nameFlag,
+		Timeout:    timeoutFlag,
+		Queue:      queueFlag,
+		Timestamps: timestampsFlag || cfg.LogTimestamps,
+		Backoff:    backoffFlag,
+		MaxDelay:   maxDelayFlag,
+		Jitter:     jitterFlag,
+
+		RestartAlways: restartAlways,
+		RestartLimit:  restartLimitFlag,
+		RestartWindow: restartWindowFlag,
+
+		OkCodes:      okCodesFlag,
+		FailIfOutput: failIfOutputFlag,
+
+		// The environment is recorded so the job can be rerun the way it was launched
+		Env:    runner.MergeEnv(runner.SnapshotEnv(cfg.EnvPatterns()), envFlags...),
+		Limits: jobLimits(cfg),
+	}
 
-	switch {
-	case d < time.Minute:
-		return "just now"
-	case d < time.Hour:
-		mins := int(d.Minutes())
-		if mins == 1 {
+	// Rotation needs a size limit, the number of segments to keep only matters with one
+	opts.MaxLogSize = maxLogSizeFlag
+	if opts.MaxLogSize == 0 {
+		size, err := cfg.LogSizeLimit()
+		if err != nil {
+			exitWithError(locales.Msg("err.invalid_size", cfg.MaxLogSize))
+		}
+		opts.MaxLogSize = size
+	}
+	if opts.MaxLogSize > 0 {
+		opts.MaxLogFiles = maxLogFilesFlag
+		if opts.MaxLogFiles == 0 {
+			opts.MaxLogFiles = cfg.LogFilesLimit()
+		}
+	}
+	for _, ref := range afterRefs {
+		opts.After = append(opts.After, findJob(t, ref, "err.run_failed").ID)
+	}
+	for _, ref := range afterAnyRefs {
+		opts.AfterAny = append(opts.AfterAny, findJob(t, ref, "err.run_failed").ID)
+	}
+	return opts
+}
+
+// jobLimits returns the resource limits for a new job: the --nice, --ionice
+// and --max-* flags, falling back to the config defaults for any not given
+func jobLimits(cfg *config.Config) tracker.Limits {
+	limits := limitsFlag
+	if limits.Nice == 0 {
+		limits.Nice = cfg.Nice
+	}
+	if limits.IOClass == "" {
+		if cfg.IONice != "" && !validIOClass(cfg.IONice) {
+			exitWithError(locales.Msg("err.invalid_ionice", cfg.IONice))
+		}
+		limits.IOClass = cfg.IONice
+	}
+	if limits.MaxMemory == 0 {
+		size, err := cfg.MemoryLimit()
+		if err != nil {
+			exitWithError(locales.Msg("err.invalid_size", cfg.MaxMemory))
+		}
+		limits.MaxMemory = size
+	}
+	if limits.MaxCPUTime == 0 {
+		d, err := cfg.CPUTimeLimit()
+		if err != nil {
+			exitWithError(locales.Msg("err.invalid_duration", cfg.MaxCPUTime))
+		}
+		limits.MaxCPUTime = d
+	}
+	if limits.MaxOpenFiles == 0 {
+		limits.MaxOpenFiles = cfg.MaxOpenFiles
+	}
+	return limits
+}
+
+// validIOClass reports whether class is an I/O scheduling class --ionice takes
+func validIOClass(class string) bool {
+	switch class {
+	case tracker.IOClassIdle, tracker.IOClassBestEffort, tracker.IOClassRealtime:
+		return true
+	}
+	return false
+}
+
+// launchJSON builds the JSON response for a newly launched job
+func launchJSON(t *tracker.Tracker, jobID int, command string, opts runner.Options) map[string]interface{} {
+	result := map[string]interface{}{
+		"id":      jobID,
+		"command": command,
+		"status":  "started",
+	}
+	if opts.Name != "" {
+		result["name"] = opts.Name
+	}
+	if opts.Timeout > 0 {
+		result["timeout"] = opts.Timeout.String()
+	}
+	if len(opts.After) > 0 || len(opts.AfterAny) > 0 {
+		result["status"] = tracker.StatusWaiting
+	}
+	if opts.Queue != "" {
+		result["queue"] = opts.Queue
+		if pos := queuePosition(t, jobID); pos > 0 {
+			result["status"] = tracker.StatusQueued
+			result["position"] = pos
+		}
+	}
+	if len(opts.After) > 0 {
+		result["after"] = opts.After
+	}
+	if len(opts.AfterAny) > 0 {
+		result["after_any"] = opts.AfterAny
+	}
+	if opts.Backoff != "" {
+		result["backoff"] = opts.Backoff
+	}
+	if opts.MaxDelay > 0 {
+		result["max_delay"] = opts.MaxDelay.String()
+	}
+	if opts.Jitter {
+		result["jitter"] = true
+	}
+	if cwdFlag != "" {
+		result["cwd"] = cwdFlag
+	}
+	if !opts.Limits.IsZero() {
+		result["limits"] = limitsJSON(opts.Limits)
+	}
+	if len(opts.OkCodes) > 0 {
+		result["ok_codes"] = opts.OkCodes
+	}
+	if opts.FailIfOutput != "" {
+		result["fail_if_output"] = opts.FailIfOutput
+	}
+	return result
+}
+
+// announceLaunch tells the user when a newly launched job won't start right away
+func announceLaunch(t *tracker.Tracker, jobID int, opts runner.Options) {
+	if pos := queuePosition(t, jobID); pos > 0 {
+		fmt.Println(locales.Msg("job.queued", opts.Queue, pos))
+	}
+
+	deps := append(append([]int{}, opts.After...), opts.AfterAny...)
+	if len(deps) == 0 {
+		return
+	}
+	fmt.Println(locales.Msg("job.waiting", joinInts(deps)))
+}
+
+// queuePosition returns a job's place in its queue, or 0 if it isn't queued
+func queuePosition(t *tracker.Tracker, jobID int) int {
+	jobs, err := t.List()
+	if err != nil {
+		return 0
+	}
+	for _, job := range jobs {
+		if job.ID == jobID {
+			return tracker.QueuePosition(jobs, job)
+		}
+	}
+	return 0
+}
+
+// outputJSON marshals and prints JSON
+func outputJSON(v interface{}) {
+	data, _ := json.MarshalIndent(v, "", "  ")
+	fmt.Println(string(data))
+}
+
+// redactedValue stands in for the values of a job's environment variables
+const redactedValue = "***"
+
+// redactEnv returns job with the values of its environment hidden, unless
+// --reveal-env asked for them. They often hold tokens and passwords.
+func redactEnv(job tracker.Job) tracker.Job {
+	if revealEnv || job.Env == nil {
+		return job
+	}
+	env := make([]string, len(job.Env))
+	for i, kv := range job.Env {
+		key, _, _ := strings.Cut(kv, "=")
+		env[i] = key + "=" + redactedValue
+	}
+	job.Env = env
+	return job
+}
+
+// redactJobs is redactEnv for each of jobs
+func redactJobs(jobs []tracker.Job) []tracker.Job {
+	redacted := make([]tracker.Job, len(jobs))
+	for i, job := range jobs {
+		redacted[i] = redactEnv(job)
+	}
+	return redacted
+}
+
+func printUsage(command string) {
+	switch command {
+	case "--list":
+		fmt.Println(locales.Msg("help.list"))
+	case "--logs":
+		fmt.Println(locales.Msg("help.logs"))
+	case "--prune":
+		fmt.Println(locales.Msg("help.prune"))
+	case "--kill":
+		fmt.Println(locales.Msg("help.kill"))
+	case "--gc":
+		fmt.Println(locales.Msg("help.gc"))
+	case "--show":
+		fmt.Println(locales.Msg("help.show"))
+	case "--tui":
+		fmt.Println(locales.Msg("help.tui"))
+	case "--pause", "--resume":
+		fmt.Println(locales.Msg("help.pause"))
+	case "--wait":
+		fmt.Println(locales.Msg("help.wait"))
+	case "--grep":
+		fmt.Println(locales.Msg("help.grep"))
+	case "--restart":
+		fmt.Println(locales.Msg("help.restart"))
+	case "--retry":
+		fmt.Println(locales.Msg("help.retry"))
+	case "--completion":
+		fmt.Println(locales.Msg("help.completion"))
+	case "--init":
+		fmt.Println(locales.Msg("help.init"))
+	default:
+		fmt.Println(locales.Msg("help.main"))
+	}
+}
+
+func runCommand(cfg *config.Config, t *tracker.Tracker, command string) {
+	pwd := launchDir("err.run_failed")
+
+	r := runner.New(cfg, t)
+	opts := launchOptions(cfg, t)
+	jobID, err := r.Run(command, pwd, opts)
+	if err != nil {
+		exitWithRunError("err.run_failed", opts, err)
+	}
+	if jsonOutput {
+		outputJSON(launchJSON(t, jobID, command, opts))
+	} else {
+		fmt.Println(locales.Msg("job.started", jobID, command))
+		announceLaunch(t, jobID, opts)
+	}
+}
+
+type jobRow struct {
+	id       int
+	name     string
+	queue    string
+	status   string
+	attempts string
+	start    string
+	duration string
+	mem      string // --wide: peak RSS
+	cpu      string // --wide: user and system CPU time
+	cmd      string
+	isError  bool
+	isDone   bool
+}
+
+// relativeTime returns a human-friendly relative time string
+func relativeTime(t time.Time) string {
+	d := time.Since(t)
+
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		mins := int(d.Minutes())
+		if mins == 1 {
 			return "1 min ago"
 		}
 		return fmt.Sprintf("%d mins ago", mins)
@@ -376,224 +1263,1243 @@ func relativeTime(t time.Time) string {
 }
 
 func listJobs(t *tracker.Tracker) {
+	if listWatch > 0 {
+		watchJobs(t)
+		return
+	}
+
 	jobs, err := t.List()
 	if err != nil {
 		exitWithError(locales.Msg("err.list_failed", err))
 	}
 
 	// Apply filters if any are set
-	hasFilter := listRunning || listFailed || listDone
-	if hasFilter {
-		var filtered []tracker.Job
-		for _, job := range jobs {
-			if listRunning && job.ExitCode == nil {
-				filtered = append(filtered, job)
-			} else if listFailed && job.ExitCode != nil && *job.ExitCode != 0 {
-				filtered = append(filtered, job)
-			} else if listDone && job.ExitCode != nil && *job.ExitCode == 0 {
-				filtered = append(filtered, job)
-			}
-		}
-		jobs = filtered
-	}
+	jobs = sortJobs(filterJobs(jobs))
 
 	if len(jobs) == 0 {
 		if jsonOutput {
 			outputJSON([]interface{}{})
+		} else if tableFormat() {
+			fmt.Println(emptyListMessage())
 		} else {
-			if hasFilter {
-				fmt.Println(locales.Msg("list.empty_filtered"))
-			} else {
-				fmt.Println(locales.Msg("list.empty"))
-			}
+			// The csv and tsv headers are still worth printing
+			printFormatted(jobs)
 		}
 		return
 	}
 
 	// JSON output - return raw job data
 	if jsonOutput {
-		outputJSON(jobs)
+		outputJSON(redactJobs(jobs))
 		return
 	}
 
-	// Build rows first
-	var rows []jobRow
-	for _, job := range jobs {
-		row := jobRow{id: job.ID}
-		row.status = "running"
-		row.duration = time.Since(job.StartTime).Round(time.Second).String()
+	printFormatted(jobs)
+}
 
-		if job.ExitCode != nil {
-			if *job.ExitCode == 0 {
-				row.status = "done"
-				row.isDone = true
-			} else {
-				row.status = fmt.Sprintf("exit(%d)", *job.ExitCode)
-				row.isError = true
+// emptyListMessage is what --list says when there are no jobs to show
+func emptyListMessage() string {
+	if filtering() {
+		return locales.Msg("list.empty_filtered")
+	}
+	return locales.Msg("list.empty")
+}
+
+// watchJobs redraws the --list table every --watch interval until interrupted,
+// in place when stdout is a terminal and as a fresh copy each time otherwise.
+// Jobs whose status changed since the previous redraw are highlighted.
+func watchJobs(t *tracker.Tracker) {
+	tty := term.IsTerminal(int(os.Stdout.Fd()))
+	interrupted := make(chan os.Signal, 1)
+	signal.Notify(interrupted, os.Interrupt, syscall.SIGTERM)
+	if tty {
+		fmt.Print("\033[?25l") // hide the cursor while redrawing
+	}
+
+	ticker := time.NewTicker(listWatch)
+	defer ticker.Stop()
+
+	statuses := make(map[int]string)   // each job's status as of the last redraw
+	changed := make(map[int]time.Time) // when each job's status last changed
+	lines := 0                         // lines the last redraw took up
+	for first := true; ; first = false {
+		// Jobs whose process died without reporting back would show as running forever
+		t.GarbageCollect()
+		jobs, err := t.List()
+		if err != nil {
+			if tty {
+				fmt.Print("\033[?25h")
+			}
+			exitWithError(locales.Msg("err.list_failed", err))
+		}
+		jobs = sortJobs(filterJobs(jobs))
+
+		now := time.Now()
+		highlight := make(map[int]bool)
+		for _, job := range jobs {
+			status := jobStatus(jobs, job)
+			if !first && statuses[job.ID] != status {
+				changed[job.ID] = now
+			}
+			statuses[job.ID] = status
+			if at, ok := changed[job.ID]; ok && (at == now || now.Sub(at) < watchHighlight) {
+				highlight[job.ID] = true
+			}
+		}
+
+		var frame bytes.Buffer
+		fmt.Fprintln(&frame, locales.Msg("list.watching", listWatch, now.Format("15:04:05")))
+		if len(jobs) == 0 && tableFormat() {
+			fmt.Fprintln(&frame, emptyListMessage())
+		} else if err := printJobs(&frame, jobs, highlight); err != nil {
+			if tty {
+				fmt.Print("\033[?25h")
+			}
+			exitWithError(locales.Msg("err.format_failed", err))
+		}
+
+		if tty {
+			// Go back to the top of the last redraw and clear it
+			if lines > 0 {
+				fmt.Printf("\033[%dF\033[J", lines)
 			}
-			if job.EndTime != nil {
-				row.duration = job.EndTime.Sub(job.StartTime).Round(time.Second).String()
+			lines = bytes.Count(frame.Bytes(), []byte("\n"))
+		} else if !first {
+			fmt.Println()
+		}
+		os.Stdout.Write(frame.Bytes())
+
+		select {
+		case <-interrupted:
+			if tty {
+				fmt.Print("\033[?25h")
 			}
+			return
+		case <-ticker.C:
 		}
+	}
+}
+
+// printJobTable writes the --list table for jobs to w, with the jobs in
+// highlight (if any) picked out in bold
+func printJobTable(w io.Writer, jobs []tracker.Job, highlight map[int]bool) {
+	// Build rows first
+	var rows []jobRow
+	for _, job := range jobs {
+		row := jobRow{id: job.ID, name: job.Name, queue: job.Queue}
+		row.status = jobStatus(jobs, job)
+		row.attempts = jobAttempts(job)
+		row.isDone = job.Succeeded()
+		row.isError = job.Failed()
+		// Time spent paused doesn't count
+		row.duration = job.Duration().Round(time.Second).String()
 
 		row.start = relativeTime(job.StartTime)
 
-		// Truncate long commands
-		row.cmd = job.Command
-		if len(row.cmd) > 40 {
-			row.cmd = row.cmd[:37] + "..."
+		if listWide {
+			row.mem, row.cpu = "-", "-"
+			if u := job.Usage; u != nil {
+				row.mem = formatSize(u.PeakRSS)
+				row.cpu = formatCPUTime(u.UserTime + u.SysTime)
+			}
 		}
 
+		row.cmd = truncateCommand(job.Command)
+
 		rows = append(rows, row)
 	}
 
 	// Calculate column widths
-	idW, statusW, startW, durW := 2, 6, 5, 8 // header widths
+	idW, nameW, queueW, statusW, attemptsW, startW, durW := 2, 0, 0, 6, 0, 5, 8 // header widths
+	memW, cpuW := 0, 0
 	for _, r := range rows {
 		if w := len(fmt.Sprintf("%d", r.id)); w > idW {
 			idW = w
 		}
+		if w := len(r.name); w > nameW {
+			nameW = w
+		}
+		if w := len(r.queue); w > queueW {
+			queueW = w
+		}
 		if w := len(r.status); w > statusW {
 			statusW = w
 		}
+		if w := len(r.attempts); w > attemptsW {
+			attemptsW = w
+		}
 		if w := len(r.start); w > startW {
 			startW = w
 		}
 		if w := len(r.duration); w > durW {
 			durW = w
 		}
+		if w := len(r.mem); w > memW {
+			memW = w
+		}
+		if w := len(r.cpu); w > cpuW {
+			cpuW = w
+		}
+	}
+
+	// The NAME, QUEUE and ATTEMPTS columns only appear once some job uses them
+	nameCol := optionalColumn(nameW, "NAME")
+	queueCol := optionalColumn(queueW, "QUEUE")
+	attemptsCol := optionalColumn(attemptsW, "ATTEMPTS")
+
+	// MEM and CPU only appear with --wide
+	memCol := optionalColumn(memW, "MEM")
+	cpuCol := optionalColumn(cpuW, "CPU")
+
+	// Print header
+	fmt.Fprintf(w, "%-*s  %s%s%-*s  %s%-*s  %-*s  %s%s%s\n", idW, "ID", nameCol("NAME"), queueCol("QUEUE"), statusW, "STATUS", attemptsCol("ATTEMPTS"), startW, "START", durW, "DURATION", memCol("MEM"), cpuCol("CPU"), "COMMAND")
+
+	// Print rows with colors
+	for _, r := range rows {
+		line := fmt.Sprintf("%-*d  %s%s%-*s  %s%-*s  %-*s  %s%s%s", idW, r.id, nameCol(r.name), queueCol(r.queue), statusW, r.status, attemptsCol(r.attempts), startW, r.start, durW, r.duration, memCol(r.mem), cpuCol(r.cpu), r.cmd)
+		if highlight[r.id] {
+			// Bold row with yellow status
+			statusStart := idW + 2 + len(nameCol("")) + len(queueCol(""))
+			statusEnd := statusStart + statusW
+			fmt.Fprintf(w, "%s%s%s%s%s%s\n",
+				colorBold, line[:statusStart],
+				colorYellow, line[statusStart:statusEnd],
+				colorReset+colorBold, line[statusEnd:]+colorReset)
+		} else if r.isError {
+			// Dim row with red status
+			statusStart := idW + 2 + len(nameCol("")) + len(queueCol(""))
+			statusEnd := statusStart + statusW
+			fmt.Fprintf(w, "%s%s%s%s%s%s%s\n",
+				colorDim, line[:statusStart],
+				colorRed, line[statusStart:statusEnd],
+				colorReset, colorDim, line[statusEnd:]+colorReset)
+		} else if r.isDone {
+			fmt.Fprintf(w, "%s%s%s\n", colorDim, line, colorReset)
+		} else {
+			fmt.Fprintln(w, line)
+		}
+	}
+}
+
+// showJob prints everything bj knows about a job (the latest if ref is
+// empty): the full command and where it ran, its process, times and exit
+// code, how it was launched, its logs, limits and resource usage, and the
+// last --lines lines of its output
+func showJob(t *tracker.Tracker, ref string) {
+	var job *tracker.Job
+	if ref == "" {
+		var err error
+		job, err = t.Latest()
+		if err != nil {
+			exitWithError(locales.Msg("err.show_failed", err))
+		}
+		if job == nil {
+			if jsonOutput {
+				exitWithError(locales.Msg("err.no_jobs"))
+			}
+			fmt.Println(locales.Msg("logs.no_jobs"))
+			os.Exit(0)
+		}
+	} else {
+		job = findJob(t, ref, "err.show_failed")
+	}
+
+	jobs, _ := t.List()
+	status := jobStatus(jobs, *job)
+	alive := job.Alive()
+	if formatFlag != "" {
+		if err := printJobsAmong(os.Stdout, []tracker.Job{*job}, jobs, nil); err != nil {
+			exitWithError(locales.Msg("err.format_failed", err))
+		}
+		return
+	}
+	tail := logTail(job, showLines)
+
+	if jsonOutput {
+		// The raw job, plus what --show works out from it
+		var result map[string]interface{}
+		data, _ := json.Marshal(redactEnv(*job))
+		json.Unmarshal(data, &result)
+		result["status"] = status
+		result["alive"] = alive
+		result["duration"] = job.Duration().String()
+		result["log_size"] = logSize(job.LogFile)
+		if meaning := exitMeaning(*job); meaning != "" {
+			result["exit_meaning"] = meaning
+		}
+		result["tail"] = tail
+		outputJSON(result)
+		return
+	}
+
+	var fields [][2]string
+	add := func(label, value string) {
+		fields = append(fields, [2]string{label, value})
+	}
+
+	add(locales.Msg("show.id"), strconv.Itoa(job.ID))
+	if job.Name != "" {
+		add(locales.Msg("show.name"), job.Name)
+	}
+	add(locales.Msg("show.command"), job.Command)
+	add(locales.Msg("show.directory"), job.PWD)
+	add(locales.Msg("show.status"), status)
+	if job.ExitCode != nil {
+		add(locales.Msg("show.exit_code"), fmt.Sprintf("%d (%s)", *job.ExitCode, exitMeaning(*job)))
+	}
+	if job.PID > 0 {
+		state := locales.Msg("show.gone")
+		if alive {
+			state = locales.Msg("show.alive")
+		}
+		pid := fmt.Sprintf("%d (%s)", job.PID, state)
+		if job.PGID != 0 && job.PGID != job.PID {
+			pid = locales.Msg("show.process_group", pid, job.PGID)
+		}
+		add(locales.Msg("show.pid"), pid)
+	}
+	add(locales.Msg("show.started"), formatTimestamp(job.StartTime))
+	if job.EndTime != nil {
+		add(locales.Msg("show.ended"), formatTimestamp(*job.EndTime))
+	}
+	add(locales.Msg("show.duration"), formatCPUTime(job.Duration()))
+	if job.PausedAt != nil {
+		add(locales.Msg("show.paused"), formatTimestamp(*job.PausedAt))
+	}
+	if job.PausedFor > 0 {
+		add(locales.Msg("show.paused_for"), formatCPUTime(job.PausedFor))
+	}
+	if mode := describeMode(*job); mode != "" {
+		add(locales.Msg("show.mode"), mode)
+	}
+	if attempts := jobAttempts(*job); attempts != "" {
+		add(locales.Msg("show.attempts"), attempts)
+	}
+	if job.Timeout > 0 {
+		add(locales.Msg("show.timeout"), job.Timeout.String())
+	}
+	if len(job.After) > 0 {
+		add(locales.Msg("show.after"), joinInts(job.After))
+	}
+	if len(job.AfterAny) > 0 {
+		add(locales.Msg("show.after_any"), joinInts(job.AfterAny))
+	}
+	if job.Queue != "" {
+		add(locales.Msg("show.queue"), job.Queue)
+	}
+	if len(job.OkCodes) > 0 {
+		add(locales.Msg("show.ok_codes"), joinInts(job.OkCodes))
+	}
+	if job.FailIfOutput != "" {
+		add(locales.Msg("show.fail_if"), job.FailIfOutput)
+	}
+	if job.Env != nil {
+		add(locales.Msg("show.env"), locales.Msg("show.env_count", len(job.Env)))
+	}
+	add(locales.Msg("show.log"), describeLog(job.LogFile))
+	if job.StdoutFile != "" {
+		add(locales.Msg("show.stdout"), describeLog(job.StdoutFile))
+	}
+	if job.StderrFile != "" {
+		add(locales.Msg("show.stderr"), describeLog(job.StderrFile))
+	}
+	if job.MaxLogSize > 0 {
+		add(locales.Msg("show.rotation"), locales.Msg("show.rotation_value", formatSize(job.MaxLogSize), job.MaxLogFiles))
+	}
+	if job.Timestamps {
+		add(locales.Msg("show.timestamps"), locales.Msg("show.yes"))
+	}
+
+	limits := describeLimits(job.Limits)
+	if limits == "" {
+		limits = locales.Msg("show.no_limits")
+	}
+	add(locales.Msg("show.limits"), limits)
+	peak, cpu, wall := "-", "-", "-"
+	if u := job.Usage; u != nil {
+		peak = formatSize(u.PeakRSS)
+		cpu = locales.Msg("show.cpu_value", formatCPUTime(u.UserTime), formatCPUTime(u.SysTime))
+		wall = formatCPUTime(u.WallTime)
+	}
+	add(locales.Msg("show.peak_rss"), peak)
+	add(locales.Msg("show.cpu_time"), cpu)
+	add(locales.Msg("show.wall_time"), wall)
+
+	for _, field := range fields {
+		fmt.Printf("%-11s %s\n", field[0]+":", field[1])
+	}
+
+	if len(tail) > 0 {
+		fmt.Println()
+		fmt.Println(locales.Msg("show.tail", len(tail)))
+		for _, line := range tail {
+			fmt.Println("  " + line)
+		}
+	}
+}
+
+// exitMeaning explains a finished job's exit code, like "success" or
+// "command not found" ("" while the job is unfinished)
+func exitMeaning(job tracker.Job) string {
+	if job.ExitCode == nil {
+		return ""
+	}
+	code := *job.ExitCode
+	switch {
+	case job.Status == tracker.StatusTimeout:
+		return locales.Msg("exit.timeout", job.Timeout)
+	case job.Status == tracker.StatusSkipped:
+		return locales.Msg("exit.skipped")
+	case job.Status == tracker.StatusCancelled:
+		return locales.Msg("exit.cancelled")
+	case job.Status == tracker.StatusCrashloop:
+		return locales.Msg("exit.crashloop")
+	case job.Status == tracker.StatusBadOutput:
+		return locales.Msg("exit.bad_output")
+	case job.Signal != "":
+		return locales.Msg("exit.killed_with", job.Signal)
+	case code == 0:
+		return locales.Msg("exit.success")
+	case job.OkExit(code):
+		return locales.Msg("exit.ok_code")
+	case code == -1:
+		return locales.Msg("exit.lost")
+	case code == 126:
+		return locales.Msg("exit.not_executable")
+	case code == 127:
+		return locales.Msg("exit.not_found")
+	case code > 128 && code < 160:
+		return locales.Msg("exit.killed_by", tracker.SignalName(syscall.Signal(code-128)))
+	}
+	return locales.Msg("exit.failure")
+}
+
+// describeMode describes how a retry or restart job reruns ("" for jobs that
+// run once), like "retry, 5s delay, exponential backoff"
+func describeMode(job tracker.Job) string {
+	var parts []string
+	switch job.Mode {
+	case tracker.ModeRetry:
+		parts = append(parts, "retry")
+	case tracker.ModeRestart:
+		if job.RestartAlways {
+			parts = append(parts, "restart always")
+		} else {
+			parts = append(parts, "restart on failure")
+		}
+	default:
+		return ""
+	}
+	if job.RetryDelay > 0 {
+		parts = append(parts, fmt.Sprintf("%ds delay", job.RetryDelay))
+	}
+	if job.Backoff != "" {
+		parts = append(parts, job.Backoff+" backoff")
+	}
+	if job.MaxDelay > 0 {
+		parts = append(parts, "at most "+job.MaxDelay.String())
+	}
+	if job.Jitter {
+		parts = append(parts, "jitter")
+	}
+	if job.RestartLimit > 0 {
+		parts = append(parts, fmt.Sprintf("up to %d restarts per %s", job.RestartLimit, job.RestartWindow))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// describeLog gives a log's path and the total size of its segments
+func describeLog(path string) string {
+	return fmt.Sprintf("%s (%s)", path, formatSize(logSize(path)))
+}
+
+// logSize adds up the size of every segment of a log, compressed or not
+func logSize(path string) int64 {
+	var size int64
+	for _, segment := range tracker.LogSegments(path) {
+		if info, err := os.Stat(segment); err == nil {
+			size += info.Size()
+		}
+	}
+	return size
+}
+
+// logTail returns the last n lines of a job's combined log
+func logTail(job *tracker.Job, n int) []string {
+	if n == 0 {
+		return nil
+	}
+	tail := &lastLines{n: n}
+	copyLog(tail, job, tracker.LogSegments(job.LogFile))
+	return tail.Lines()
+}
+
+// lastLines is a writer that keeps only the last n lines written to it
+type lastLines struct {
+	n       int
+	lines   []string
+	partial []byte
+}
+
+func (l *lastLines) Write(p []byte) (int, error) {
+	data := append(l.partial, p...)
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		l.lines = append(l.lines, string(data[:i]))
+		if len(l.lines) > l.n {
+			l.lines = l.lines[1:]
+		}
+		data = data[i+1:]
+	}
+	l.partial = append([]byte(nil), data...)
+	return len(p), nil
+}
+
+// Lines returns the lines kept, including an unterminated last one
+func (l *lastLines) Lines() []string {
+	lines := l.lines
+	if len(l.partial) > 0 {
+		lines = append(lines, string(l.partial))
+		if len(lines) > l.n {
+			lines = lines[1:]
+		}
+	}
+	return lines
+}
+
+// formatTimestamp formats a time in full, followed by how long ago it was
+func formatTimestamp(t time.Time) string {
+	return fmt.Sprintf("%s (%s)", t.Format("2006-01-02 15:04:05 MST"), relativeTime(t))
+}
+
+// joinInts lists numbers separated by commas, like "1, 2, 3"
+func joinInts(nums []int) string {
+	strs := make([]string, len(nums))
+	for i, n := range nums {
+		strs[i] = strconv.Itoa(n)
+	}
+	return strings.Join(strs, ", ")
+}
+
+// describeLimits lists the limits that are set, like "nice 10, memory 2.0G"
+func describeLimits(l tracker.Limits) string {
+	var parts []string
+	if l.Nice != 0 {
+		parts = append(parts, fmt.Sprintf("nice %d", l.Nice))
+	}
+	if l.IOClass != "" {
+		parts = append(parts, "ionice "+l.IOClass)
+	}
+	if l.MaxMemory > 0 {
+		parts = append(parts, "memory "+formatSize(l.MaxMemory))
+	}
+	if l.MaxCPUTime > 0 {
+		parts = append(parts, "CPU time "+l.MaxCPUTime.String())
+	}
+	if l.MaxOpenFiles > 0 {
+		parts = append(parts, fmt.Sprintf("%d open files", l.MaxOpenFiles))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// limitsJSON describes the limits that are set for a --json response
+func limitsJSON(l tracker.Limits) map[string]interface{} {
+	result := map[string]interface{}{}
+	if l.Nice != 0 {
+		result["nice"] = l.Nice
+	}
+	if l.IOClass != "" {
+		result["ionice"] = l.IOClass
+	}
+	if l.MaxMemory > 0 {
+		result["max_memory"] = l.MaxMemory
+	}
+	if l.MaxCPUTime > 0 {
+		result["max_cpu_time"] = l.MaxCPUTime.String()
+	}
+	if l.MaxOpenFiles > 0 {
+		result["max_open_files"] = l.MaxOpenFiles
+	}
+	return result
+}
+
+// formatSize formats a size in bytes with a K, M or G suffix (powers of 1024)
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1fG", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1fM", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1fK", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%dB", n)
+}
+
+// formatCPUTime formats a CPU or run time to the millisecond
+func formatCPUTime(d time.Duration) string {
+	return d.Round(time.Millisecond).String()
+}
+
+// jobStatus describes a job's state the way --list shows it: running, done,
+// done(N) for another --ok-codes code, exit(N), or a special status like
+// timeout, waiting or "queued #N"
+// jobs is used to work out queue positions
+func jobStatus(jobs []tracker.Job, job tracker.Job) string {
+	if job.ExitCode == nil {
+		switch {
+		case job.Paused():
+			return "paused"
+		case tracker.QueuePosition(jobs, job) > 0:
+			return fmt.Sprintf("queued #%d", tracker.QueuePosition(jobs, job))
+		case job.Status != "":
+			return job.Status
+		}
+		return "running"
+	}
+
+	switch {
+	case job.Status != "":
+		return job.Status
+	case job.Succeeded() && *job.ExitCode == 0:
+		return "done"
+	case job.Succeeded():
+		return fmt.Sprintf("done(%d)", *job.ExitCode)
+	case job.Signal != "":
+		return fmt.Sprintf("killed(%s)", job.Signal)
+	}
+	return fmt.Sprintf("exit(%d)", *job.ExitCode)
+}
+
+// jobAttempts describes how many times a retry or restart job has run, out of
+// its limit if it has one ("" for jobs that run once)
+func jobAttempts(job tracker.Job) string {
+	switch {
+	case job.Mode == "":
+		return ""
+	case job.Mode == tracker.ModeRetry && job.MaxAttempts > 0:
+		return fmt.Sprintf("%d/%d", len(job.Attempts), job.MaxAttempts)
+	}
+	return strconv.Itoa(len(job.Attempts))
+}
+
+// optionalColumn returns a formatter for a column that's hidden when no row
+// has a value (width 0), and otherwise at least as wide as its header
+func optionalColumn(width int, header string) func(string) string {
+	if width == 0 {
+		return func(s string) string { return "" }
+	}
+	if width < len(header) {
+		width = len(header)
+	}
+	return func(s string) string { return fmt.Sprintf("%-*s  ", width, s) }
+}
+
+// printJobIDs outputs job IDs for shell completion (no jq needed)
+// Respects --running, --failed, --done filters
+// If ref is given, only jobs with that ID or name are printed
+func printJobIDs(t *tracker.Tracker, ref string) {
+	jobs, err := t.List()
+	if err != nil {
+		os.Exit(1) // Silent fail for completions
+	}
+
+	var matched []tracker.Job
+	for _, job := range filterJobs(jobs) {
+		if ref != "" && ref != job.Name && ref != strconv.Itoa(job.ID) {
+			continue
+		}
+		matched = append(matched, job)
+	}
+	matched = sortJobs(matched)
+
+	if formatFlag == "" {
+		for _, job := range matched {
+			fmt.Println(job.ID)
+		}
+		return
+	}
+	if err := printJobsAmong(os.Stdout, matched, jobs, nil); err != nil {
+		exitWithError(locales.Msg("err.format_failed", err))
+	}
+}
+
+// printJobNames outputs the distinct names of jobs for shell completion
+// Respects --running, --failed, --done filters
+func printJobNames(t *tracker.Tracker) {
+	jobs, err := t.List()
+	if err != nil {
+		os.Exit(1) // Silent fail for completions
+	}
+
+	seen := make(map[string]bool)
+	for _, job := range filterJobs(jobs) {
+		if job.Name == "" || seen[job.Name] {
+			continue
+		}
+		seen[job.Name] = true
+		fmt.Println(job.Name)
+	}
+}
+
+// truncateCommand shortens long commands to fit in a column
+func truncateCommand(cmd string) string {
+	if len(cmd) > 40 {
+		return cmd[:37] + "..."
+	}
+	return cmd
+}
+
+// filtering reports whether any of the job filters are set
+func filtering() bool {
+	return listRunning || listFailed || listDone || hereDir != "" ||
+		!listSince.IsZero() || listCmd != "" || listExitCode != nil
+}
+
+// filterJobs returns the jobs matching any of the --running, --failed and
+// --done filters (all jobs when none are set) and all of the others
+func filterJobs(jobs []tracker.Job) []tracker.Job {
+	if !filtering() {
+		return jobs
+	}
+	var filtered []tracker.Job
+	for _, job := range jobs {
+		if !matchesJobFilters(job) {
+			continue
+		}
+		switch {
+		case !listRunning && !listFailed && !listDone,
+			listRunning && job.ExitCode == nil,
+			listFailed && job.Failed(),
+			listDone && job.Succeeded():
+			filtered = append(filtered, job)
+		}
+	}
+	return filtered
+}
+
+// startedHere reports whether a job was started in or below the --here
+// directory (always true without --here)
+func startedHere(job tracker.Job) bool {
+	if hereDir == "" {
+		return true
+	}
+	rel, err := filepath.Rel(hereDir, job.PWD)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// matchesJobFilters reports whether a job passes the filters that aren't
+// about its status: --here or --dir, --since, --cmd and --exit-code
+func matchesJobFilters(job tracker.Job) bool {
+	if !startedHere(job) {
+		return false
+	}
+	if !listSince.IsZero() && job.StartTime.Before(listSince) {
+		return false
+	}
+	if listCmd != "" && !strings.Contains(job.Command, listCmd) {
+		return false
+	}
+	if listExitCode != nil && (job.ExitCode == nil || *job.ExitCode != *listExitCode) {
+		return false
+	}
+	return true
+}
+
+// sortJobs puts jobs (newest first, as the tracker lists them) in --sort
+// order, flipped by --reverse, and keeps the first --limit of them. Jobs that
+// sort the same stay newest first.
+func sortJobs(jobs []tracker.Job) []tracker.Job {
+	var less func(a, b tracker.Job) bool
+	switch listSort {
+	case "duration":
+		// Longest first
+		less = func(a, b tracker.Job) bool { return a.Duration() > b.Duration() }
+	case "id":
+		// Lowest first, the order they were started in
+		less = func(a, b tracker.Job) bool { return a.ID < b.ID }
+	case "status":
+		// Unfinished first, then ruined, then successful
+		less = func(a, b tracker.Job) bool { return statusRank(a) < statusRank(b) }
+	}
+	if less != nil {
+		sort.SliceStable(jobs, func(i, j int) bool { return less(jobs[i], jobs[j]) })
+	}
+	if listReverse {
+		slices.Reverse(jobs)
+	}
+	if listLimit > 0 && len(jobs) > listLimit {
+		jobs = jobs[:listLimit]
+	}
+	return jobs
+}
+
+// statusRank orders jobs for --sort status
+func statusRank(job tracker.Job) int {
+	switch {
+	case job.ExitCode == nil:
+		return 0
+	case job.Failed():
+		return 1
+	}
+	return 2
+}
+
+func pruneJobs(t *tracker.Tracker) {
+	var count int
+	var err error
+	if filtering() || listSort != "" || listReverse || listLimit > 0 {
+		count, err = pruneSelected(t)
+	} else {
+		count, err = t.Prune()
+	}
+	if err != nil {
+		exitWithError(locales.Msg("err.prune_failed", err))
+	}
+	if jsonOutput {
+		outputJSON(map[string]interface{}{"pruned": count})
+	} else if count == 0 {
+		fmt.Println(locales.Msg("prune.nothing"))
+	} else {
+		fmt.Println(locales.Msg("prune.success", count))
+	}
+}
+
+// pruneSelected prunes just the finished jobs picked out by the filters,
+// --sort, --reverse and --limit
+func pruneSelected(t *tracker.Tracker) (int, error) {
+	jobs, err := t.List()
+	if err != nil {
+		return 0, err
+	}
+	var finished []tracker.Job
+	for _, job := range jobs {
+		if job.ExitCode != nil {
+			finished = append(finished, job)
+		}
+	}
+	var ids []int
+	for _, job := range sortJobs(filterJobs(finished)) {
+		ids = append(ids, job.ID)
+	}
+	return t.PruneIDs(ids)
+}
+
+func garbageCollect(cfg *config.Config, t *tracker.Tracker) {
+	count, err := t.GarbageCollect()
+	if err != nil {
+		exitWithError(locales.Msg("err.gc_failed", err))
+	}
+	if count > 0 {
+		// Collected jobs never reported back, so their queue slots are free now
+		if err := runner.New(cfg, t).PromoteAll(); err != nil {
+			exitWithError(locales.Msg("err.gc_failed", err))
+		}
+	}
+	if jsonOutput {
+		outputJSON(map[string]interface{}{"collected": count})
+	} else if count == 0 {
+		fmt.Println(locales.Msg("gc.nothing"))
+	} else {
+		fmt.Println(locales.Msg("gc.success", count))
+	}
+}
+
+func killJob(cfg *config.Config, t *tracker.Tracker, ref string) {
+	var job *tracker.Job
+	var err error
+	var jobID int
+
+	if ref == "" {
+		// Find the most recent running job
+		job, err = t.LatestRunning()
+		if err != nil {
+			exitWithError(locales.Msg("err.kill_check_failed", err))
+		}
+		if job == nil {
+			if jsonOutput {
+				exitWithError("no running jobs to kill")
+			}
+			fmt.Println(locales.Msg("kill.no_running"))
+			os.Exit(0)
+		}
+		jobID = job.ID
+	} else {
+		jobID = findJob(t, ref, "err.kill_check_failed").ID
+	}
+
+	job, err = t.Kill(jobID, killSignal, killGrace)
+	if err != nil {
+		exitWithError(locales.Msg("err.kill_failed", err))
+	}
+
+	// A killed job never reaches bj --complete, so hand its slot to the next in line
+	if job.Queue != "" {
+		if err := runner.New(cfg, t).Promote(job.Queue); err != nil {
+			exitWithError(locales.Msg("err.kill_failed", err))
+		}
+	}
+
+	if jsonOutput {
+		outputJSON(killJSON(job))
+	} else {
+		printKilled(job)
+	}
+}
+
+// killAllJobs kills every unfinished job, or only those in the --queue given
+// and, with --failed-deps, those depending on a job that failed
+func killAllJobs(cfg *config.Config, t *tracker.Tracker) {
+	jobs, err := t.List()
+	if err != nil {
+		exitWithError(locales.Msg("err.kill_check_failed", err))
+	}
+	byID := make(map[int]tracker.Job, len(jobs))
+	for _, job := range jobs {
+		byID[job.ID] = job
+	}
+
+	// Queued jobs go first so none of them get promoted into a slot we just freed
+	var queued, started []tracker.Job
+	for _, job := range jobs {
+		if job.ExitCode != nil || (queueFlag != "" && job.Queue != queueFlag) {
+			continue
+		}
+		if killFailedDeps && !dependencyFailed(byID, job) {
+			continue
+		}
+		if job.Status == tracker.StatusQueued {
+			queued = append(queued, job)
+		} else {
+			started = append(started, job)
+		}
+	}
+
+	if len(queued)+len(started) == 0 {
+		if jsonOutput {
+			outputJSON([]interface{}{})
+			return
+		}
+		fmt.Println(locales.Msg("kill.no_running"))
+		return
+	}
+
+	// Jobs that finish on their own while we're at it don't count as failures
+	var killed []*tracker.Job
+	var failed []error
+	for _, job := range queued {
+		k, err := t.Kill(job.ID, killSignal, killGrace)
+		if err != nil {
+			if !errors.Is(err, tracker.ErrJobFinished) {
+				failed = append(failed, err)
+			}
+			continue
+		}
+		killed = append(killed, k)
+	}
+
+	// Kill running jobs in parallel so stubborn ones don't each cost a full grace period
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, job := range started {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			k, err := t.Kill(id, killSignal, killGrace)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if !errors.Is(err, tracker.ErrJobFinished) {
+					failed = append(failed, err)
+				}
+				return
+			}
+			killed = append(killed, k)
+		}(job.ID)
+	}
+	wg.Wait()
+
+	if err := runner.New(cfg, t).PromoteAll(); err != nil {
+		failed = append(failed, err)
+	}
+
+	sort.Slice(killed, func(i, j int) bool { return killed[i].ID < killed[j].ID })
+	if jsonOutput {
+		results := []map[string]interface{}{}
+		for _, job := range killed {
+			results = append(results, killJSON(job))
+		}
+		outputJSON(results)
+	} else {
+		for _, job := range killed {
+			printKilled(job)
+		}
+	}
+
+	for _, err := range failed {
+		fmt.Fprintln(os.Stderr, locales.Msg("err.kill_failed", err))
+	}
+	if len(failed) > 0 {
+		os.Exit(1)
+	}
+}
+
+// dependencyFailed reports whether one of the jobs job runs --after or
+// --after-any has finished without succeeding
+func dependencyFailed(byID map[int]tracker.Job, job tracker.Job) bool {
+	for _, id := range append(append([]int{}, job.After...), job.AfterAny...) {
+		if dep, ok := byID[id]; ok && dep.ExitCode != nil && !dep.Succeeded() {
+			return true
+		}
+	}
+	return false
+}
+
+// killJSON builds the JSON response for a killed job
+func killJSON(job *tracker.Job) map[string]interface{} {
+	if job.Status == tracker.StatusCancelled {
+		return map[string]interface{}{
+			"id":      job.ID,
+			"command": job.Command,
+			"status":  tracker.StatusCancelled,
+		}
+	}
+	return map[string]interface{}{
+		"id":      job.ID,
+		"command": job.Command,
+		"status":  "killed",
+		"signal":  job.Signal,
+	}
+}
+
+// printKilled reports a killed job, noting when it had to be forced
+func printKilled(job *tracker.Job) {
+	if job.Status == tracker.StatusCancelled {
+		fmt.Println(locales.Msg("job.cancelled", job.ID, job.Command))
+		return
+	}
+	fmt.Println(locales.Msg("job.killed", job.ID, job.Command))
+	if job.Signal == tracker.SignalName(syscall.SIGKILL) && killSignal != syscall.SIGKILL {
+		fmt.Println(locales.Msg("job.kill_escalated", tracker.SignalName(killSignal), killGrace))
+	}
+}
+
+// waitForJobs blocks until the referenced jobs (default: unfinished jobs started
+// from the current directory) have finished, printing each as it ends and then
+// a summary. Exits 1 if any failed, or 124 if --timeout ran out first.
+func waitForJobs(t *tracker.Tracker, refs []string) {
+	var ids []int
+	if len(refs) == 0 {
+		pwd, err := os.Getwd()
+		if err != nil {
+			exitWithError(locales.Msg("err.wait_failed", err))
+		}
+		jobs, err := t.List()
+		if err != nil {
+			exitWithError(locales.Msg("err.wait_failed", err))
+		}
+		for _, j := range jobs {
+			if j.ExitCode == nil && j.PWD == pwd {
+				ids = append(ids, j.ID)
+			}
+		}
+		sort.Ints(ids)
+	} else {
+		for _, ref := range refs {
+			ids = append(ids, findJob(t, ref, "err.wait_failed").ID)
+		}
 	}
 
-	// Print header
-	fmt.Printf("%-*s  %-*s  %-*s  %-*s  %s\n", idW, "ID", statusW, "STATUS", startW, "START", durW, "DURATION", "COMMAND")
-
-	// Print rows with colors
-	for _, r := range rows {
-		line := fmt.Sprintf("%-*d  %-*s  %-*s  %-*s  %s", idW, r.id, statusW, r.status, startW, r.start, durW, r.duration, r.cmd)
-		if r.isError {
-			// Dim row with red status
-			statusStart := idW + 2
-			statusEnd := statusStart + statusW
-			fmt.Printf("%s%s%s%s%s%s%s\n",
-				colorDim, line[:statusStart],
-				colorRed, line[statusStart:statusEnd],
-				colorReset, colorDim, line[statusEnd:]+colorReset)
-		} else if r.isDone {
-			fmt.Printf("%s%s%s\n", colorDim, line, colorReset)
-		} else {
-			fmt.Println(line)
-		}
+	if len(ids) == 0 && !jsonOutput {
+		fmt.Println(locales.Msg("wait.nothing"))
+		return
 	}
-}
 
-// printJobIDs outputs job IDs for shell completion (no jq needed)
-// Respects --running, --failed, --done filters
-func printJobIDs(t *tracker.Tracker) {
-	jobs, err := t.List()
-	if err != nil {
-		os.Exit(1) // Silent fail for completions
+	var deadline time.Time
+	if timeoutFlag > 0 {
+		deadline = time.Now().Add(timeoutFlag)
 	}
 
-	for _, job := range jobs {
-		// Apply filters if any are set
-		if listRunning && job.ExitCode != nil {
-			continue
+	last := make(map[int]tracker.Job) // most recent snapshot of each job
+	var finished []tracker.Job        // in the order they finished
+	done := make(map[int]bool)
+	timedOut := false
+	for {
+		// Jobs whose process died without reporting back get marked as failed
+		// here, so we don't wait on them forever
+		t.GarbageCollect()
+
+		jobs, err := t.List()
+		if err != nil {
+			exitWithError(locales.Msg("err.wait_failed", err))
 		}
-		if listFailed && (job.ExitCode == nil || *job.ExitCode == 0) {
-			continue
+		current := make(map[int]tracker.Job, len(jobs))
+		for _, j := range jobs {
+			current[j.ID] = j
 		}
-		if listDone && (job.ExitCode == nil || *job.ExitCode != 0) {
-			continue
+
+		for _, id := range ids {
+			if done[id] {
+				continue
+			}
+			job, ok := current[id]
+			if !ok {
+				// Pruned before we saw it finish, so its result is lost
+				job = last[id]
+				job.ID = id
+				lost := -1
+				job.ExitCode = &lost
+			}
+			last[id] = job
+			if job.ExitCode == nil {
+				continue
+			}
+			done[id] = true
+			finished = append(finished, job)
+			if !jsonOutput {
+				fmt.Println(locales.Msg("wait.finished", job.ID, jobStatus(jobs, job), job.Command))
+			}
+		}
+
+		if len(finished) == len(ids) {
+			break
+		}
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			timedOut = true
+			break
 		}
-		fmt.Println(job.ID)
+		time.Sleep(waitPollInterval)
 	}
-}
 
-func pruneJobs(t *tracker.Tracker) {
-	count, err := t.Prune()
-	if err != nil {
-		exitWithError(locales.Msg("err.prune_failed", err))
+	succeeded, failed := 0, 0
+	for _, job := range finished {
+		if job.Succeeded() {
+			succeeded++
+		} else {
+			failed++
+		}
 	}
-	if jsonOutput {
-		outputJSON(map[string]interface{}{"pruned": count})
-	} else if count == 0 {
-		fmt.Println(locales.Msg("prune.nothing"))
-	} else {
-		fmt.Println(locales.Msg("prune.success", count))
+	var pending []int
+	for _, id := range ids {
+		if !done[id] {
+			pending = append(pending, id)
+		}
 	}
-}
 
-func garbageCollect(t *tracker.Tracker) {
-	count, err := t.GarbageCollect()
-	if err != nil {
-		exitWithError(locales.Msg("err.gc_failed", err))
-	}
 	if jsonOutput {
-		outputJSON(map[string]interface{}{"collected": count})
-	} else if count == 0 {
-		fmt.Println(locales.Msg("gc.nothing"))
+		results := []map[string]interface{}{}
+		for _, job := range finished {
+			results = append(results, map[string]interface{}{
+				"id":        job.ID,
+				"command":   job.Command,
+				"status":    jobStatus(nil, job),
+				"exit_code": *job.ExitCode,
+			})
+		}
+		if pending == nil {
+			pending = []int{}
+		}
+		outputJSON(map[string]interface{}{
+			"jobs":      results,
+			"pending":   pending,
+			"succeeded": succeeded,
+			"failed":    failed,
+			"timed_out": timedOut,
+		})
 	} else {
-		fmt.Println(locales.Msg("gc.success", count))
+		fmt.Println(locales.Msg("wait.summary", len(finished), succeeded, failed))
+		if timedOut {
+			fmt.Println(locales.Msg("wait.timed_out", timeoutFlag, len(pending)))
+		}
+	}
+
+	switch {
+	case timedOut:
+		os.Exit(tracker.ExitTimeout)
+	case failed > 0:
+		os.Exit(1)
 	}
 }
 
-func killJob(t *tracker.Tracker, jobID int) {
-	var job *tracker.Job
-	var err error
+// suspendJob pauses a running job or resumes a paused one
+// Without a ref, it picks the most recent job that can be paused (or resumed)
+func suspendJob(t *tracker.Tracker, ref string, pause bool) {
+	errKey, noneKey := "err.resume_failed", "resume.no_paused"
+	if pause {
+		errKey, noneKey = "err.pause_failed", "pause.no_running"
+	}
 
-	if jobID == 0 {
-		// Find the most recent running job
-		job, err = t.LatestRunning()
+	var jobID int
+	if ref == "" {
+		jobs, err := t.List()
 		if err != nil {
-			exitWithError(locales.Msg("err.kill_check_failed", err))
+			exitWithError(locales.Msg(errKey, err))
 		}
-		if job == nil {
+		for _, j := range jobs {
+			if j.ExitCode == nil && j.Status != tracker.StatusQueued && j.Paused() != pause {
+				jobID = j.ID
+				break
+			}
+		}
+		if jobID == 0 {
 			if jsonOutput {
-				exitWithError("no running jobs to kill")
+				exitWithError(locales.Msg(noneKey))
 			}
-			fmt.Println(locales.Msg("kill.no_running"))
+			fmt.Println(locales.Msg(noneKey))
 			os.Exit(0)
 		}
-		jobID = job.ID
+	} else {
+		jobID = findJob(t, ref, errKey).ID
 	}
 
-	job, err = t.Kill(jobID)
+	var job *tracker.Job
+	var err error
+	if pause {
+		job, err = t.Pause(jobID)
+	} else {
+		job, err = t.Resume(jobID)
+	}
 	if err != nil {
-		exitWithError(locales.Msg("err.kill_failed", err))
+		exitWithError(locales.Msg(errKey, err))
 	}
 
 	if jsonOutput {
+		status := "running"
+		if pause {
+			status = "paused"
+		}
 		outputJSON(map[string]interface{}{
-			"id":      job.ID,
-			"command": job.Command,
-			"status":  "killed",
+			"id":         job.ID,
+			"command":    job.Command,
+			"status":     status,
+			"paused_for": job.PausedFor.String(),
 		})
+	} else if pause {
+		fmt.Println(locales.Msg("job.paused", job.ID, job.Command))
 	} else {
-		fmt.Println(locales.Msg("job.killed", job.ID, job.Command))
+		fmt.Println(locales.Msg("job.resumed", job.ID, job.Command))
 	}
 }
 
 // runCommandWithRetry runs a new command with retry logic
 func runCommandWithRetry(cfg *config.Config, t *tracker.Tracker, command string, maxAttempts int, delaySecs int) {
-	pwd, err := os.Getwd()
-	if err != nil {
-		exitWithError(locales.Msg("err.retry_pwd_failed", err))
-	}
+	pwd := launchDir("err.retry_pwd_failed")
 
 	r := runner.New(cfg, t)
-	jobID, err := r.RunWithRetry(command, pwd, maxAttempts, delaySecs)
+	opts := launchOptions(cfg, t)
+	jobID, err := r.RunWithRetry(command, pwd, maxAttempts, delaySecs, opts)
 	if err != nil {
-		exitWithError(locales.Msg("err.run_failed", err))
+		exitWithRunError("err.run_failed", opts, err)
 	}
 
 	if jsonOutput {
-		outputJSON(map[string]interface{}{
-			"id":           jobID,
-			"command":      command,
-			"status":       "started",
-			"max_attempts": maxAttempts,
-			"delay_secs":   delaySecs,
-		})
+		result := launchJSON(t, jobID, command, opts)
+		result["max_attempts"] = maxAttempts
+		result["delay_secs"] = delaySecs
+		outputJSON(result)
 	} else {
 		if maxAttempts == 0 {
 			fmt.Println(locales.Msg("job.retry_unlimited", jobID, command))
@@ -602,47 +2508,55 @@ func runCommandWithRetry(cfg *config.Config, t *tracker.Tracker, command string,
 		} else {
 			fmt.Println(locales.Msg("job.retry_limited", jobID, maxAttempts, command))
 		}
+		announceLaunch(t, jobID, opts)
 	}
 }
 
 // runCommandWithRestart runs a new command with restart-on-failure logic
-func runCommandWithRestart(cfg *config.Config, t *tracker.Tracker, command string) {
-	pwd, err := os.Getwd()
-	if err != nil {
-		exitWithError(locales.Msg("err.restart_pwd_failed", err))
-	}
+func runCommandWithRestart(cfg *config.Config, t *tracker.Tracker, command string, delaySecs int) {
+	pwd := launchDir("err.restart_pwd_failed")
 
 	r := runner.New(cfg, t)
-	jobID, err := r.RunWithRestart(command, pwd)
+	opts := launchOptions(cfg, t)
+	jobID, err := r.RunWithRestart(command, pwd, delaySecs, opts)
 	if err != nil {
-		exitWithError(locales.Msg("err.run_failed", err))
+		exitWithRunError("err.run_failed", opts, err)
 	}
 
 	if jsonOutput {
-		outputJSON(map[string]interface{}{
-			"id":      jobID,
-			"command": command,
-			"status":  "started",
-			"restart": true,
-		})
+		result := launchJSON(t, jobID, command, opts)
+		result["restart"] = true
+		result["delay_secs"] = delaySecs
+		if restartAlways {
+			result["restart_policy"] = "always"
+		}
+		if job, _ := t.Get(jobID); job != nil && job.RestartLimit > 0 {
+			result["restart_limit"] = job.RestartLimit
+			result["restart_window"] = job.RestartWindow.String()
+		}
+		outputJSON(result)
 	} else {
-		fmt.Println(locales.Msg("job.restarted", jobID, command))
+		if restartAlways {
+			fmt.Println(locales.Msg("job.restarted_always", jobID, command))
+		} else {
+			fmt.Println(locales.Msg("job.restarted", jobID, command))
+		}
+		announceLaunch(t, jobID, opts)
 	}
 }
 
-// retryExistingJob retries a failed job (or most recent failure if jobID is 0)
-func retryExistingJob(cfg *config.Config, t *tracker.Tracker, jobID int, maxAttempts int, delaySecs int) {
+// retryExistingJob retries a failed job (or most recent failure if ref is empty)
+func retryExistingJob(cfg *config.Config, t *tracker.Tracker, ref string, maxAttempts int, delaySecs int) {
 	var job *tracker.Job
-	var err error
 
-	if jobID == 0 {
+	if ref == "" {
 		// Find the most recent failed job
 		jobs, err := t.List()
 		if err != nil {
 			exitWithError(locales.Msg("err.retry_history_failed", err))
 		}
 		for _, j := range jobs {
-			if j.ExitCode != nil && *j.ExitCode != 0 {
+			if j.Failed() {
 				job = &j
 				break
 			}
@@ -655,39 +2569,28 @@ func retryExistingJob(cfg *config.Config, t *tracker.Tracker, jobID int, maxAtte
 			os.Exit(0)
 		}
 	} else {
-		job, err = t.Get(jobID)
-		if err != nil {
-			exitWithError(locales.Msg("err.retry_find_failed", err))
-		}
-		if job == nil {
-			exitWithError(locales.Msg("err.job_not_found", jobID))
-		}
+		job = findJob(t, ref, "err.retry_find_failed")
 	}
 
 	// Check if job actually failed
 	if job.ExitCode == nil {
 		exitWithError(locales.Msg("err.job_still_running", job.ID))
 	}
-	if *job.ExitCode == 0 {
+	if job.Succeeded() {
 		exitWithError(locales.Msg("err.job_already_succeeded", job.ID))
 	}
 
-	// Run the job with retry wrapper
-	r := runner.New(cfg, t)
-	newJobID, err := r.RunWithRetry(job.Command, job.PWD, maxAttempts, delaySecs)
+	newJobID, opts, err := rerunJob(cfg, t, job, maxAttempts, delaySecs)
 	if err != nil {
-		exitWithError(locales.Msg("err.retry_start_failed", err))
+		exitWithRunError("err.retry_start_failed", opts, err)
 	}
 
 	if jsonOutput {
-		outputJSON(map[string]interface{}{
-			"id":           newJobID,
-			"command":      job.Command,
-			"status":       "started",
-			"max_attempts": maxAttempts,
-			"delay_secs":   delaySecs,
-			"original_job": job.ID,
-		})
+		result := launchJSON(t, newJobID, job.Command, opts)
+		result["max_attempts"] = maxAttempts
+		result["delay_secs"] = delaySecs
+		result["original_job"] = job.ID
+		outputJSON(result)
 	} else {
 		if maxAttempts == 0 {
 			fmt.Println(locales.Msg("job.retry_unlimited", newJobID, job.Command))
@@ -696,64 +2599,439 @@ func retryExistingJob(cfg *config.Config, t *tracker.Tracker, jobID int, maxAtte
 		} else {
 			fmt.Println(locales.Msg("job.retry_limited", newJobID, maxAttempts, job.Command))
 		}
+		announceLaunch(t, newJobID, opts)
 	}
 }
 
-func viewLogs(cfg *config.Config, t *tracker.Tracker, jobID int) {
+// rerunJob starts a failed job's command again with retry logic, under the
+// original job's name, in the environment it was launched with and judging
+// success the way it did, unless the command line says otherwise
+func rerunJob(cfg *config.Config, t *tracker.Tracker, job *tracker.Job, maxAttempts int, delaySecs int) (int, runner.Options, error) {
+	r := runner.New(cfg, t)
+	opts := launchOptions(cfg, t)
+	if opts.Name == "" {
+		opts.Name = job.Name
+	}
+	if opts.OkCodes == nil {
+		opts.OkCodes = job.OkCodes
+	}
+	if opts.FailIfOutput == "" {
+		opts.FailIfOutput = job.FailIfOutput
+	}
+	if job.Env != nil {
+		opts.Env = runner.MergeEnv(job.Env, envFlags...)
+	}
+	if limitsFlag.IsZero() {
+		opts.Limits = job.Limits
+	}
+	pwd := job.PWD
+	if cwdFlag != "" {
+		pwd = cwdFlag
+	}
+	jobID, err := r.RunWithRetry(job.Command, pwd, maxAttempts, delaySecs, opts)
+	return jobID, opts, err
+}
+
+func viewLogs(cfg *config.Config, t *tracker.Tracker, ref string) {
 	var job *tracker.Job
 	var err error
 
-	if jobID == 0 {
+	if ref == "" {
 		job, err = t.Latest()
 		if err != nil {
 			exitWithError(locales.Msg("err.logs_recall_failed", err))
 		}
 		if job == nil {
 			if jsonOutput {
-				exitWithError("no jobs found")
+				exitWithError(locales.Msg("err.no_jobs"))
 			}
 			fmt.Println(locales.Msg("logs.no_jobs"))
 			os.Exit(0)
 		}
 	} else {
-		job, err = t.Get(jobID)
-		if err != nil {
-			exitWithError(locales.Msg("err.logs_find_failed", err))
+		job = findJob(t, ref, "err.logs_find_failed")
+	}
+
+	// The combined log has both streams interleaved, --stdout/--stderr pick one
+	logPath := job.LogFile
+	if logsStream != "" {
+		logPath = job.StreamLog(logsStream)
+		if logPath == "" {
+			exitWithError(locales.Msg("err.logs_no_stream", job.ID, logsStream))
 		}
-		if job == nil {
-			exitWithError(locales.Msg("err.job_not_found", jobID))
+	}
+
+	// Attempts are recorded as ranges of the combined log, which is only read whole
+	if attemptFlag > 0 {
+		if logsStream != "" || logsFollow {
+			exitWithError(locales.Msg("err.attempt_conflict"))
+		}
+		if attemptFlag > len(job.Attempts) {
+			exitWithError(locales.Msg("err.attempt_not_found", job.ID, attemptFlag, len(job.Attempts)))
 		}
+		if end := job.Attempts[attemptFlag-1].LogEnd; end > 0 && end <= job.LogDropped {
+			exitWithError(locales.Msg("err.attempt_rotated", attemptFlag, job.ID))
+		}
+	}
+
+	// --since/--until go by the timestamp on each line, so the job needs them
+	filtered := !sinceFlag.IsZero() || !untilFlag.IsZero()
+	if filtered && !job.Timestamps {
+		exitWithError(locales.Msg("err.logs_no_timestamps", job.ID))
+	}
+
+	// Check if log file exists (its rotated segments count too)
+	segments := tracker.LogSegments(logPath)
+	if len(segments) == 0 {
+		exitWithError(locales.Msg("err.logs_not_found", logPath))
 	}
 
-	// Check if log file exists
-	if _, err := os.Stat(job.LogFile); os.IsNotExist(err) {
-		exitWithError(locales.Msg("err.logs_not_found", job.LogFile))
+	if logsFollow {
+		followLogs(t, job, logPath)
+		return
 	}
 
 	// JSON mode: output log contents
 	if jsonOutput {
-		content, err := os.ReadFile(job.LogFile)
-		if err != nil {
+		var content bytes.Buffer
+		if err := copyLog(&content, job, segments); err != nil {
 			exitWithError(locales.Msg("err.logs_read_failed", err))
 		}
-		outputJSON(map[string]interface{}{
-			"job":     job,
-			"content": string(content),
-		})
+		result := map[string]interface{}{
+			"job":     redactEnv(*job),
+			"content": content.String(),
+		}
+		if logsStream != "" {
+			result["stream"] = logsStream
+		}
+		if attemptFlag > 0 {
+			result["attempt"] = attemptFlag
+		}
+		outputJSON(result)
 		return
 	}
 
+	// The viewer needs a single plain file, so stitch rotated segments together,
+	// decompress them and leave out lines outside --since/--until
+	stitched := filtered || attemptFlag > 0 || len(segments) > 1 || strings.HasSuffix(segments[0], ".gz")
+	if stitched {
+		tmp, err := os.CreateTemp("", fmt.Sprintf("bj-%d-*.log", job.ID))
+		if err != nil {
+			exitWithError(locales.Msg("err.logs_read_failed", err))
+		}
+		err = copyLog(tmp, job, segments)
+		tmp.Close()
+		defer os.Remove(tmp.Name())
+		if err != nil {
+			os.Remove(tmp.Name()) // exitWithError skips deferred calls
+			exitWithError(locales.Msg("err.logs_read_failed", err))
+		}
+		logPath = tmp.Name()
+	}
+
 	// Open with configured viewer
-	cmd := exec.Command(cfg.Viewer, job.LogFile)
+	cmd := exec.Command(cfg.Viewer, logPath)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
 	if err := cmd.Run(); err != nil {
+		if stitched {
+			os.Remove(logPath)
+		}
 		exitWithError(locales.Msg("err.logs_open_failed", err))
 	}
 }
 
+// copyLog writes the lines of a job's log segments that pass the --since/--until
+// filter to w, in order, narrowed down to one attempt's output with --attempt
+func copyLog(w io.Writer, job *tracker.Job, segments []string) error {
+	out := newLogFilter(w, job)
+	var dst io.Writer = out
+	if attemptFlag > 0 {
+		dst = attemptRange(out, job, job.Attempts[attemptFlag-1])
+	}
+	for _, segment := range segments {
+		if err := copyFile(dst, segment); err != nil {
+			return err
+		}
+	}
+	return out.Flush()
+}
+
+// attemptRange returns a writer that passes on only the part of the job's
+// combined log, as stitched from the segments rotation kept, that holds the
+// attempt's output
+func attemptRange(out io.Writer, job *tracker.Job, a tracker.Attempt) io.Writer {
+	// Offsets count from the start of the log, including deleted segments
+	start := a.LogStart - job.LogDropped
+	if start < 0 {
+		start = 0
+	}
+	r := &byteRange{out: out, skip: start, left: -1}
+	if a.LogEnd > 0 {
+		r.left = a.LogEnd - job.LogDropped - start
+	}
+	return r
+}
+
+// byteRange passes on the bytes written to it after skipping the first skip,
+// up to left of them (-1 = no limit)
+type byteRange struct {
+	out        io.Writer
+	skip, left int64
+}
+
+func (r *byteRange) Write(p []byte) (int, error) {
+	n := len(p)
+	skip := min(r.skip, int64(len(p)))
+	p = p[skip:]
+	r.skip -= skip
+	if r.left >= 0 {
+		p = p[:min(r.left, int64(len(p)))]
+		r.left -= int64(len(p))
+	}
+	if len(p) > 0 {
+		if _, err := r.out.Write(p); err != nil {
+			return 0, err
+		}
+	}
+	return n, nil
+}
+
+// copyFile writes a log file's contents to w, decompressing it if needed
+func copyFile(w io.Writer, path string) error {
+	f, err := tracker.OpenLog(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// logFilter passes on the log lines written between sinceFlag and untilFlag.
+// Lines without a timestamp, such as bj's own banners, are kept or dropped
+// along with the line before them. Without bounds, output passes straight
+// through so partial lines aren't held back.
+type logFilter struct {
+	out          io.Writer
+	since, until time.Time
+	keep         bool // whether the current line is in range
+	pending      []byte
+}
+
+func newLogFilter(out io.Writer, job *tracker.Job) *logFilter {
+	f := &logFilter{out: out, since: sinceFlag, until: untilFlag}
+	// Anything before the first timestamp was written when the job started
+	f.keep = f.inRange(job.StartTime)
+	return f
+}
+
+func (f *logFilter) Write(p []byte) (int, error) {
+	if f.since.IsZero() && f.until.IsZero() {
+		return f.out.Write(p)
+	}
+
+	f.pending = append(f.pending, p...)
+	for {
+		end := bytes.IndexByte(f.pending, '\n') + 1
+		if end == 0 {
+			return len(p), nil
+		}
+		if err := f.line(f.pending[:end]); err != nil {
+			return 0, err
+		}
+		f.pending = f.pending[end:]
+	}
+}
+
+// Flush filters a final line that didn't end with a newline
+func (f *logFilter) Flush() error {
+	if len(f.pending) == 0 {
+		return nil
+	}
+	err := f.line(f.pending)
+	f.pending = nil
+	return err
+}
+
+func (f *logFilter) line(b []byte) error {
+	if ts, ok := lineTimestamp(b); ok {
+		f.keep = f.inRange(ts)
+	}
+	if !f.keep {
+		return nil
+	}
+	_, err := f.out.Write(b)
+	return err
+}
+
+func (f *logFilter) inRange(ts time.Time) bool {
+	return (f.since.IsZero() || !ts.Before(f.since)) && (f.until.IsZero() || !ts.After(f.until))
+}
+
+// lineTimestamp parses the timestamp a log line starts with, if it has one
+func lineTimestamp(line []byte) (time.Time, bool) {
+	n := len(runner.TimestampFormat)
+	if len(line) <= n || line[n] != ' ' {
+		return time.Time{}, false
+	}
+	ts, err := time.Parse(runner.TimestampFormat, string(line[:n]))
+	return ts, err == nil
+}
+
+// grepMatch is a log line that matched --grep
+type grepMatch struct {
+	Job  tracker.Job `json:"job"`
+	Line int         `json:"line"` // 1-based, counted across rotated segments
+	Text string      `json:"text"`
+}
+
+// grepLogs searches every tracked job's log (narrowed by the list filters) for
+// lines matching a regular expression, oldest job first. Like grep, it exits
+// with status 1 when nothing matches.
+func grepLogs(t *tracker.Tracker, pattern string) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		exitWithError(locales.Msg("err.grep_invalid_pattern", err))
+	}
+
+	jobs, err := t.List()
+	if err != nil {
+		exitWithError(locales.Msg("err.grep_failed", err))
+	}
+	jobs = filterJobs(jobs)
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].ID < jobs[j].ID })
+
+	matches := []grepMatch{}
+	for _, job := range jobs {
+		// Pruned logs or jobs from before the log directory moved just have nothing to search
+		var log bytes.Buffer
+		if err := copyLog(&log, &job, tracker.LogSegments(job.LogFile)); err != nil {
+			continue
+		}
+
+		for i, line := range strings.Split(strings.TrimSuffix(log.String(), "\n"), "\n") {
+			if !re.MatchString(line) {
+				continue
+			}
+			match := grepMatch{Job: redactEnv(job), Line: i + 1, Text: line}
+			matches = append(matches, match)
+			if !jsonOutput {
+				fmt.Printf("%s%s%s %s\n", colorDim, locales.Msg("grep.prefix", job.ID, truncateCommand(job.Command)), colorReset, line)
+			}
+		}
+	}
+
+	if jsonOutput {
+		outputJSON(matches)
+	} else if len(matches) == 0 {
+		fmt.Println(locales.Msg("grep.no_matches", pattern))
+	}
+	if len(matches) == 0 {
+		os.Exit(1)
+	}
+}
+
+// followLogs streams one of a job's logs to stdout as it's written, then exits
+// with the job's exit status once it has finished and all its output is printed
+func followLogs(t *tracker.Tracker, job *tracker.Job, logPath string) {
+	out := newLogFilter(os.Stdout, job)
+
+	// Catch up on anything already rotated out of the live log
+	segments := tracker.LogSegments(logPath)
+	for i := 0; i < len(segments)-1; i++ {
+		segment := segments[i]
+		if err := copyFile(out, segment); err != nil {
+			exitWithError(locales.Msg("err.logs_read_failed", err))
+		}
+	}
+
+	// Only finished jobs have compressed logs, there's nothing left to follow
+	livePath := segments[len(segments)-1]
+	if strings.HasSuffix(livePath, ".gz") {
+		if err := copyFile(out, livePath); err != nil {
+			exitWithError(locales.Msg("err.logs_read_failed", err))
+		}
+		out.Flush()
+		current, err := t.Get(job.ID)
+		if err != nil {
+			exitWithError(locales.Msg("err.logs_read_failed", err))
+		}
+		os.Exit(jobExitStatus(current))
+	}
+
+	f, err := os.Open(livePath)
+	if err != nil {
+		exitWithError(locales.Msg("err.logs_read_failed", err))
+	}
+	defer f.Close()
+
+	for {
+		// A job whose process died without reporting back would be followed forever
+		t.GarbageCollect()
+
+		// Check before draining so output written just before completion isn't missed
+		current, err := t.Get(job.ID)
+		if err != nil {
+			exitWithError(locales.Msg("err.logs_read_failed", err))
+		}
+		if _, err := io.Copy(out, f); err != nil {
+			exitWithError(locales.Msg("err.logs_read_failed", err))
+		}
+
+		// The log was rotated: what's left of the old file was just printed,
+		// carry on with the new one
+		if rotated(f, livePath) {
+			f.Close()
+			if f, err = os.Open(livePath); err != nil {
+				exitWithError(locales.Msg("err.logs_read_failed", err))
+			}
+			continue
+		}
+
+		if current == nil || current.ExitCode != nil {
+			out.Flush()
+			f.Close()
+			os.Exit(jobExitStatus(current))
+		}
+		time.Sleep(followPollInterval)
+	}
+}
+
+// rotated reports whether the file at path is no longer the open file f
+func rotated(f *os.File, path string) bool {
+	current, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	open, err := f.Stat()
+	return err == nil && !os.SameFile(open, current)
+}
+
+// jobExitStatus converts a finished job's exit code to a process exit status
+// Jobs that succeeded exit 0 (whatever their --ok-codes code), failed jobs
+// whose code would read as success exit 1, and killed jobs (-N) follow the
+// shell's 128+N convention
+func jobExitStatus(job *tracker.Job) int {
+	if job == nil || job.ExitCode == nil {
+		return 1
+	}
+	code := *job.ExitCode
+	switch {
+	case job.Succeeded():
+		return 0
+	case code > 0:
+		return code
+	case job.Signal != "":
+		return 128 - code
+	default:
+		return 1
+	}
+}
+
 func printCompletion(shell string) {
 	switch shell {
 	case "fish":
@@ -8,11 +8,14 @@ import (
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/metruzanca/bj/internal/runner"
 	"github.com/metruzanca/bj/internal/tracker"
 )
 
@@ -43,6 +46,7 @@ type testEnv struct {
 	t         *testing.T
 	configDir string
 	bjPath    string
+	vars      []string // extra KEY=VAL environment variables bj is run with
 }
 
 func newTestEnv(t *testing.T) *testEnv {
@@ -78,7 +82,7 @@ func (e *testEnv) run(args ...string) (stdout, stderr string, exitCode int) {
 	e.t.Helper()
 
 	cmd := exec.Command(e.bjPath, args...)
-	cmd.Env = append(os.Environ(), "BJ_CONFIG_DIR="+e.configDir)
+	cmd.Env = append(append(os.Environ(), e.vars...), "BJ_CONFIG_DIR="+e.configDir)
 
 	var stdoutBuf, stderrBuf strings.Builder
 	cmd.Stdout = &stdoutBuf
@@ -144,6 +148,14 @@ func assertContains(t *testing.T, output, substr string) {
 	}
 }
 
+// assertNotContains checks stdout doesn't contain a substring
+func assertNotContains(t *testing.T, output, substr string) {
+	t.Helper()
+	if strings.Contains(output, substr) {
+		t.Errorf("output contained unexpected substring\nunexpected: %s\noutput: %s", substr, output)
+	}
+}
+
 // assertExitCode checks the exit code
 func assertExitCode(t *testing.T, got, want int) {
 	t.Helper()
@@ -217,6 +229,27 @@ func TestHelpRetry(t *testing.T) {
 	goldenFile(t, "help-retry", stdout)
 }
 
+func TestHelpRestart(t *testing.T) {
+	env := newTestEnv(t)
+	stdout, _, code := env.run("--restart", "--help")
+	assertExitCode(t, code, 0)
+	goldenFile(t, "help-restart", stdout)
+}
+
+func TestHelpShow(t *testing.T) {
+	env := newTestEnv(t)
+	stdout, _, code := env.run("--show", "--help")
+	assertExitCode(t, code, 0)
+	goldenFile(t, "help-show", stdout)
+}
+
+func TestHelpTUI(t *testing.T) {
+	env := newTestEnv(t)
+	stdout, _, code := env.run("--tui", "--help")
+	assertExitCode(t, code, 0)
+	goldenFile(t, "help-tui", stdout)
+}
+
 func TestHelpPrune(t *testing.T) {
 	env := newTestEnv(t)
 	stdout, _, code := env.run("--prune", "--help")
@@ -231,6 +264,27 @@ func TestHelpGC(t *testing.T) {
 	goldenFile(t, "help-gc", stdout)
 }
 
+func TestHelpPause(t *testing.T) {
+	env := newTestEnv(t)
+	stdout, _, code := env.run("--pause", "--help")
+	assertExitCode(t, code, 0)
+	goldenFile(t, "help-pause", stdout)
+}
+
+func TestHelpWait(t *testing.T) {
+	env := newTestEnv(t)
+	stdout, _, code := env.run("--wait", "--help")
+	assertExitCode(t, code, 0)
+	goldenFile(t, "help-wait", stdout)
+}
+
+func TestHelpGrep(t *testing.T) {
+	env := newTestEnv(t)
+	stdout, _, code := env.run("--grep", "--help")
+	assertExitCode(t, code, 0)
+	goldenFile(t, "help-grep", stdout)
+}
+
 func TestHelpCompletion(t *testing.T) {
 	env := newTestEnv(t)
 	stdout, _, code := env.run("--completion", "--help")
@@ -421,6 +475,13 @@ func TestIds(t *testing.T) {
 	if len(lines) != 2 {
 		t.Errorf("expected 2 done IDs, got %d: %v", len(lines), lines)
 	}
+
+	// Status filters add up, the same as for --list
+	stdout, _, _ = env.run("--ids", "--failed", "--done")
+	lines = strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 3 {
+		t.Errorf("expected 3 failed or done IDs, got %d: %v", len(lines), lines)
+	}
 }
 
 func TestLogs(t *testing.T) {
@@ -620,6 +681,193 @@ func TestKillJSON(t *testing.T) {
 	}
 }
 
+func TestKillSignal(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.run("sleep", "30")
+	time.Sleep(200 * time.Millisecond)
+
+	_, _, code := env.run("--kill", "1", "--signal", "INT")
+	assertExitCode(t, code, 0)
+
+	job := env.getJob(1)
+	if job.Signal != "SIGINT" || *job.ExitCode != -2 {
+		t.Errorf("signal = %q, exit code = %d, want SIGINT and -2", job.Signal, *job.ExitCode)
+	}
+
+	listOut, _, _ := env.run("--list")
+	assertContains(t, listOut, "killed(SIGINT)")
+}
+
+func TestKillEscalates(t *testing.T) {
+	env := newTestEnv(t)
+
+	// The ignored disposition is inherited by sleep, so only SIGKILL will do
+	env.run("trap '' TERM; sleep 30")
+	time.Sleep(200 * time.Millisecond)
+
+	start := time.Now()
+	stdout, _, code := env.run("--kill", "--grace", "300ms")
+	assertExitCode(t, code, 0)
+	assertContains(t, stdout, "SIGTERM was ignored for 300ms, so bj used SIGKILL")
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond {
+		t.Errorf("kill returned after %s, before the grace period ended", elapsed)
+	}
+
+	job := env.getJob(1)
+	if job.Signal != "SIGKILL" || *job.ExitCode != -9 {
+		t.Errorf("signal = %q, exit code = %d, want SIGKILL and -9", job.Signal, *job.ExitCode)
+	}
+}
+
+func TestKillKeepsShutdownOutput(t *testing.T) {
+	env := newTestEnv(t)
+
+	// What the job writes after SIGTERM still has to reach its logs
+	env.run("trap 'echo graceful-bye; exit 0' TERM; echo started; while :; do sleep 0.1; done")
+	time.Sleep(300 * time.Millisecond)
+
+	_, _, code := env.run("--kill", "--grace", "5s")
+	assertExitCode(t, code, 0)
+
+	logsOut, _, _ := env.run("--logs", "1")
+	assertContains(t, logsOut, "started")
+	assertContains(t, logsOut, "graceful-bye")
+	stdoutLog, _, _ := env.run("--logs", "1", "--stdout")
+	assertContains(t, stdoutLog, "graceful-bye")
+}
+
+func TestWrapperOutputAppendsToLog(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.run("echo first-line-of-output; sleep 0.5")
+	time.Sleep(200 * time.Millisecond)
+	logFile := env.getJob(1).LogFile
+
+	// With the job gone, bj --complete complains through the wrapper's output
+	env.writeJobsFile(nil)
+	time.Sleep(time.Second)
+
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := string(data)
+	if !strings.HasPrefix(log, "first-line-of-output\n") {
+		t.Errorf("the job's output was overwritten:\n%s", log)
+	}
+	if len(strings.TrimPrefix(log, "first-line-of-output\n")) == 0 {
+		t.Errorf("the wrapper's error didn't reach the log:\n%s", log)
+	}
+}
+
+func TestKillRestartJobStaysDown(t *testing.T) {
+	env := newTestEnv(t)
+
+	// A restart job exits cleanly on SIGTERM, which mustn't count as a reason to restart
+	env.run("--restart=always", "--delay", "0", "trap 'exit 0' TERM; while :; do sleep 0.1; done")
+	time.Sleep(300 * time.Millisecond)
+
+	_, _, code := env.run("--kill")
+	assertExitCode(t, code, 0)
+	time.Sleep(500 * time.Millisecond)
+
+	job := env.getJob(1)
+	if job.Signal != "SIGTERM" {
+		t.Errorf("signal = %q, want SIGTERM", job.Signal)
+	}
+	logsOut, _, _ := env.run("--logs", "1")
+	if n := strings.Count(logsOut, "=== Starting:"); n != 1 {
+		t.Errorf("job started %d times, want 1:\n%s", n, logsOut)
+	}
+}
+
+func TestKillAll(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.run("sleep", "30")
+	env.run("--queue", "a", "sleep", "30")
+	env.run("--queue", "a", "sleep", "30")
+	env.run("--queue", "b", "sleep", "30")
+	time.Sleep(200 * time.Millisecond)
+
+	// Narrowed to one queue, including its queued job
+	stdout, _, code := env.run("--kill", "--all", "--queue", "a")
+	assertExitCode(t, code, 0)
+	assertMatch(t, stdout, `\[2\] bj stopped abruptly`)
+	assertMatch(t, stdout, `\[3\] bj called it off before it started`)
+
+	// Nothing was signalled for the queued job, it just never ran
+	if job := env.getJob(3); job.Status != tracker.StatusCancelled || job.Signal != "" || *job.ExitCode != tracker.ExitSkipped {
+		t.Errorf("queued job status = %q, signal = %q, exit code = %d, want cancelled without a signal", job.Status, job.Signal, *job.ExitCode)
+	}
+	listOut, _, _ := env.run("--list")
+	assertMatch(t, listOut, `3\s+a\s+\S*cancelled`)
+	idsOut, _, _ := env.run("--ids", "--running")
+	if got := strings.Fields(idsOut); len(got) != 2 {
+		t.Errorf("running jobs after killing queue a = %v, want [4 1]", got)
+	}
+
+	stdout, _, code = env.run("--kill", "--all", "--json")
+	assertExitCode(t, code, 0)
+	var results []struct {
+		ID     int    `json:"id"`
+		Signal string `json:"signal"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &results); err != nil {
+		t.Fatalf("failed to parse JSON: %v", err)
+	}
+	if len(results) != 2 || results[0].ID != 1 || results[1].ID != 4 {
+		t.Errorf("killed %+v, want jobs 1 and 4", results)
+	}
+
+	idsOut, _, _ = env.run("--ids", "--running")
+	if strings.TrimSpace(idsOut) != "" {
+		t.Errorf("expected no running jobs after --kill --all, got: %s", idsOut)
+	}
+}
+
+func TestKillAllFailedDeps(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.runAndWait("false")
+	env.run("--after-any", "1", "sleep", "30")
+	env.run("sleep", "30")
+	time.Sleep(300 * time.Millisecond)
+
+	// Only the job riding on the failed one is stopped
+	stdout, _, code := env.run("--kill", "--all", "--failed-deps")
+	assertExitCode(t, code, 0)
+	assertMatch(t, stdout, `\[2\] bj stopped abruptly`)
+	assertNotContains(t, stdout, "[3]")
+
+	idsOut, _, _ := env.run("--ids", "--running")
+	if got := strings.Fields(idsOut); !slices.Equal(got, []string{"3"}) {
+		t.Errorf("running jobs = %v, want [3]", got)
+	}
+	env.run("--kill", "3")
+}
+
+func TestKillAllRejectsRef(t *testing.T) {
+	env := newTestEnv(t)
+
+	_, stderr, code := env.run("--kill", "--all", "5")
+	assertExitCode(t, code, 1)
+	assertContains(t, stderr, "doesn't take a job too ('5')")
+
+	_, stderr, code = env.run("--kill", "--failed-deps")
+	assertExitCode(t, code, 1)
+	assertContains(t, stderr, "add --all")
+}
+
+func TestInvalidSignal(t *testing.T) {
+	env := newTestEnv(t)
+
+	_, stderr, code := env.run("--kill", "--signal", "NOPE")
+	assertExitCode(t, code, 1)
+	assertContains(t, stderr, "bj doesn't know the signal 'NOPE'")
+}
+
 // =============================================================================
 // GC Tests
 // =============================================================================
@@ -879,3 +1127,2259 @@ func TestListFilterEmpty(t *testing.T) {
 	stdout, _, _ := env.run("--list", "--failed")
 	assertContains(t, stdout, "No jobs match your criteria")
 }
+
+// =============================================================================
+// Named Job Tests
+// =============================================================================
+
+func TestNamedJob(t *testing.T) {
+	env := newTestEnv(t)
+
+	stdout, _, code := env.runAndWait("--name", "greeter", "echo", "hello by name")
+	assertExitCode(t, code, 0)
+	assertMatch(t, stdout, `\[1\] bj is on it: echo hello by name`)
+
+	// Logs can be looked up by name
+	stdout, _, code = env.run("--logs", "greeter", "--json")
+	assertExitCode(t, code, 0)
+	var result struct {
+		Job     tracker.Job `json:"job"`
+		Content string      `json:"content"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("failed to parse JSON: %v", err)
+	}
+	if result.Job.Name != "greeter" {
+		t.Errorf("name = %q, want %q", result.Job.Name, "greeter")
+	}
+	assertContains(t, result.Content, "hello by name")
+
+	// The list shows a NAME column
+	listOut, _, _ := env.run("--list")
+	assertMatch(t, listOut, `ID\s+NAME\s+STATUS`)
+	assertContains(t, listOut, "greeter")
+}
+
+func TestNamedJobKill(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.run("--name", "sleepy", "sleep", "30")
+	time.Sleep(200 * time.Millisecond)
+
+	stdout, _, code := env.run("--kill", "sleepy")
+	assertExitCode(t, code, 0)
+	assertMatch(t, stdout, `\[1\] bj stopped abruptly: sleep 30`)
+}
+
+func TestNameInUse(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.run("--name", "api", "sleep", "30")
+	time.Sleep(200 * time.Millisecond)
+
+	_, stderr, code := env.run("--name", "api", "sleep", "30")
+	assertExitCode(t, code, 1)
+	assertContains(t, stderr, "already busy with a job named 'api'")
+
+	// Once the first job is gone the name is free again
+	env.run("--kill", "api")
+	_, _, code = env.run("--name", "api", "echo", "again")
+	assertExitCode(t, code, 0)
+}
+
+func TestNameNumeric(t *testing.T) {
+	env := newTestEnv(t)
+
+	_, stderr, code := env.run("--name", "42", "echo", "test")
+	assertExitCode(t, code, 1)
+	assertContains(t, stderr, "looks like a job ID")
+}
+
+func TestIdsAndNamesByName(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.runAndWait("--name", "build", "echo", "one")
+	env.runAndWait("echo", "two")
+	env.runAndWait("--name", "build", "false")
+
+	stdout, _, _ := env.run("--ids", "build")
+	if got := strings.Fields(stdout); len(got) != 2 || got[0] != "3" || got[1] != "1" {
+		t.Errorf("--ids build = %v, want [3 1]", got)
+	}
+
+	stdout, _, _ = env.run("--ids", "build", "--failed")
+	if got := strings.TrimSpace(stdout); got != "3" {
+		t.Errorf("--ids build --failed = %q, want %q", got, "3")
+	}
+
+	stdout, _, _ = env.run("--names")
+	if got := strings.TrimSpace(stdout); got != "build" {
+		t.Errorf("--names = %q, want %q", got, "build")
+	}
+}
+
+func TestRetryByName(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.runAndWait("--name", "flaky", "false")
+	env.runAndWait("sh", "-c", "exit 2")
+
+	stdout, _, code := env.run("--retry", "--id", "flaky")
+	assertExitCode(t, code, 0)
+	assertMatch(t, stdout, `\[\d+\] bj will keep edging until it succeeds: false`)
+}
+
+func TestRetryKeepsName(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.runAndWait("--name", "flaky", "sh", "-c", "echo first; exit 1")
+
+	stdout, _, code := env.run("--retry=1", "--id", "flaky", "--json")
+	assertExitCode(t, code, 0)
+	assertContains(t, stdout, `"name": "flaky"`)
+	if job := env.waitForJob(2, 5*time.Second); job.Name != "flaky" {
+		t.Errorf("retried job name = %q, want flaky", job.Name)
+	}
+
+	// The name now finds the retry rather than the original
+	logs, _, _ := env.run("--logs", "flaky")
+	assertContains(t, logs, "=== Attempt 1 of 1 ===")
+
+	// An explicit name still wins
+	env.run("--retry=1", "--id", "flaky", "--name", "other")
+	if job := env.waitForJob(3, 5*time.Second); job.Name != "other" {
+		t.Errorf("renamed retry name = %q, want other", job.Name)
+	}
+}
+
+func TestLogsUnknownName(t *testing.T) {
+	env := newTestEnv(t)
+
+	_, stderr, code := env.run("--logs", "nope")
+	assertExitCode(t, code, 1)
+	assertContains(t, stderr, "Job nope? bj doesn't remember that.")
+}
+
+// =============================================================================
+// Timeout Tests
+// =============================================================================
+
+// waitForJob polls until the given job has finished and returns it
+func (e *testEnv) waitForJob(id int, timeout time.Duration) tracker.Job {
+	e.t.Helper()
+	deadline := time.Now().Add(timeout)
+	for {
+		listOut, _, _ := e.run("--list", "--json")
+		var jobs []tracker.Job
+		json.Unmarshal([]byte(listOut), &jobs)
+		for _, j := range jobs {
+			if j.ID == id && j.ExitCode != nil {
+				return j
+			}
+		}
+		if time.Now().After(deadline) {
+			e.t.Fatalf("job %d did not finish within %s", id, timeout)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	env := newTestEnv(t)
+
+	stdout, _, code := env.run("--timeout", "500ms", "sleep", "30")
+	assertExitCode(t, code, 0)
+	assertMatch(t, stdout, `\[1\] bj is on it: sleep 30`)
+
+	job := env.waitForJob(1, 5*time.Second)
+	if job.Status != tracker.StatusTimeout {
+		t.Errorf("status = %q, want %q", job.Status, tracker.StatusTimeout)
+	}
+	if *job.ExitCode != tracker.ExitTimeout {
+		t.Errorf("exit code = %d, want %d", *job.ExitCode, tracker.ExitTimeout)
+	}
+
+	listOut, _, _ := env.run("--list")
+	assertContains(t, listOut, "timeout")
+
+	// Timed out jobs count as failed
+	idsOut, _, _ := env.run("--ids", "--failed")
+	if strings.TrimSpace(idsOut) != "1" {
+		t.Errorf("expected timed out job to be listed as failed, got %q", idsOut)
+	}
+}
+
+func TestTimeoutNotReached(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.run("--timeout", "10s", "echo", "quick")
+	job := env.waitForJob(1, 5*time.Second)
+	if *job.ExitCode != 0 || job.Status != "" {
+		t.Errorf("exit code = %d, status = %q, want 0 and no status", *job.ExitCode, job.Status)
+	}
+
+	stdout, _, _ := env.run("--logs", "--json")
+	assertContains(t, stdout, "quick")
+}
+
+func TestTimeoutJSON(t *testing.T) {
+	env := newTestEnv(t)
+
+	stdout, _, code := env.run("--json", "--timeout", "90s", "echo", "quick")
+	assertExitCode(t, code, 0)
+	assertContains(t, stdout, `"timeout": "1m30s"`)
+}
+
+func TestTimeoutWithRetry(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.run("--retry=2", "--delay", "0", "--timeout", "300ms", "sleep", "30")
+	job := env.waitForJob(1, 5*time.Second)
+	if job.Status != tracker.StatusTimeout {
+		t.Errorf("status = %q, want %q", job.Status, tracker.StatusTimeout)
+	}
+
+	stdout, _, _ := env.run("--logs", "--json")
+	assertContains(t, stdout, "Attempt 1 ruined (exit 124)")
+	assertContains(t, stdout, "All 2 attempts ruined")
+}
+
+func TestInvalidTimeout(t *testing.T) {
+	env := newTestEnv(t)
+
+	_, stderr, code := env.run("--timeout", "soon", "echo", "test")
+	assertExitCode(t, code, 1)
+	assertContains(t, stderr, "bj needs a duration like 30s")
+}
+
+// =============================================================================
+// Dependency Tests
+// =============================================================================
+
+func TestAfterSuccess(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.run("sleep", "1")
+	stdout, _, code := env.run("--after", "1", "echo", "deployed")
+	assertExitCode(t, code, 0)
+	assertContains(t, stdout, "bj will wait for job(s) 1 to finish first")
+
+	// The dependent job waits while job 1 is still going
+	time.Sleep(300 * time.Millisecond)
+	listOut, _, _ := env.run("--list")
+	assertMatch(t, listOut, `2\s+waiting`)
+
+	job := env.waitForJob(2, 5*time.Second)
+	if *job.ExitCode != 0 || job.Status != "" {
+		t.Errorf("exit code = %d, status = %q, want 0 and no status", *job.ExitCode, job.Status)
+	}
+
+	stdout, _, _ = env.run("--logs", "2", "--json")
+	assertContains(t, stdout, "deployed")
+}
+
+func TestAfterFailureSkips(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.run("--name", "build", "sh", "-c", "sleep 0.3; exit 2")
+	env.run("--after", "build", "echo", "deployed")
+
+	job := env.waitForJob(2, 5*time.Second)
+	if job.Status != tracker.StatusSkipped {
+		t.Errorf("status = %q, want %q", job.Status, tracker.StatusSkipped)
+	}
+	if *job.ExitCode != tracker.ExitSkipped {
+		t.Errorf("exit code = %d, want %d", *job.ExitCode, tracker.ExitSkipped)
+	}
+
+	stdout, _, _ := env.run("--logs", "2", "--json")
+	var result struct {
+		Content string `json:"content"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("failed to parse JSON: %v", err)
+	}
+	assertContains(t, result.Content, "Skipped: job 1 failed (exit 2)")
+	if strings.Contains(result.Content, "deployed") {
+		t.Errorf("skipped job should not have run its command")
+	}
+
+	listOut, _, _ := env.run("--list")
+	assertContains(t, listOut, "skipped")
+}
+
+func TestAfterAnyRunsAfterFailure(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.runAndWait("false")
+	env.run("--after-any", "1", "echo", "cleanup")
+
+	job := env.waitForJob(2, 5*time.Second)
+	if *job.ExitCode != 0 {
+		t.Errorf("exit code = %d, want 0", *job.ExitCode)
+	}
+}
+
+func TestAfterJSON(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.runAndWait("echo", "one")
+	env.runAndWait("echo", "two")
+
+	stdout, _, code := env.run("--json", "--after", "1,2", "echo", "three")
+	assertExitCode(t, code, 0)
+	var result struct {
+		Status string `json:"status"`
+		After  []int  `json:"after"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("failed to parse JSON: %v", err)
+	}
+	if result.Status != "waiting" || len(result.After) != 2 {
+		t.Errorf("got status %q after %v, want waiting after [1 2]", result.Status, result.After)
+	}
+}
+
+func TestAfterUnknownJob(t *testing.T) {
+	env := newTestEnv(t)
+
+	_, stderr, code := env.run("--after", "nope", "echo", "test")
+	assertExitCode(t, code, 1)
+	assertContains(t, stderr, "Job nope? bj doesn't remember that.")
+}
+
+// =============================================================================
+// Queue Tests
+// =============================================================================
+
+// writeConfig writes a bj.toml into the test's config directory
+func (e *testEnv) writeConfig(content string) {
+	e.t.Helper()
+	if err := os.WriteFile(filepath.Join(e.configDir, "bj.toml"), []byte(content), 0644); err != nil {
+		e.t.Fatalf("failed to write bj.toml: %v", err)
+	}
+}
+
+// getJob returns a job from --list --json, failing the test if it's missing
+func (e *testEnv) getJob(id int) tracker.Job {
+	e.t.Helper()
+	listOut, _, _ := e.run("--list", "--json")
+	var jobs []tracker.Job
+	json.Unmarshal([]byte(listOut), &jobs)
+	for _, j := range jobs {
+		if j.ID == id {
+			return j
+		}
+	}
+	e.t.Fatalf("job %d not found", id)
+	return tracker.Job{}
+}
+
+func TestQueueLimit(t *testing.T) {
+	env := newTestEnv(t)
+	env.writeConfig("[queues.shards]\nmax_parallel = 2\n")
+
+	env.run("--queue", "shards", "sleep", "0.5")
+	env.run("--queue", "shards", "sleep", "0.5")
+	stdout, _, code := env.run("--queue", "shards", "echo", "third")
+	assertExitCode(t, code, 0)
+	assertContains(t, stdout, "bj queued it in 'shards' (position 1)")
+
+	listOut, _, _ := env.run("--list")
+	assertMatch(t, listOut, `ID\s+QUEUE\s+STATUS`)
+	assertMatch(t, listOut, `3\s+shards\s+queued #1`)
+
+	// The queued job starts once one of the first two completes
+	third := env.waitForJob(3, 5*time.Second)
+	first := env.waitForJob(1, 5*time.Second)
+	if *third.ExitCode != 0 {
+		t.Errorf("exit code = %d, want 0", *third.ExitCode)
+	}
+	if third.StartTime.Before(*first.EndTime) {
+		t.Errorf("queued job started at %v, before a slot freed up at %v", third.StartTime, *first.EndTime)
+	}
+
+	stdout, _, _ = env.run("--logs", "3", "--json")
+	assertContains(t, stdout, "third")
+}
+
+func TestQueuePositions(t *testing.T) {
+	env := newTestEnv(t)
+
+	// Unconfigured queues run one job at a time
+	env.run("--queue", "serial", "sleep", "30")
+	env.run("--queue", "serial", "sleep", "30")
+	stdout, _, code := env.run("--json", "--queue", "serial", "sleep", "30")
+	assertExitCode(t, code, 0)
+	var result struct {
+		Status   string `json:"status"`
+		Queue    string `json:"queue"`
+		Position int    `json:"position"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("failed to parse JSON: %v", err)
+	}
+	if result.Status != "queued" || result.Queue != "serial" || result.Position != 2 {
+		t.Errorf("got %+v, want queued in serial at position 2", result)
+	}
+
+	listOut, _, _ := env.run("--list")
+	assertMatch(t, listOut, `2\s+serial\s+queued #1`)
+	assertMatch(t, listOut, `3\s+serial\s+queued #2`)
+
+	// Killing a queued job just takes it out of line
+	_, _, code = env.run("--kill", "2")
+	assertExitCode(t, code, 0)
+	listOut, _, _ = env.run("--list")
+	assertMatch(t, listOut, `3\s+serial\s+queued #1`)
+
+	// Killing the running job hands its slot to the next in line
+	env.run("--kill", "1")
+	job := env.getJob(3)
+	if job.Status != "" || job.PID == 0 {
+		t.Errorf("status = %q, pid = %d, want job 3 running", job.Status, job.PID)
+	}
+	env.run("--kill", "3")
+}
+
+// =============================================================================
+// Pause Tests
+// =============================================================================
+
+// logSize returns the current size of a job's log file
+func (e *testEnv) logSize(id int) int64 {
+	e.t.Helper()
+	info, err := os.Stat(e.getJob(id).LogFile)
+	if err != nil {
+		e.t.Fatalf("failed to stat log: %v", err)
+	}
+	return info.Size()
+}
+
+func TestPauseResume(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.run("while true; do echo tick; sleep 0.05; done")
+	time.Sleep(200 * time.Millisecond)
+
+	stdout, _, code := env.run("--pause")
+	assertExitCode(t, code, 0)
+	assertMatch(t, stdout, `\[1\] bj is taking a breather`)
+
+	listOut, _, _ := env.run("--list")
+	assertMatch(t, listOut, `1\s+paused`)
+
+	// Nothing gets written while the job is stopped
+	size := env.logSize(1)
+	time.Sleep(300 * time.Millisecond)
+	if got := env.logSize(1); got != size {
+		t.Errorf("log grew from %d to %d bytes while paused", size, got)
+	}
+
+	_, stderr, code := env.run("--pause", "1")
+	assertExitCode(t, code, 1)
+	assertContains(t, stderr, "already paused")
+
+	stdout, _, code = env.run("--resume")
+	assertExitCode(t, code, 0)
+	assertMatch(t, stdout, `\[1\] bj is back at it`)
+
+	time.Sleep(200 * time.Millisecond)
+	if got := env.logSize(1); got == size {
+		t.Errorf("log didn't grow after resuming")
+	}
+
+	job := env.getJob(1)
+	if job.PausedFor < 300*time.Millisecond || job.PausedAt != nil {
+		t.Errorf("paused_for = %s, paused_at = %v, want at least 300ms and unset", job.PausedFor, job.PausedAt)
+	}
+	if d := job.Duration(); d > time.Since(job.StartTime)-300*time.Millisecond {
+		t.Errorf("duration %s includes paused time", d)
+	}
+
+	env.run("--kill", "1")
+}
+
+func TestKillPaused(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.run("sleep", "30")
+	time.Sleep(200 * time.Millisecond)
+	env.run("--pause", "1")
+
+	start := time.Now()
+	_, _, code := env.run("--kill", "1")
+	assertExitCode(t, code, 0)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("killing a paused job took %s, it should be continued so SIGTERM lands", elapsed)
+	}
+
+	job := env.getJob(1)
+	if job.Signal != "SIGTERM" || job.Paused() {
+		t.Errorf("signal = %q, paused = %v, want SIGTERM and not paused", job.Signal, job.Paused())
+	}
+}
+
+func TestResumeNothingPaused(t *testing.T) {
+	env := newTestEnv(t)
+
+	stdout, _, code := env.run("--resume")
+	assertExitCode(t, code, 0)
+	assertContains(t, stdout, "Nothing to resume")
+}
+
+// =============================================================================
+// Follow Tests
+// =============================================================================
+
+func TestLogsFollow(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.run("for i in 1 2 3; do echo line$i; sleep 0.2; done; exit 3")
+
+	start := time.Now()
+	stdout, _, code := env.run("--logs", "1", "-f")
+	assertExitCode(t, code, 3)
+	assertContains(t, stdout, "line1\nline2\nline3\n")
+	if time.Since(start) < 300*time.Millisecond {
+		t.Errorf("--follow returned before the job finished")
+	}
+}
+
+func TestLogsFollowFinishedJob(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.runAndWait("echo", "all done")
+	stdout, _, code := env.run("--logs", "--follow")
+	assertExitCode(t, code, 0)
+	assertContains(t, stdout, "all done")
+}
+
+func TestLogsFollowJSON(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.runAndWait("echo", "test")
+	_, _, code := env.run("--logs", "-f", "--json")
+	assertExitCode(t, code, 1)
+}
+
+func TestFollowShortFlagBelongsToCommand(t *testing.T) {
+	env := newTestEnv(t)
+
+	// Without --logs, -f is part of the command
+	stdout, _, code := env.runAndWait("echo", "-f")
+	assertExitCode(t, code, 0)
+	assertContains(t, stdout, "echo -f")
+}
+
+func TestFlagsAfterCommandBelongToCommand(t *testing.T) {
+	env := newTestEnv(t)
+
+	// Once the command starts, flags bj also knows are passed through untouched
+	stdout, _, code := env.runAndWait("echo", "docker", "run", "--name", "db", "--env", "A=1", "--all", "--follow", "--since=yesterday", "postgres")
+	assertExitCode(t, code, 0)
+	assertContains(t, stdout, "echo docker run --name db --env A=1 --all --follow --since=yesterday postgres")
+
+	job := env.getJob(1)
+	if job.Name != "" {
+		t.Errorf("name = %q, want none", job.Name)
+	}
+	if slices.Contains(job.Env, "A=1") {
+		t.Errorf("--env after the command was taken as bj's")
+	}
+	logsOut, _, _ := env.run("--logs", "1")
+	assertContains(t, logsOut, "docker run --name db --env A=1 --all --follow --since=yesterday postgres")
+}
+
+func TestDoubleDashEndsFlags(t *testing.T) {
+	env := newTestEnv(t)
+
+	// bj's own flags before --, the command's after it
+	stdout, _, code := env.runAndWait("--name", "dashed", "--", "echo", "--name", "other")
+	assertExitCode(t, code, 0)
+	assertContains(t, stdout, "echo --name other")
+
+	if job := env.getJob(1); job.Name != "dashed" {
+		t.Errorf("name = %q, want %q", job.Name, "dashed")
+	}
+	logsOut, _, _ := env.run("--logs", "dashed")
+	assertContains(t, logsOut, "--name other")
+}
+
+func TestWaitSuccess(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.run("sleep 0.3; echo one")
+	env.run("sleep 0.5; echo two")
+
+	start := time.Now()
+	stdout, _, code := env.run("--wait")
+	assertExitCode(t, code, 0)
+	if time.Since(start) < 400*time.Millisecond {
+		t.Errorf("--wait returned before the jobs finished")
+	}
+	assertMatch(t, stdout, `\[1\] .*done.*: sleep 0\.3; echo one`)
+	assertMatch(t, stdout, `\[2\] .*done.*: sleep 0\.5; echo two`)
+	assertContains(t, stdout, "2 job(s): 2 done, 0 ruined")
+}
+
+func TestWaitFailure(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.run("sleep 0.2; exit 3")
+	env.run("true")
+
+	stdout, _, code := env.run("--wait", "1", "2")
+	assertExitCode(t, code, 1)
+	assertContains(t, stdout, "exit(3)")
+	assertContains(t, stdout, "2 job(s): 1 done, 1 ruined")
+}
+
+func TestWaitByName(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.run("sleep 0.5")
+	env.run("--name", "build", "sleep 0.1")
+
+	stdout, _, code := env.run("--wait", "build")
+	assertExitCode(t, code, 0)
+	assertContains(t, stdout, "1 job(s)")
+	if job := env.getJob(1); job.ExitCode != nil {
+		t.Errorf("job 1 should still be running")
+	}
+}
+
+func TestWaitOtherDirectory(t *testing.T) {
+	env := newTestEnv(t)
+
+	other := t.TempDir()
+	cmd := exec.Command(env.bjPath, "sleep", "5")
+	cmd.Dir = other
+	cmd.Env = append(os.Environ(), "BJ_CONFIG_DIR="+env.configDir)
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("failed to start job: %v", err)
+	}
+	t.Cleanup(func() { env.run("--kill", "1") })
+
+	// Jobs started elsewhere aren't waited for by default
+	stdout, _, code := env.run("--wait")
+	assertExitCode(t, code, 0)
+	assertContains(t, stdout, "Nothing to wait for")
+}
+
+func TestWaitTimeout(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.run("sleep 5")
+	t.Cleanup(func() { env.run("--kill", "1") })
+
+	stdout, _, code := env.run("--wait", "1", "--timeout", "300ms")
+	assertExitCode(t, code, 124)
+	assertContains(t, stdout, "0 job(s)")
+	assertContains(t, stdout, "1 job(s) still going")
+}
+
+func TestWaitJSON(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.run("exit 2")
+	env.run("sleep 5")
+	t.Cleanup(func() { env.run("--kill", "2") })
+
+	stdout, _, code := env.run("--wait", "1", "2", "--timeout", "500ms", "--json")
+	assertExitCode(t, code, 124)
+
+	var result struct {
+		Jobs []struct {
+			ID       int    `json:"id"`
+			Status   string `json:"status"`
+			ExitCode int    `json:"exit_code"`
+		} `json:"jobs"`
+		Pending   []int `json:"pending"`
+		Succeeded int   `json:"succeeded"`
+		Failed    int   `json:"failed"`
+		TimedOut  bool  `json:"timed_out"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	if len(result.Jobs) != 1 || result.Jobs[0].ID != 1 || result.Jobs[0].ExitCode != 2 {
+		t.Errorf("unexpected finished jobs: %+v", result.Jobs)
+	}
+	if len(result.Pending) != 1 || result.Pending[0] != 2 {
+		t.Errorf("expected job 2 pending, got %v", result.Pending)
+	}
+	if result.Failed != 1 || result.Succeeded != 0 || !result.TimedOut {
+		t.Errorf("unexpected summary: %+v", result)
+	}
+}
+
+func TestWaitOrphan(t *testing.T) {
+	env := newTestEnv(t)
+
+	// A "running" job whose process is long gone
+	env.writeJobsFile([]tracker.Job{{
+		ID:        1,
+		Command:   "sleep 100",
+		PWD:       "/tmp",
+		StartTime: time.Now().Add(-time.Minute),
+		PID:       999999,
+	}})
+
+	stdout, _, code := env.run("--wait", "1", "--timeout", "5s")
+	assertExitCode(t, code, 1)
+	assertContains(t, stdout, "0 done, 1 ruined")
+}
+
+func TestLogsSeparateStreams(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.runAndWait("echo out; sleep 0.1; echo err >&2; sleep 0.1; echo out2")
+
+	logContent := func(args ...string) string {
+		t.Helper()
+		stdout, _, code := env.run(append([]string{"--logs", "1", "--json"}, args...)...)
+		assertExitCode(t, code, 0)
+		var result struct {
+			Content string `json:"content"`
+		}
+		if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+			t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+		}
+		return result.Content
+	}
+
+	if got := logContent(); got != "out\nerr\nout2\n" {
+		t.Errorf("combined log = %q, want both streams in order", got)
+	}
+	if got := logContent("--stdout"); got != "out\nout2\n" {
+		t.Errorf("stdout log = %q", got)
+	}
+	if got := logContent("--stderr"); got != "err\n" {
+		t.Errorf("stderr log = %q", got)
+	}
+
+	job := env.getJob(1)
+	if job.StdoutFile == "" || job.StderrFile == "" {
+		t.Errorf("expected stream log paths on the job, got %+v", job)
+	}
+}
+
+func TestLogsShowPartialLine(t *testing.T) {
+	env := newTestEnv(t)
+
+	// A prompt without a newline shows up while the job is still running
+	env.run("printf 'Continue? '; sleep 30")
+	time.Sleep(time.Second)
+
+	logsOut, _, code := env.run("--logs", "1")
+	assertExitCode(t, code, 0)
+	assertContains(t, logsOut, "Continue? ")
+	env.run("--kill", "1")
+}
+
+func TestLogsStreamFollow(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.run("echo visible >&2; sleep 0.2; echo hidden; exit 4")
+
+	stdout, _, code := env.run("--logs", "1", "--stderr", "-f")
+	assertExitCode(t, code, 4)
+	assertContains(t, stdout, "visible")
+	if strings.Contains(stdout, "hidden") {
+		t.Errorf("--stderr should not show stdout, got %q", stdout)
+	}
+}
+
+func TestLogsStreamConflict(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.runAndWait("echo", "test")
+	_, _, code := env.run("--logs", "--stdout", "--stderr")
+	assertExitCode(t, code, 1)
+}
+
+func TestLogsStreamOldJob(t *testing.T) {
+	env := newTestEnv(t)
+
+	// Jobs from before separate capture only have the combined log
+	logPath := filepath.Join(env.configDir, "old.log")
+	os.WriteFile(logPath, []byte("old output\n"), 0644)
+	exitCode := 0
+	env.writeJobsFile([]tracker.Job{{
+		ID:        1,
+		Command:   "echo old output",
+		PWD:       "/tmp",
+		StartTime: time.Now(),
+		ExitCode:  &exitCode,
+		LogFile:   logPath,
+	}})
+
+	_, stderr, code := env.run("--logs", "1", "--stderr")
+	assertExitCode(t, code, 1)
+	assertContains(t, stderr, "full log")
+}
+
+func TestPruneRemovesStreamLogs(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.runAndWait("echo", "test")
+	job := env.getJob(1)
+
+	env.run("--prune")
+	for _, f := range []string{job.LogFile, job.StdoutFile, job.StderrFile} {
+		if _, err := os.Stat(f); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be deleted", f)
+		}
+	}
+}
+
+func TestLogTimestamps(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.runAndWait("--timestamps", "echo out; echo err >&2")
+
+	stamped := regexp.MustCompile(`^\d{4}-\d\d-\d\dT\d\d:\d\d:\d\d\.\d{6}[+-]\d\d:\d\d (out|err)$`)
+	job := env.getJob(1)
+	if !job.Timestamps {
+		t.Errorf("expected job to be recorded with timestamps")
+	}
+	for _, f := range []string{job.LogFile, job.StdoutFile, job.StderrFile} {
+		content, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", f, err)
+		}
+		for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+			if !stamped.MatchString(line) {
+				t.Errorf("%s: line %q has no timestamp", filepath.Base(f), line)
+			}
+		}
+	}
+}
+
+func TestLogTimestampsConfig(t *testing.T) {
+	env := newTestEnv(t)
+	env.writeConfig("log_timestamps = true\n")
+
+	env.runAndWait("echo", "hello")
+	stdout, _, _ := env.run("--logs", "--json")
+	assertMatch(t, stdout, `"content": "\d{4}-\d\d-\d\dT[^ ]+ hello\\n"`)
+}
+
+func TestLogsSinceUntil(t *testing.T) {
+	env := newTestEnv(t)
+
+	now := time.Now()
+	stamp := func(ago time.Duration, text string) string {
+		return now.Add(-ago).Format(runner.TimestampFormat) + " " + text + "\n"
+	}
+	logPath := filepath.Join(env.configDir, "stamped.log")
+	os.WriteFile(logPath, []byte(
+		stamp(2*time.Hour, "early")+
+			stamp(30*time.Minute, "crash")+
+			"=== Failed with exit 1, restarting in 5s... ===\n"+
+			stamp(5*time.Minute, "late"),
+	), 0644)
+	exitCode := 0
+	env.writeJobsFile([]tracker.Job{{
+		ID:         1,
+		Command:    "./server",
+		PWD:        "/tmp",
+		StartTime:  now.Add(-3 * time.Hour),
+		ExitCode:   &exitCode,
+		LogFile:    logPath,
+		Timestamps: true,
+	}})
+
+	content := func(args ...string) string {
+		t.Helper()
+		stdout, _, code := env.run(append([]string{"--logs", "1", "--json"}, args...)...)
+		assertExitCode(t, code, 0)
+		var result struct {
+			Content string `json:"content"`
+		}
+		if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+			t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+		}
+		return result.Content
+	}
+
+	// Untimestamped lines go with the line before them
+	got := content("--since", "1h")
+	assertNotContains(t, got, "early")
+	assertContains(t, got, "crash")
+	assertContains(t, got, "restarting")
+	assertContains(t, got, "late")
+
+	got = content("--until", "10m")
+	assertContains(t, got, "early")
+	assertContains(t, got, "restarting")
+	assertNotContains(t, got, "late")
+
+	got = content("--since", now.Add(-time.Hour).Format(time.RFC3339), "--until", "10m")
+	if strings.Count(got, "\n") != 2 {
+		t.Errorf("expected the crash and its banner, got %q", got)
+	}
+}
+
+func TestLogsSinceWithoutTimestamps(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.runAndWait("echo", "test")
+	_, stderr, code := env.run("--logs", "--since", "10m")
+	assertExitCode(t, code, 1)
+	assertContains(t, stderr, "--timestamps")
+}
+
+func TestLogsInvalidSince(t *testing.T) {
+	env := newTestEnv(t)
+
+	_, _, code := env.run("--logs", "--since", "yesterday-ish")
+	assertExitCode(t, code, 1)
+}
+
+func TestSinceUntilOnlyWithLogs(t *testing.T) {
+	env := newTestEnv(t)
+
+	// git log --since=yesterday is the command's, not a log filter
+	stdout, _, code := env.runAndWait("echo", "log", "--since=yesterday", "--until", "now")
+	assertExitCode(t, code, 0)
+	assertContains(t, stdout, "echo log --since=yesterday --until now")
+
+	// Before a command they mean nothing to bj
+	_, stderr, code := env.run("--since", "1h", "echo", "hi")
+	assertExitCode(t, code, 1)
+	assertContains(t, stderr, "--since")
+}
+
+func TestLogRotation(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.runAndWait("--max-log-size", "100", "--max-log-files", "3", "for i in $(seq 1 30); do echo line $i; done")
+
+	job := env.getJob(1)
+	if job.MaxLogSize != 100 || job.MaxLogFiles != 3 {
+		t.Errorf("expected rotation limits on the job, got %d/%d", job.MaxLogSize, job.MaxLogFiles)
+	}
+
+	segments := tracker.LogSegments(job.LogFile)
+	if len(segments) < 2 {
+		t.Fatalf("expected the log to be rotated, got %v", segments)
+	}
+	for _, segment := range segments {
+		if info, err := os.Stat(segment); err != nil || info.Size() > 100 {
+			t.Errorf("segment %s is larger than the limit", filepath.Base(segment))
+		}
+	}
+
+	// --logs stitches the segments back together in order
+	stdout, _, code := env.run("--logs", "1", "--json")
+	assertExitCode(t, code, 0)
+	var result struct {
+		Content string `json:"content"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	var want strings.Builder
+	for i := 1; i <= 30; i++ {
+		fmt.Fprintf(&want, "line %d\n", i)
+	}
+	if result.Content != want.String() {
+		t.Errorf("stitched log = %q", result.Content)
+	}
+}
+
+func TestLogRotationDropsOldest(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.runAndWait("--max-log-size", "100", "--max-log-files", "2", "for i in $(seq 1 100); do echo line $i; done")
+
+	job := env.getJob(1)
+	if _, err := os.Stat(job.LogFile + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected at most 2 rotated segments")
+	}
+
+	stdout, _, _ := env.run("--logs", "1", "--json")
+	assertContains(t, stdout, `line 100\n`)
+	assertNotContains(t, stdout, `"line 1\n`)
+}
+
+func TestLogRotationConfig(t *testing.T) {
+	env := newTestEnv(t)
+	env.writeConfig("max_log_size = \"1K\"\n")
+
+	env.runAndWait("echo", "test")
+	job := env.getJob(1)
+	if job.MaxLogSize != 1024 || job.MaxLogFiles != 5 {
+		t.Errorf("expected 1K logs with 5 segments, got %d/%d", job.MaxLogSize, job.MaxLogFiles)
+	}
+}
+
+func TestLogRotationFollow(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.run("--max-log-size", "50", "for i in $(seq 1 20); do echo line $i; sleep 0.05; done")
+
+	stdout, _, code := env.run("--logs", "1", "-f")
+	assertExitCode(t, code, 0)
+	var want strings.Builder
+	for i := 1; i <= 20; i++ {
+		fmt.Fprintf(&want, "line %d\n", i)
+	}
+	if stdout != want.String() {
+		t.Errorf("followed output = %q", stdout)
+	}
+}
+
+func TestPruneRemovesLogSegments(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.runAndWait("--max-log-size", "50", "for i in $(seq 1 20); do echo line $i; done")
+	job := env.getJob(1)
+	segments := job.LogFiles()
+	if len(segments) <= 3 {
+		t.Fatalf("expected rotated segments, got %v", segments)
+	}
+
+	env.run("--prune")
+	for _, f := range segments {
+		if _, err := os.Stat(f); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be deleted", filepath.Base(f))
+		}
+	}
+}
+
+func TestInvalidMaxLogSize(t *testing.T) {
+	env := newTestEnv(t)
+
+	_, _, code := env.run("--max-log-size", "lots", "echo", "test")
+	assertExitCode(t, code, 1)
+
+	_, _, code = env.run("--max-log-files", "0", "echo", "test")
+	assertExitCode(t, code, 1)
+}
+
+func TestCompressLogs(t *testing.T) {
+	env := newTestEnv(t)
+	env.writeConfig("compress_logs = true\ncompress_min_size = \"1\"\n")
+
+	env.runAndWait("echo out; sleep 0.2; echo err >&2; exit 3")
+
+	job := env.getJob(1)
+	for _, f := range []string{job.LogFile, job.StdoutFile, job.StderrFile} {
+		if !strings.HasSuffix(f, ".gz") {
+			t.Errorf("expected %s to be compressed", filepath.Base(f))
+		}
+		if _, err := os.Stat(strings.TrimSuffix(f, ".gz")); !os.IsNotExist(err) {
+			t.Errorf("expected the uncompressed %s to be removed", filepath.Base(f))
+		}
+	}
+
+	stdout, _, code := env.run("--logs", "1", "--json")
+	assertExitCode(t, code, 0)
+	assertContains(t, stdout, `"content": "out\nerr\n"`)
+
+	stdout, _, _ = env.run("--logs", "1", "--stderr", "--json")
+	assertContains(t, stdout, `"content": "err\n"`)
+
+	stdout, _, code = env.run("--logs", "1", "-f")
+	assertExitCode(t, code, 3)
+	if stdout != "out\nerr\n" {
+		t.Errorf("followed output = %q", stdout)
+	}
+
+	env.run("--prune")
+	for _, f := range []string{job.LogFile, job.StdoutFile, job.StderrFile} {
+		if _, err := os.Stat(f); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be deleted", filepath.Base(f))
+		}
+	}
+}
+
+func TestCompressLogsThreshold(t *testing.T) {
+	env := newTestEnv(t)
+	env.writeConfig("compress_logs = true\n")
+
+	// Far below the default threshold
+	env.runAndWait("echo", "small")
+	if job := env.getJob(1); strings.HasSuffix(job.LogFile, ".gz") {
+		t.Errorf("expected a small log to stay uncompressed")
+	}
+}
+
+func TestCompressLogsKilled(t *testing.T) {
+	env := newTestEnv(t)
+	env.writeConfig("compress_logs = true\ncompress_min_size = \"1\"\n")
+
+	env.run("echo started; sleep 10")
+	time.Sleep(300 * time.Millisecond)
+	env.run("--kill", "1")
+
+	job := env.getJob(1)
+	if !strings.HasSuffix(job.LogFile, ".gz") {
+		t.Errorf("expected the killed job's log to be compressed, got %s", job.LogFile)
+	}
+	stdout, _, _ := env.run("--logs", "1", "--json")
+	assertContains(t, stdout, "started")
+}
+
+func TestGrep(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.runAndWait("echo alpha; echo beta")
+	env.runAndWait("echo gamma; echo alpha two >&2")
+
+	stdout, _, code := env.run("--grep", "^alpha")
+	assertExitCode(t, code, 0)
+	assertMatch(t, stdout, `\[1\] echo alpha; echo beta:.* alpha\n`)
+	assertMatch(t, stdout, `\[2\] echo gamma; echo alpha two >&2:.* alpha two\n`)
+	assertNotContains(t, stdout, "beta\n")
+	if strings.Index(stdout, "[1]") > strings.Index(stdout, "[2]") {
+		t.Errorf("expected matches from older jobs first:\n%s", stdout)
+	}
+}
+
+func TestGrepStatusFilter(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.runAndWait("echo needle")
+	env.runAndWait("echo needle; exit 1")
+
+	stdout, _, code := env.run("--grep", "--failed", "needle")
+	assertExitCode(t, code, 0)
+	assertContains(t, stdout, "[2]")
+	assertNotContains(t, stdout, "[1]")
+}
+
+func TestGrepJSON(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.runAndWait("--name", "build", "echo compiling; echo 'error: oops'; exit 2")
+
+	stdout, _, code := env.run("--grep", "error", "--json")
+	assertExitCode(t, code, 0)
+
+	var matches []struct {
+		Job  tracker.Job `json:"job"`
+		Line int         `json:"line"`
+		Text string      `json:"text"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &matches); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	if len(matches) != 1 {
+		t.Fatalf("expected 1 match, got %d", len(matches))
+	}
+	m := matches[0]
+	if m.Job.ID != 1 || m.Job.Name != "build" || m.Job.ExitCode == nil || *m.Job.ExitCode != 2 {
+		t.Errorf("unexpected job metadata: %+v", m.Job)
+	}
+	if m.Line != 2 || m.Text != "error: oops" {
+		t.Errorf("unexpected match: line %d %q", m.Line, m.Text)
+	}
+}
+
+func TestGrepNoMatches(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.runAndWait("echo", "hello")
+
+	_, _, code := env.run("--grep", "goodbye")
+	assertExitCode(t, code, 1)
+
+	stdout, _, code := env.run("--grep", "goodbye", "--json")
+	assertExitCode(t, code, 1)
+	if strings.TrimSpace(stdout) != "[]" {
+		t.Errorf("expected an empty JSON array, got %q", stdout)
+	}
+}
+
+func TestGrepInvalidPattern(t *testing.T) {
+	env := newTestEnv(t)
+
+	_, _, code := env.run("--grep", "(unclosed")
+	assertExitCode(t, code, 1)
+}
+
+// =============================================================================
+// Backoff Tests
+// =============================================================================
+
+func TestRetryBackoffBanners(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.run("--retry=3", "--delay", "1", "--backoff", "exponential", "--max-delay", "1500ms", "false")
+	env.waitForJob(1, 10*time.Second)
+
+	stdout, _, _ := env.run("--logs", "--json")
+	assertContains(t, stdout, "Attempt 1 ruined (exit 1), trying again in 1s...")
+	assertContains(t, stdout, "Attempt 2 ruined (exit 1), trying again in 1.5s...")
+	assertContains(t, stdout, "All 3 attempts ruined")
+}
+
+func TestRetryJitter(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.run("--retry=2", "--delay", "1", "--jitter", "false")
+	env.waitForJob(1, 10*time.Second)
+
+	// Jitter waits between half and all of the delay
+	stdout, _, _ := env.run("--logs", "--json")
+	assertMatch(t, stdout, `Attempt 1 ruined \(exit 1\), trying again in (\d{3}(\.\d+)?ms|1s)\.\.\.`)
+}
+
+func TestRestartDelay(t *testing.T) {
+	env := newTestEnv(t)
+
+	// Fail the first run only, so the restart loop ends with the second
+	marker := filepath.Join(t.TempDir(), "ran")
+	env.run("--restart", "--delay", "0", "test -f "+marker+" || { touch "+marker+"; exit 3; }")
+	job := env.waitForJob(1, 5*time.Second)
+	if job.ExitCode == nil || *job.ExitCode != 0 {
+		t.Errorf("exit code = %v, want 0", job.ExitCode)
+	}
+
+	stdout, _, _ := env.run("--logs", "--json")
+	assertContains(t, stdout, "Failed with exit 3, restarting in 0s...")
+}
+
+func TestRestartDelayConfig(t *testing.T) {
+	env := newTestEnv(t)
+	env.writeConfig("restart_delay = 0\n")
+
+	marker := filepath.Join(t.TempDir(), "ran")
+	env.run("--restart", "--backoff", "linear", "test -f "+marker+" || { touch "+marker+"; exit 3; }")
+	env.waitForJob(1, 5*time.Second)
+
+	stdout, _, _ := env.run("--logs", "--json")
+	assertContains(t, stdout, "Failed with exit 3, restarting in 0s...")
+}
+
+func TestBackoffWithoutRetry(t *testing.T) {
+	env := newTestEnv(t)
+
+	for _, args := range [][]string{
+		{"--backoff", "linear", "echo", "test"},
+		{"--max-delay", "1m", "echo", "test"},
+		{"--jitter", "echo", "test"},
+	} {
+		_, stderr, code := env.run(args...)
+		assertExitCode(t, code, 1)
+		assertContains(t, stderr, "need --retry or --restart")
+	}
+}
+
+func TestInvalidBackoff(t *testing.T) {
+	env := newTestEnv(t)
+
+	_, stderr, code := env.run("--retry", "--backoff", "quadratic", "echo", "test")
+	assertExitCode(t, code, 1)
+	assertContains(t, stderr, "bj doesn't know the backoff 'quadratic'")
+}
+
+// =============================================================================
+// Attempt History Tests
+// =============================================================================
+
+// flakyCommand fails the first failures times it runs and succeeds after
+// that, printing which run it's on
+func flakyCommand(t *testing.T, failures int) string {
+	counter := filepath.Join(t.TempDir(), "runs")
+	return fmt.Sprintf("n=$(($(cat %s 2>/dev/null || echo 0) + 1)); echo $n > %s; echo run $n; [ $n -gt %d ]", counter, counter, failures)
+}
+
+func TestRetryAttempts(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.run("--retry=3", "--delay", "0", flakyCommand(t, 1))
+	job := env.waitForJob(1, 5*time.Second)
+
+	if len(job.Attempts) != 2 {
+		t.Fatalf("recorded %d attempts, want 2", len(job.Attempts))
+	}
+	for i, want := range []int{1, 0} {
+		a := job.Attempts[i]
+		if a.ExitCode == nil || *a.ExitCode != want {
+			t.Errorf("attempt %d exit code = %v, want %d", i+1, a.ExitCode, want)
+		}
+		if a.EndTime == nil || a.EndTime.Before(a.StartTime) {
+			t.Errorf("attempt %d has no end time after its start", i+1)
+		}
+	}
+	if job.Attempts[1].LogStart != job.Attempts[0].LogEnd {
+		t.Errorf("attempt 2 starts at %d, want %d", job.Attempts[1].LogStart, job.Attempts[0].LogEnd)
+	}
+
+	stdout, _, code := env.run("--logs", "1", "--attempt", "1", "--json")
+	assertExitCode(t, code, 0)
+	assertContains(t, stdout, `=== Attempt 1 of 3 ===\nrun 1\n=== Attempt 1 ruined`)
+	assertNotContains(t, stdout, "run 2")
+
+	stdout, _, _ = env.run("--logs", "1", "--attempt=2", "--json")
+	assertContains(t, stdout, `"content": "=== Attempt 2 of 3 ===\nrun 2\n"`)
+}
+
+func TestRestartAttempts(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.run("--restart", "--delay", "0", flakyCommand(t, 2))
+	job := env.waitForJob(1, 5*time.Second)
+
+	if len(job.Attempts) != 3 {
+		t.Fatalf("recorded %d attempts, want 3", len(job.Attempts))
+	}
+	stdout, _, _ := env.run("--logs", "1", "--attempt", "2", "--json")
+	assertContains(t, stdout, "run 2")
+	assertContains(t, stdout, "Failed with exit 1, restarting in 0s...")
+	assertNotContains(t, stdout, "run 1")
+	assertNotContains(t, stdout, "run 3")
+}
+
+func TestListAttemptsColumn(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.runAndWait("echo", "once")
+	stdout, _, _ := env.run("--list")
+	assertNotContains(t, stdout, "ATTEMPTS")
+
+	env.run("--retry=3", "--delay", "0", flakyCommand(t, 1))
+	env.waitForJob(2, 5*time.Second)
+
+	stdout, _, _ = env.run("--list")
+	assertContains(t, stdout, "ATTEMPTS")
+	assertMatch(t, stdout, `2\s+done\s+2/3\s`)
+}
+
+func TestLogsAttemptNotFound(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.runAndWait("echo", "once")
+	_, stderr, code := env.run("--logs", "1", "--attempt", "1")
+	assertExitCode(t, code, 1)
+	assertContains(t, stderr, "Job 1 has no attempt 1")
+
+	_, stderr, code = env.run("--logs", "1", "--attempt", "1", "--stderr")
+	assertExitCode(t, code, 1)
+	assertContains(t, stderr, "--attempt shows part of the combined log")
+}
+
+func TestLogsAttemptAfterRotation(t *testing.T) {
+	env := newTestEnv(t)
+
+	// Each run writes well over the size limit, so only the last ones survive
+	env.run("--retry=4", "--delay", "0", "--max-log-size", "100", "--max-log-files", "1",
+		"for i in 1 2 3 4; do echo 'some output to fill the log up'; done; "+flakyCommand(t, 3))
+	job := env.waitForJob(1, 5*time.Second)
+	if job.LogDropped == 0 {
+		t.Fatalf("expected rotation to delete part of the log")
+	}
+
+	stdout, _, code := env.run("--logs", "1", "--attempt", "4", "--json")
+	assertExitCode(t, code, 0)
+	assertContains(t, stdout, "run 4")
+	assertNotContains(t, stdout, "run 3")
+
+	_, stderr, code := env.run("--logs", "1", "--attempt", "1")
+	assertExitCode(t, code, 1)
+	assertContains(t, stderr, "Attempt 1 of job 1 has been rotated out of the log")
+}
+
+// =============================================================================
+// Restart Policy Tests
+// =============================================================================
+
+func TestRestartCrashloop(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.run("--restart", "--delay", "0", "--restart-limit", "2", "--restart-window", "1m", "false")
+	job := env.waitForJob(1, 5*time.Second)
+
+	if job.Status != tracker.StatusCrashloop {
+		t.Errorf("status = %q, want %q", job.Status, tracker.StatusCrashloop)
+	}
+	if job.ExitCode == nil || *job.ExitCode != 1 {
+		t.Errorf("exit code = %v, want 1", job.ExitCode)
+	}
+	if len(job.Attempts) != 3 {
+		t.Errorf("recorded %d attempts, want 3", len(job.Attempts))
+	}
+
+	stdout, _, _ := env.run("--logs", "1", "--json")
+	assertContains(t, stdout, "Exited 3 times within 1m0s, giving up")
+
+	stdout, _, _ = env.run("--list", "--failed")
+	assertContains(t, stdout, "crashloop")
+}
+
+func TestRestartAlways(t *testing.T) {
+	env := newTestEnv(t)
+
+	stdout, _, code := env.run("--restart=always", "--delay", "0", "--restart-limit", "1", "true")
+	assertExitCode(t, code, 0)
+	assertContains(t, stdout, "restarts whenever it exits")
+
+	job := env.waitForJob(1, 5*time.Second)
+	if job.Status != tracker.StatusCrashloop {
+		t.Errorf("status = %q, want %q", job.Status, tracker.StatusCrashloop)
+	}
+	if !job.Failed() {
+		t.Errorf("crashloop job with exit code %v should count as failed", job.ExitCode)
+	}
+
+	stdout, _, _ = env.run("--logs", "1", "--json")
+	assertContains(t, stdout, "Exited cleanly, restarting in 0s...")
+
+	stdout, _, _ = env.run("--list", "--failed")
+	assertContains(t, stdout, "crashloop")
+}
+
+func TestRestartLimitWithoutRestart(t *testing.T) {
+	env := newTestEnv(t)
+
+	_, stderr, code := env.run("--restart-limit", "3", "echo", "test")
+	assertExitCode(t, code, 1)
+	assertContains(t, stderr, "only make sense with --restart")
+
+	_, stderr, code = env.run("--restart", "--restart-window", "1m", "echo", "test")
+	assertExitCode(t, code, 1)
+	assertContains(t, stderr, "--restart-window needs a --restart-limit")
+}
+
+func TestInvalidRestartPolicy(t *testing.T) {
+	env := newTestEnv(t)
+
+	_, stderr, code := env.run("--restart=sometimes", "echo", "test")
+	assertExitCode(t, code, 1)
+	assertContains(t, stderr, "bj doesn't know the restart policy 'sometimes'")
+}
+
+func TestOkCodes(t *testing.T) {
+	env := newTestEnv(t)
+
+	stdout, _, code := env.run("--json", "--ok-codes", "0,3", "exit 3")
+	assertExitCode(t, code, 0)
+	assertContains(t, stdout, `"ok_codes": [`)
+
+	job := env.waitForJob(1, 5*time.Second)
+	if !job.Succeeded() {
+		t.Errorf("exit 3 with --ok-codes 0,3 should count as success")
+	}
+
+	// 0 isn't ok unless it's listed
+	env.run("--ok-codes", "1", "true")
+	env.waitForJob(2, 5*time.Second)
+
+	stdout, _, _ = env.run("--list", "--done")
+	assertContains(t, stdout, "done(3)")
+	assertNotContains(t, stdout, "exit(0)")
+
+	stdout, _, _ = env.run("--list", "--failed")
+	assertContains(t, stdout, "exit(0)")
+	assertNotContains(t, stdout, "done(3)")
+
+	_, _, code = env.run("--logs", "1", "--follow")
+	assertExitCode(t, code, 0)
+}
+
+func TestOkCodesRetry(t *testing.T) {
+	env := newTestEnv(t)
+
+	// A retry job stops at the first ok code instead of waiting for 0
+	env.run("--retry=3", "--delay", "0", "--ok-codes", "0,1", flakyCommand(t, 2))
+	job := env.waitForJob(1, 5*time.Second)
+
+	if len(job.Attempts) != 1 {
+		t.Errorf("recorded %d attempts, want 1", len(job.Attempts))
+	}
+	if job.ExitCode == nil || *job.ExitCode != 1 || !job.Succeeded() {
+		t.Errorf("exit code = %v, want a successful 1", job.ExitCode)
+	}
+}
+
+func TestFailIfOutput(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.run("--retry=2", "--delay", "0", "--fail-if-output", "^ERROR", "echo ERROR: disk full")
+	job := env.waitForJob(1, 5*time.Second)
+
+	if job.Status != tracker.StatusBadOutput {
+		t.Errorf("status = %q, want %q", job.Status, tracker.StatusBadOutput)
+	}
+	if len(job.Attempts) != 2 {
+		t.Errorf("recorded %d attempts, want 2", len(job.Attempts))
+	}
+
+	stdout, _, _ := env.run("--logs", "1", "--json")
+	assertContains(t, stdout, `Output matched \"^ERROR\", counting the run as ruined`)
+
+	stdout, _, _ = env.run("--list", "--failed")
+	assertContains(t, stdout, "bad-output")
+
+	// Output that doesn't match leaves a clean exit alone
+	env.run("--fail-if-output", "^ERROR", "echo no ERROR here")
+	job = env.waitForJob(2, 5*time.Second)
+	if !job.Succeeded() {
+		t.Errorf("job with no matching output should succeed, status %q", job.Status)
+	}
+}
+
+func TestInvalidOkCodes(t *testing.T) {
+	env := newTestEnv(t)
+
+	_, stderr, code := env.run("--ok-codes", "0,256", "true")
+	assertExitCode(t, code, 1)
+	assertContains(t, stderr, "exit codes from 0 to 255, not '256'")
+
+	_, stderr, code = env.run("--fail-if-output", "(", "true")
+	assertExitCode(t, code, 1)
+	assertContains(t, stderr, "can't make sense of that pattern")
+}
+
+// jobEnv returns a job's recorded environment with its values
+func (e *testEnv) jobEnv(id int) []string {
+	e.t.Helper()
+	stdout, _, code := e.run("--show", strconv.Itoa(id), "--json", "--reveal-env")
+	assertExitCode(e.t, code, 0)
+	var job tracker.Job
+	if err := json.Unmarshal([]byte(stdout), &job); err != nil {
+		e.t.Fatalf("failed to parse JSON: %v", err)
+	}
+	return job.Env
+}
+
+func TestEnvSnapshot(t *testing.T) {
+	env := newTestEnv(t)
+	env.writeConfig(`env_allowlist = ["BJ_TEST_*"]`)
+
+	env.vars = []string{"BJ_TEST_STAGE=first"}
+	env.run("echo stage=$BJ_TEST_STAGE; exit 1")
+	env.waitForJob(1, 5*time.Second)
+	if !slices.Contains(env.jobEnv(1), "BJ_TEST_STAGE=first") {
+		t.Errorf("job env doesn't include BJ_TEST_STAGE=first")
+	}
+
+	// Retrying from a different environment reruns with the original one
+	env.vars = []string{"BJ_TEST_STAGE=second"}
+	env.run("--retry=1", "--id", "1")
+	env.waitForJob(2, 5*time.Second)
+
+	stdout, _, _ := env.run("--logs", "2", "--json")
+	assertContains(t, stdout, "stage=first")
+}
+
+func TestEnvFlags(t *testing.T) {
+	env := newTestEnv(t)
+
+	envFile := filepath.Join(t.TempDir(), ".env")
+	os.WriteFile(envFile, []byte("# settings\nexport GREETING=\"hello there\"\nTARGET=file\n"), 0644)
+
+	env.vars = []string{"TARGET=inherited"}
+	env.run("--env-file", envFile, "--env", "TARGET=flag", "echo $GREETING $TARGET")
+	env.waitForJob(1, 5*time.Second)
+
+	stdout, _, _ := env.run("--logs", "1", "--json")
+	assertContains(t, stdout, "hello there flag")
+}
+
+func TestEnvAllowlist(t *testing.T) {
+	env := newTestEnv(t)
+	env.writeConfig(`env_allowlist = ["BJ_KEEP_*"]`)
+
+	env.vars = []string{"BJ_KEEP_ME=1", "BJ_DROP_ME=1"}
+	env.run("--env", "EXTRA=1", "true")
+	env.waitForJob(1, 5*time.Second)
+
+	jobEnv := env.jobEnv(1)
+	for _, want := range []string{"BJ_KEEP_ME=1", "EXTRA=1"} {
+		if !slices.Contains(jobEnv, want) {
+			t.Errorf("job env doesn't include %s", want)
+		}
+	}
+	if slices.Contains(jobEnv, "BJ_DROP_ME=1") {
+		t.Errorf("job env includes BJ_DROP_ME, which isn't allowlisted")
+	}
+}
+
+func TestEnvDefaultAllowlist(t *testing.T) {
+	env := newTestEnv(t)
+
+	// Without env_allowlist, secrets in the environment aren't recorded
+	env.vars = []string{"BJ_SECRET_TOKEN=hunter2"}
+	env.run("--env", "PORT=8080", "true")
+	env.waitForJob(1, 5*time.Second)
+
+	jobEnv := env.jobEnv(1)
+	if slices.Contains(jobEnv, "BJ_SECRET_TOKEN=hunter2") {
+		t.Errorf("job env includes BJ_SECRET_TOKEN, which isn't allowlisted by default")
+	}
+	for _, want := range []string{"PORT=8080", "PATH=" + os.Getenv("PATH")} {
+		if !slices.Contains(jobEnv, want) {
+			t.Errorf("job env doesn't include %s", want)
+		}
+	}
+}
+
+func TestEnvRedacted(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.run("--env", "API_KEY=s3cret", "true")
+	env.waitForJob(1, 5*time.Second)
+
+	// Every way of printing a job as JSON hides the values
+	for _, args := range [][]string{
+		{"--list", "--json"},
+		{"--list", "--format", "jsonl"},
+		{"--show", "1", "--json"},
+		{"--logs", "1", "--json"},
+	} {
+		stdout, _, code := env.run(args...)
+		assertExitCode(t, code, 0)
+		assertNotContains(t, stdout, "s3cret")
+		assertContains(t, stdout, "API_KEY=***")
+	}
+
+	stdout, _, _ := env.run("--list", "--json", "--reveal-env")
+	assertContains(t, stdout, "API_KEY=s3cret")
+
+	// The values are still there to rerun the job with
+	data, err := os.ReadFile(filepath.Join(env.configDir, "jobs.json"))
+	if err != nil {
+		t.Fatalf("failed to read jobs.json: %v", err)
+	}
+	assertContains(t, string(data), "API_KEY=s3cret")
+}
+
+func TestJobsFilePrivate(t *testing.T) {
+	env := newTestEnv(t)
+
+	// Files left readable by older versions are tightened too
+	env.writeJobsFile(nil)
+	env.runAndWait("true")
+
+	for _, name := range []string{"jobs.json", "jobs.lock"} {
+		info, err := os.Stat(filepath.Join(env.configDir, name))
+		if err != nil {
+			t.Fatalf("failed to stat %s: %v", name, err)
+		}
+		if perm := info.Mode().Perm(); perm != 0600 {
+			t.Errorf("%s mode = %o, want 600", name, perm)
+		}
+	}
+}
+
+func TestInvalidEnv(t *testing.T) {
+	env := newTestEnv(t)
+
+	_, stderr, code := env.run("--env", "NOVALUE", "true")
+	assertExitCode(t, code, 1)
+	assertContains(t, stderr, "bj needs --env as KEY=VALUE, not 'NOVALUE'")
+
+	_, stderr, code = env.run("--env-file", filepath.Join(t.TempDir(), "missing.env"), "true")
+	assertExitCode(t, code, 1)
+	assertContains(t, stderr, "couldn't read the env file")
+}
+
+func TestCwd(t *testing.T) {
+	env := newTestEnv(t)
+	dir := t.TempDir()
+
+	stdout, _, code := env.run("--json", "--cwd", dir, "pwd")
+	assertExitCode(t, code, 0)
+	assertContains(t, stdout, `"cwd": "`+dir+`"`)
+
+	job := env.waitForJob(1, 5*time.Second)
+	if job.PWD != dir {
+		t.Errorf("pwd = %q, want %q", job.PWD, dir)
+	}
+	stdout, _, _ = env.run("--logs", "1", "--json")
+	assertContains(t, stdout, dir)
+
+	// Retry and restart jobs take it too
+	env.run("--retry=1", "--cwd", dir, "true")
+	if job := env.waitForJob(2, 5*time.Second); job.PWD != dir {
+		t.Errorf("retry job pwd = %q, want %q", job.PWD, dir)
+	}
+}
+
+func TestInvalidCwd(t *testing.T) {
+	env := newTestEnv(t)
+
+	_, stderr, code := env.run("--cwd", filepath.Join(t.TempDir(), "missing"), "true")
+	assertExitCode(t, code, 1)
+	assertContains(t, stderr, "it isn't a directory")
+
+	_, stderr, code = env.run("--cwd")
+	assertExitCode(t, code, 1)
+	assertContains(t, stderr, "--cwd needs a directory")
+}
+
+func TestListHere(t *testing.T) {
+	env := newTestEnv(t)
+	cwd, _ := os.Getwd()
+
+	env.run("--cwd", filepath.Join(cwd, "testdata"), "echo below")
+	env.run("--cwd", t.TempDir(), "echo elsewhere")
+	env.waitForJob(1, 5*time.Second)
+	env.waitForJob(2, 5*time.Second)
+
+	stdout, _, _ := env.run("--list", "--here")
+	assertContains(t, stdout, "echo below")
+	assertNotContains(t, stdout, "echo elsewhere")
+
+	stdout, _, _ = env.run("--ids", "--here")
+	if strings.TrimSpace(stdout) != "1" {
+		t.Errorf("--ids --here = %q, want just job 1", stdout)
+	}
+}
+
+func TestListWatch(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.run("sleep 0.5")
+
+	// Not a terminal, so each redraw is a full copy of the table
+	cmd := exec.Command(env.bjPath, "--list", "--watch", "200ms")
+	cmd.Env = append(os.Environ(), "BJ_CONFIG_DIR="+env.configDir)
+	var stdout strings.Builder
+	cmd.Stdout = &stdout
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start bj: %v", err)
+	}
+	time.Sleep(1500 * time.Millisecond)
+	cmd.Process.Signal(os.Interrupt)
+	if err := cmd.Wait(); err != nil {
+		t.Fatalf("--watch didn't exit cleanly on interrupt: %v", err)
+	}
+
+	out := stdout.String()
+	if n := strings.Count(out, "Every 200ms"); n < 4 {
+		t.Errorf("got %d redraws in 1.5s, want at least 4:\n%s", n, out)
+	}
+	assertMatch(t, out, `1\s+running`)
+	// The job finishing is highlighted
+	assertContains(t, out, "\033[1m1   \033[33mdone")
+	assertNotContains(t, out, "\033[?25l") // no cursor control outside a terminal
+
+	// Without --list, --watch belongs to the command
+	env.run("echo", "--watch")
+	if job := env.waitForJob(2, 5*time.Second); job.Command != "echo --watch" {
+		t.Errorf("command = %q, want echo --watch", job.Command)
+	}
+}
+
+func TestListWatchInvalid(t *testing.T) {
+	env := newTestEnv(t)
+
+	_, stderr, code := env.run("--list", "--watch", "soon")
+	assertExitCode(t, code, 1)
+	assertContains(t, stderr, "--watch wants an interval like 2s, 500ms or 5, got 'soon'")
+
+	stdout, _, code := env.run("--list", "--watch", "--json")
+	assertExitCode(t, code, 1)
+	assertContains(t, stdout, "can't be combined with --json")
+}
+
+func TestTUINoTerminal(t *testing.T) {
+	env := newTestEnv(t)
+
+	// Tests run with pipes, not a terminal
+	_, stderr, code := env.run("--tui")
+	assertExitCode(t, code, 1)
+	assertContains(t, stderr, "--tui needs a terminal")
+}
+
+func TestTUIPruneOnlyVisibleJobs(t *testing.T) {
+	t.Setenv("BJ_CONFIG_DIR", t.TempDir())
+	tr, err := tracker.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, code := range []int{0, 1, 0} {
+		id, _ := tr.Add(tracker.Job{Command: "exit " + strconv.Itoa(code)})
+		tr.Complete(id, code)
+	}
+
+	// As if started with --failed, only the failed job is on screen
+	all, _ := tr.List()
+	ui := &tui{t: tr}
+	for _, j := range all {
+		if j.Failed() {
+			ui.jobs = append(ui.jobs, j)
+		}
+	}
+
+	ui.handle('P')
+	assertContains(t, ui.message, "1 finished job(s)")
+	ui.handle('y')
+
+	left, _ := tr.List()
+	if len(left) != 2 {
+		t.Fatalf("%d jobs left after pruning, want the 2 that weren't shown", len(left))
+	}
+	for _, j := range left {
+		if j.Failed() {
+			t.Errorf("job %d was shown and confirmed but not pruned", j.ID)
+		}
+	}
+}
+
+func TestResourceLimits(t *testing.T) {
+	env := newTestEnv(t)
+
+	stdout, _, code := env.run("--json", "--nice", "5", "--max-open-files", "64", "--max-cpu-time", "1m", "echo nice=$(nice) files=$(ulimit -n) cpu=$(ulimit -t); exit 1")
+	assertExitCode(t, code, 0)
+	assertContains(t, stdout, `"max_open_files": 64`)
+
+	job := env.waitForJob(1, 5*time.Second)
+	want := tracker.Limits{Nice: 5, MaxOpenFiles: 64, MaxCPUTime: time.Minute}
+	if job.Limits != want {
+		t.Errorf("limits = %+v, want %+v", job.Limits, want)
+	}
+	stdout, _, _ = env.run("--logs", "1", "--json")
+	assertContains(t, stdout, "nice=5 files=64 cpu=60")
+
+	// Retrying the job keeps its limits
+	env.run("--retry=1", "--id", "1")
+	if job := env.waitForJob(2, 5*time.Second); job.Limits != want {
+		t.Errorf("retried job limits = %+v, want %+v", job.Limits, want)
+	}
+}
+
+func TestResourceLimitsConfig(t *testing.T) {
+	env := newTestEnv(t)
+	env.writeConfig("max_open_files = 32\nnice = 3\n")
+
+	env.run("--nice", "7", "echo nice=$(nice) files=$(ulimit -n)")
+	env.waitForJob(1, 5*time.Second)
+
+	stdout, _, _ := env.run("--logs", "1", "--json")
+	assertContains(t, stdout, "nice=7 files=32")
+}
+
+func TestResourceUsage(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.run("--retry=2", "--delay", "0", flakyCommand(t, 1))
+	job := env.waitForJob(1, 5*time.Second)
+
+	if job.Usage == nil {
+		t.Fatal("no resource usage recorded")
+	}
+	if job.Usage.PeakRSS <= 0 || job.Usage.WallTime <= 0 {
+		t.Errorf("usage = %+v, want a peak RSS and wall time", *job.Usage)
+	}
+
+	stdout, _, _ := env.run("--list", "--wide")
+	assertMatch(t, stdout, `DURATION\s+MEM\s+CPU\s+COMMAND`)
+
+	stdout, _, _ = env.run("--list")
+	assertNotContains(t, stdout, "MEM")
+
+	stdout, _, _ = env.run("--show", "1")
+	assertContains(t, stdout, "Limits:     none")
+	assertMatch(t, stdout, `Peak RSS:   [0-9.]+[KMG]`)
+	assertMatch(t, stdout, `CPU time:   \S+ user, \S+ sys`)
+}
+
+func TestShow(t *testing.T) {
+	env := newTestEnv(t)
+
+	long := "echo one; echo two; echo three; echo four; echo five; exit 3"
+	env.run("--name", "counting", "--ok-codes", "0,3", long)
+	job := env.waitForJob(1, 5*time.Second)
+
+	stdout, _, code := env.run("--show", "counting", "--lines", "2")
+	assertExitCode(t, code, 0)
+	assertContains(t, stdout, "Command:    "+long)
+	assertContains(t, stdout, "Directory:  "+job.PWD)
+	assertContains(t, stdout, fmt.Sprintf("PID:        %d (gone)", job.PID))
+	assertContains(t, stdout, "Exit code:  3 (success, one of its --ok-codes)")
+	assertContains(t, stdout, "OK codes:   0, 3")
+	assertMatch(t, stdout, `Started:    \d{4}-\d\d-\d\d \d\d:\d\d:\d\d`)
+	assertContains(t, stdout, "Log:        "+job.LogFile+" (")
+	assertContains(t, stdout, "Last 2 lines of output:\n  four\n  five")
+	assertNotContains(t, stdout, "\n  three\n")
+
+	// Without a reference it's the latest job
+	env.run("definitely-not-a-command-bj-test")
+	env.waitForJob(2, 5*time.Second)
+	stdout, _, _ = env.run("--show", "--lines", "0")
+	assertContains(t, stdout, "Exit code:  127 (command not found)")
+	assertNotContains(t, stdout, "Last")
+
+	env.run("sleep", "30")
+	time.Sleep(200 * time.Millisecond)
+	job = env.getJob(3)
+	stdout, _, _ = env.run("--show", "3")
+	assertContains(t, stdout, fmt.Sprintf("PID:        %d (alive)", job.PID))
+	assertNotContains(t, stdout, "Exit code:")
+
+	env.run("--kill", "3")
+	stdout, _, _ = env.run("--show", "3")
+	assertContains(t, stdout, "(killed with SIGTERM)")
+}
+
+func TestShowJSON(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.run("echo one; echo two; echo three")
+	env.waitForJob(1, 5*time.Second)
+
+	stdout, _, code := env.run("--show", "--json", "--lines=2")
+	assertExitCode(t, code, 0)
+
+	var result struct {
+		ID          int      `json:"id"`
+		Command     string   `json:"cmd"`
+		Status      string   `json:"status"`
+		Alive       bool     `json:"alive"`
+		LogSize     int64    `json:"log_size"`
+		ExitMeaning string   `json:"exit_meaning"`
+		Tail        []string `json:"tail"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	if result.ID != 1 || result.Command != "echo one; echo two; echo three" {
+		t.Errorf("got job %d %q, want job 1 with its full command", result.ID, result.Command)
+	}
+	if result.Status != "done" || result.Alive || result.ExitMeaning != "success" {
+		t.Errorf("status = %q, alive = %v, meaning = %q, want a finished success", result.Status, result.Alive, result.ExitMeaning)
+	}
+	if result.LogSize != int64(len("one\ntwo\nthree\n")) {
+		t.Errorf("log_size = %d, want %d", result.LogSize, len("one\ntwo\nthree\n"))
+	}
+	if !slices.Equal(result.Tail, []string{"two", "three"}) {
+		t.Errorf("tail = %q, want the last 2 lines", result.Tail)
+	}
+}
+
+func TestShowNoJobs(t *testing.T) {
+	env := newTestEnv(t)
+
+	stdout, _, code := env.run("--show")
+	assertExitCode(t, code, 0)
+	assertContains(t, stdout, "bj hasn't done anything yet")
+
+	stdout, _, code = env.run("--show", "--json")
+	assertExitCode(t, code, 1)
+	assertContains(t, stdout, `"error": "bj hasn't done anything yet"`)
+
+	stdout, _, code = env.run("--logs", "--json")
+	assertExitCode(t, code, 1)
+	assertContains(t, stdout, `"error": "bj hasn't done anything yet"`)
+}
+
+func TestShowNSFW(t *testing.T) {
+	env := newTestEnv(t)
+	env.writeConfig("nsfw = true\n")
+
+	env.run("echo hi")
+	env.waitForJob(1, 5*time.Second)
+
+	stdout, _, code := env.run("--show")
+	assertExitCode(t, code, 0)
+	assertMatch(t, stdout, `Lasted:\s+\S+`)
+	assertContains(t, stdout, "The last 1 lines of pillow talk:\n  hi")
+	assertNotContains(t, stdout, "Last 1 lines of output")
+}
+
+func TestInvalidLimits(t *testing.T) {
+	env := newTestEnv(t)
+
+	_, stderr, code := env.run("--nice", "20", "true")
+	assertExitCode(t, code, 1)
+	assertContains(t, stderr, "niceness from -20 (greedy) to 19 (polite), not '20'")
+
+	_, stderr, code = env.run("--ionice", "fast", "true")
+	assertExitCode(t, code, 1)
+	assertContains(t, stderr, "doesn't know the I/O class 'fast'")
+
+	_, stderr, code = env.run("--max-memory", "lots", "true")
+	assertExitCode(t, code, 1)
+	assertContains(t, stderr, "the size 'lots'")
+}
+
+func TestListFormat(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.run("--name", "greet", "echo", "hi")
+	env.waitForJob(1, 5*time.Second)
+	env.run("exit", "3")
+	env.waitForJob(2, 5*time.Second)
+
+	stdout, _, code := env.run("--list", "--format", `{{.ID}}\t{{.Name}}\t{{status .}}\t{{duration .}}\t{{ago .StartTime}}`)
+	assertExitCode(t, code, 0)
+	if stdout != "2\t\texit(3)\t0s\tjust now\n1\tgreet\tdone\t0s\tjust now\n" {
+		t.Errorf("template output = %q", stdout)
+	}
+
+	header := "id,name,status,exit_code,start_time,end_time,duration_secs,pwd,command"
+	stdout, _, _ = env.run("--list", "--format=csv")
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 3 || lines[0] != header {
+		t.Fatalf("expected a header and 2 rows, got %q", stdout)
+	}
+	assertMatch(t, lines[2], `^1,greet,done,0,\d{4}-\d\d-\d\dT[^,]+,\d{4}-[^,]+,\d+\.\d{3},[^,]+,echo hi$`)
+
+	stdout, _, _ = env.run("--list", "--format", "tsv", "--failed")
+	lines = strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected a header and 1 row, got %q", stdout)
+	}
+	assertMatch(t, lines[1], "^2\t\texit\\(3\\)\t3\t")
+
+	stdout, _, _ = env.run("--list", "--format", "jsonl")
+	lines = strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", stdout)
+	}
+	var job tracker.Job
+	if err := json.Unmarshal([]byte(lines[1]), &job); err != nil {
+		t.Fatalf("invalid jsonl line %q: %v", lines[1], err)
+	}
+	if job.Name != "greet" {
+		t.Errorf("expected job greet on the last line, got %q", lines[1])
+	}
+
+	stdout, _, _ = env.run("--list", "--format", "wide")
+	assertContains(t, stdout, "MEM")
+
+	// Nothing to list still gets a header
+	stdout, _, _ = env.run("--list", "--running", "--format", "csv")
+	if stdout != header+"\n" {
+		t.Errorf("empty csv output = %q", stdout)
+	}
+}
+
+func TestShowFormat(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.run("--name", "greet", "echo", "hi")
+	job := env.waitForJob(1, 5*time.Second)
+
+	stdout, _, code := env.run("--show", "greet", "--format", "{{.PWD}} {{status .}}")
+	assertExitCode(t, code, 0)
+	if stdout != job.PWD+" done\n" {
+		t.Errorf("show output = %q", stdout)
+	}
+}
+
+func TestIDsFormat(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.run("--name", "greet", "echo", "hi")
+	env.waitForJob(1, 5*time.Second)
+	env.run("false")
+	env.waitForJob(2, 5*time.Second)
+
+	stdout, _, code := env.run("--ids", "--format", "{{.ID}}:{{.Command}}")
+	assertExitCode(t, code, 0)
+	if stdout != "2:false\n1:echo hi\n" {
+		t.Errorf("ids output = %q", stdout)
+	}
+
+	stdout, _, _ = env.run("--ids", "--failed", "--format", "{{.ID}}")
+	if stdout != "2\n" {
+		t.Errorf("failed ids output = %q", stdout)
+	}
+}
+
+func TestInvalidFormat(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.run("true")
+	env.waitForJob(1, 5*time.Second)
+
+	_, stderr, code := env.run("--list", "--format", "{{.ID")
+	assertExitCode(t, code, 1)
+	assertContains(t, stderr, "Invalid --format template")
+
+	_, stderr, code = env.run("--list", "--format", "{{.Nope}}")
+	assertExitCode(t, code, 1)
+	assertContains(t, stderr, "Failed to format jobs")
+
+	_, stderr, code = env.run("--list", "--format")
+	assertExitCode(t, code, 1)
+	assertContains(t, stderr, "--format needs a value")
+
+	stdout, _, code := env.run("--list", "--format", "csv", "--json")
+	assertExitCode(t, code, 1)
+	assertContains(t, stdout, "--format and --json both pick an output format")
+}
+
+// finishedJob is a job for writeJobsFile that started ago and ran for took
+func finishedJob(id int, command, pwd string, ago, took time.Duration, exitCode int) tracker.Job {
+	start := time.Now().Add(-ago)
+	end := start.Add(took)
+	return tracker.Job{
+		ID:        id,
+		Command:   command,
+		PWD:       pwd,
+		StartTime: start,
+		EndTime:   &end,
+		ExitCode:  &exitCode,
+		LogFile:   filepath.Join(os.TempDir(), fmt.Sprintf("bj-test-missing-%d.log", id)),
+	}
+}
+
+// sortingJobs are jobs for the --sort, --limit and filter tests
+func sortingJobs() []tracker.Job {
+	running := tracker.Job{
+		ID:        4,
+		Command:   "make watch",
+		PWD:       "/src/web",
+		StartTime: time.Now().Add(-10 * time.Minute),
+		PID:       os.Getpid(),
+	}
+	return []tracker.Job{
+		finishedJob(1, "make build", "/src/api", 3*time.Hour, 5*time.Minute, 0),
+		finishedJob(2, "npm test", "/src/web", 90*time.Minute, time.Minute, 1),
+		finishedJob(3, "make test", "/src/api/cmd", 30*time.Minute, 20*time.Minute, 1),
+		running,
+		finishedJob(5, "go vet", "/src/web", 5*time.Minute, time.Second, 2),
+	}
+}
+
+func TestListSort(t *testing.T) {
+	env := newTestEnv(t)
+	env.writeJobsFile(sortingJobs())
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{}, "5 4 3 2 1"},
+		{[]string{"--sort", "start"}, "5 4 3 2 1"},
+		{[]string{"--sort", "duration"}, "3 4 1 2 5"},
+		{[]string{"--sort", "id"}, "1 2 3 4 5"},
+		{[]string{"--sort=status"}, "4 5 3 2 1"},
+		{[]string{"--reverse"}, "1 2 3 4 5"},
+		{[]string{"--sort", "id", "--reverse", "--limit", "2"}, "5 4"},
+		{[]string{"--limit=3"}, "5 4 3"},
+	}
+	for _, tt := range tests {
+		for _, cmd := range []string{"--list", "--ids"} {
+			args := append([]string{cmd, "--format", "{{.ID}}"}, tt.args...)
+			stdout, _, code := env.run(args...)
+			assertExitCode(t, code, 0)
+			if got := strings.Join(strings.Fields(stdout), " "); got != tt.want {
+				t.Errorf("bj %v = %q, want %q", args, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestListJobFilters(t *testing.T) {
+	env := newTestEnv(t)
+	env.writeJobsFile(sortingJobs())
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--since", "1h"}, "5 4 3"},
+		{[]string{"--cmd", "make"}, "4 3 1"},
+		{[]string{"--exit-code", "1"}, "3 2"},
+		{[]string{"--exit-code=0"}, "1"},
+		{[]string{"--dir", "/src/api"}, "3 1"},
+		{[]string{"--dir", "/src/api/"}, "3 1"},
+		{[]string{"--failed", "--cmd", "make"}, "3"},
+		{[]string{"--since", "1h", "--sort", "duration", "--limit", "1"}, "3"},
+	}
+	for _, tt := range tests {
+		for _, cmd := range []string{"--list", "--ids"} {
+			args := append([]string{cmd, "--format", "{{.ID}}"}, tt.args...)
+			stdout, _, code := env.run(args...)
+			assertExitCode(t, code, 0)
+			if got := strings.Join(strings.Fields(stdout), " "); got != tt.want {
+				t.Errorf("bj %v = %q, want %q", args, got, tt.want)
+			}
+		}
+	}
+
+	stdout, _, _ := env.run("--list", "--cmd", "nothing-like-this")
+	assertContains(t, stdout, "No jobs match your criteria")
+}
+
+func TestListNegativeExitCode(t *testing.T) {
+	env := newTestEnv(t)
+	killed := finishedJob(2, "sleep 60", "/src", time.Hour, time.Minute, -15)
+	killed.Signal = "SIGTERM"
+	env.writeJobsFile([]tracker.Job{
+		finishedJob(1, "make", "/src", 2*time.Hour, time.Minute, tracker.ExitSkipped),
+		killed,
+		finishedJob(3, "make", "/src", 30*time.Minute, time.Minute, 15),
+	})
+
+	// Killed and lost jobs are recorded with negative codes
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--exit-code", "-15"}, "2"},
+		{[]string{"--exit-code=-1"}, "1"},
+		{[]string{"--exit-code", "15"}, "3"},
+	}
+	for _, tt := range tests {
+		args := append([]string{"--ids"}, tt.args...)
+		stdout, _, code := env.run(args...)
+		assertExitCode(t, code, 0)
+		if got := strings.TrimSpace(stdout); got != tt.want {
+			t.Errorf("bj %v = %q, want %q", args, got, tt.want)
+		}
+	}
+}
+
+func TestPruneSelected(t *testing.T) {
+	env := newTestEnv(t)
+	env.writeJobsFile(sortingJobs())
+
+	// Running jobs are never pruned, even when they match
+	stdout, _, code := env.run("--prune", "--cmd", "make", "--json")
+	assertExitCode(t, code, 0)
+	assertContains(t, stdout, `"pruned": 2`)
+	stdout, _, _ = env.run("--ids")
+	if got := strings.Join(strings.Fields(stdout), " "); got != "5 4 2" {
+		t.Errorf("after pruning make jobs, ids = %q", got)
+	}
+
+	// --limit counts finished jobs only
+	env.writeJobsFile(sortingJobs())
+	env.run("--prune", "--sort", "start", "--reverse", "--limit", "2")
+	stdout, _, _ = env.run("--ids")
+	if got := strings.Join(strings.Fields(stdout), " "); got != "5 4 3" {
+		t.Errorf("after pruning the 2 oldest, ids = %q", got)
+	}
+
+	env.writeJobsFile(sortingJobs())
+	env.run("--prune", "--failed", "--since", "1h")
+	stdout, _, _ = env.run("--ids")
+	if got := strings.Join(strings.Fields(stdout), " "); got != "4 2 1" {
+		t.Errorf("after pruning recent failures, ids = %q", got)
+	}
+
+	stdout, _, _ = env.run("--prune", "--exit-code", "7")
+	assertContains(t, stdout, "Nothing to clean up")
+}
+
+func TestInvalidListFlags(t *testing.T) {
+	env := newTestEnv(t)
+
+	_, stderr, code := env.run("--list", "--sort", "name")
+	assertExitCode(t, code, 1)
+	assertContains(t, stderr, "--sort can't order jobs by 'name'")
+
+	_, stderr, code = env.run("--ids", "--limit", "0")
+	assertExitCode(t, code, 1)
+	assertContains(t, stderr, "--limit wants a positive number of jobs, got '0'")
+
+	_, stderr, code = env.run("--prune", "--exit-code", "x")
+	assertExitCode(t, code, 1)
+	assertContains(t, stderr, "not 'x'")
+
+	_, stderr, code = env.run("--list", "--cmd")
+	assertExitCode(t, code, 1)
+	assertContains(t, stderr, "--cmd needs some text")
+}
@@ -0,0 +1,582 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/metruzanca/bj/internal/config"
+	"github.com/metruzanca/bj/internal/locales"
+	"github.com/metruzanca/bj/internal/runner"
+	"github.com/metruzanca/bj/internal/term"
+	"github.com/metruzanca/bj/internal/tracker"
+)
+
+// tuiRedrawInterval is how often the TUI redraws when nothing else happens,
+// to keep durations and the selected job's log tail current
+const tuiRedrawInterval = 250 * time.Millisecond
+
+// tuiReloadInterval is how often the TUI reloads the job list even when
+// jobs.json hasn't changed, collecting jobs that died without reporting back
+const tuiReloadInterval = time.Second
+
+// tuiTailBytes is how much of the end of the selected job's live log the TUI
+// reads for its log pane before falling back to reading the whole log
+const tuiTailBytes = 64 << 10
+
+// Control characters read from the terminal in raw mode
+const (
+	keyCtrlC = 3
+	keyEnter = '\r'
+	keyEsc   = 27
+)
+
+// Keys sent as escape sequences, mapped to codes above the byte range
+const (
+	keyUp = 256 + iota
+	keyDown
+	keyPageUp
+	keyPageDown
+	keyHome
+	keyEnd
+)
+
+// tui is the state of the full-screen job manager started by bj --tui
+type tui struct {
+	cfg   *config.Config
+	t     *tracker.Tracker
+	state *term.State // the terminal's settings before the TUI took it over
+
+	jobs     []tracker.Job
+	selected int // ID of the highlighted job (0 = the first one)
+	offset   int // index of the first job shown in the list pane
+
+	width, height int
+	loadedAt      time.Time // when the job list was last reloaded
+	modTime       time.Time // jobs.json's modification time as of that reload
+
+	message string        // result of the last action, shown in the footer
+	confirm func() string // action waiting for a y to confirm, if any
+	results chan string   // messages from actions running in the background
+	tail    []string      // last lines of the selected job's log
+	tailKey string        // which log (and how much of it) tail was read from
+	screen  []byte        // what was last drawn, so an unchanged screen isn't sent again
+}
+
+// runTUI takes over the terminal with a job list, the selected job's log tail
+// and keys to act on jobs, until the user quits
+func runTUI(cfg *config.Config, t *tracker.Tracker) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		exitWithError(locales.Msg("err.tui_no_terminal"))
+	}
+
+	ui := &tui{cfg: cfg, t: t, results: make(chan string, 1)}
+	if err := ui.enter(); err != nil {
+		exitWithError(locales.Msg("err.tui_failed", err))
+	}
+	err := ui.loop()
+	ui.leave()
+	if err != nil {
+		exitWithError(locales.Msg("err.tui_failed", err))
+	}
+}
+
+// enter puts the terminal into raw mode on the alternate screen
+func (ui *tui) enter() error {
+	state, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return err
+	}
+	ui.state = state
+	fmt.Print("\033[?1049h\033[?25l") // alternate screen, hidden cursor
+	return nil
+}
+
+// leave gives the terminal back the way it was found
+func (ui *tui) leave() {
+	fmt.Print("\033[?25h\033[?1049l")
+	term.Restore(int(os.Stdin.Fd()), ui.state)
+}
+
+// loop reads keys and redraws until the user quits
+func (ui *tui) loop() error {
+	buf := make([]byte, 64)
+	var drawnAt time.Time
+	for {
+		if err := ui.refresh(); err != nil {
+			return err
+		}
+		if time.Since(drawnAt) >= tuiRedrawInterval {
+			ui.draw()
+			drawnAt = time.Now()
+		}
+
+		// Returns empty-handed after term.ReadTimeout without a key
+		n, err := os.Stdin.Read(buf)
+		if err != nil && err != io.EOF {
+			return err
+		}
+		for _, key := range parseKeys(buf[:n]) {
+			if !ui.handle(key) {
+				return nil
+			}
+			drawnAt = time.Time{}
+		}
+
+		select {
+		case msg := <-ui.results:
+			ui.message = msg
+			ui.loadedAt = time.Time{}
+			drawnAt = time.Time{}
+		default:
+		}
+	}
+}
+
+// refresh reloads the job list when jobs.json changes (or every
+// tuiReloadInterval regardless) and picks up the terminal's size
+func (ui *tui) refresh() error {
+	width, height, err := term.Size(int(os.Stdout.Fd()))
+	if err != nil {
+		return err
+	}
+	ui.width, ui.height = width, height
+
+	modTime := ui.t.ModTime()
+	if modTime.Equal(ui.modTime) && time.Since(ui.loadedAt) < tuiReloadInterval {
+		return nil
+	}
+
+	// Jobs whose process died without reporting back would show as running forever
+	if count, _ := ui.t.GarbageCollect(); count > 0 {
+		runner.New(ui.cfg, ui.t).PromoteAll()
+	}
+	jobs, err := ui.t.List()
+	if err != nil {
+		return err
+	}
+	ui.jobs = filterJobs(jobs)
+	ui.modTime = ui.t.ModTime()
+	ui.loadedAt = time.Now()
+	return nil
+}
+
+// current returns the highlighted job and its index, or nil if there are no jobs
+func (ui *tui) current() (*tracker.Job, int) {
+	if len(ui.jobs) == 0 {
+		return nil, 0
+	}
+	for i := range ui.jobs {
+		if ui.jobs[i].ID == ui.selected {
+			return &ui.jobs[i], i
+		}
+	}
+	return &ui.jobs[0], 0
+}
+
+// move highlights the job delta rows away from the current one
+func (ui *tui) move(delta int) {
+	if len(ui.jobs) == 0 {
+		return
+	}
+	_, i := ui.current()
+	i = max(0, min(len(ui.jobs)-1, i+delta))
+	ui.selected = ui.jobs[i].ID
+}
+
+// handle acts on a key, returning false when it's time to quit
+func (ui *tui) handle(key int) bool {
+	// A pending confirmation takes the next key, y runs it and anything else cancels
+	if ui.confirm != nil {
+		action := ui.confirm
+		ui.confirm = nil
+		ui.message = ""
+		if key == 'y' || key == 'Y' {
+			ui.message = action()
+		}
+		return true
+	}
+
+	ui.message = ""
+	job, _ := ui.current()
+	switch key {
+	case 'q', keyCtrlC:
+		return false
+	case keyUp, 'k':
+		ui.move(-1)
+	case keyDown, 'j':
+		ui.move(1)
+	case keyPageUp:
+		ui.move(-ui.listRows())
+	case keyPageDown:
+		ui.move(ui.listRows())
+	case keyHome, 'g':
+		ui.move(-len(ui.jobs))
+	case keyEnd, 'G':
+		ui.move(len(ui.jobs))
+	case keyEnter, 'v':
+		if job != nil {
+			ui.message = ui.view(job)
+		}
+	case 'x':
+		if job == nil {
+			break
+		}
+		if job.ExitCode != nil {
+			ui.message = locales.Msg("err.kill_failed", tracker.ErrJobFinished)
+			break
+		}
+		id, command := job.ID, job.Command
+		ui.message = locales.Msg("tui.confirm_kill", id, truncateCommand(command))
+		ui.confirm = func() string {
+			go func() { ui.results <- ui.kill(id) }()
+			return locales.Msg("tui.killing", id)
+		}
+	case 'r':
+		if job != nil {
+			ui.message = ui.retry(job)
+		}
+	case 'p':
+		if job != nil {
+			ui.message = ui.pause(job)
+		}
+	case 'P':
+		// Only the jobs on screen, the filters may be hiding others
+		var finished []int
+		for _, j := range ui.jobs {
+			if j.ExitCode != nil {
+				finished = append(finished, j.ID)
+			}
+		}
+		if len(finished) == 0 {
+			ui.message = locales.Msg("prune.nothing")
+			break
+		}
+		ui.message = locales.Msg("tui.confirm_prune", len(finished))
+		ui.confirm = func() string { return ui.prune(finished) }
+	}
+	ui.loadedAt = time.Time{} // whatever happened, show the latest
+	return true
+}
+
+// kill stops a job the way bj --kill does, handing its queue slot on
+func (ui *tui) kill(id int) string {
+	job, err := ui.t.Kill(id, killSignal, killGrace)
+	if err != nil {
+		return locales.Msg("err.kill_failed", err)
+	}
+	if job.Queue != "" {
+		if err := runner.New(ui.cfg, ui.t).Promote(job.Queue); err != nil {
+			return locales.Msg("err.kill_failed", err)
+		}
+	}
+	if job.Status == tracker.StatusCancelled {
+		return locales.Msg("job.cancelled", job.ID, job.Command)
+	}
+	return locales.Msg("job.killed", job.ID, job.Command)
+}
+
+// retry gives a failed job one more go the way bj --retry=1 --id does, and
+// highlights the new job
+func (ui *tui) retry(job *tracker.Job) string {
+	if job.ExitCode == nil {
+		return locales.Msg("err.job_still_running", job.ID)
+	}
+	if job.Succeeded() {
+		return locales.Msg("err.job_already_succeeded", job.ID)
+	}
+	newJobID, _, err := rerunJob(ui.cfg, ui.t, job, 1, 1) // --retry's default delay
+	if err != nil {
+		return locales.Msg("err.retry_start_failed", err)
+	}
+	ui.selected = newJobID
+	return locales.Msg("job.retry_one_existing", newJobID, job.Command)
+}
+
+// pause pauses a running job, or resumes it if it's paused already
+func (ui *tui) pause(job *tracker.Job) string {
+	if job.Paused() {
+		if _, err := ui.t.Resume(job.ID); err != nil {
+			return locales.Msg("err.resume_failed", err)
+		}
+		return locales.Msg("job.resumed", job.ID, job.Command)
+	}
+	if _, err := ui.t.Pause(job.ID); err != nil {
+		return locales.Msg("err.pause_failed", err)
+	}
+	return locales.Msg("job.paused", job.ID, job.Command)
+}
+
+// prune clears the given finished jobs the way bj --prune does
+func (ui *tui) prune(ids []int) string {
+	count, err := ui.t.PruneIDs(ids)
+	if err != nil {
+		return locales.Msg("err.prune_failed", err)
+	}
+	return locales.Msg("prune.success", count)
+}
+
+// view hands the terminal over to the configured viewer showing the job's
+// whole log, stitched together the way bj --logs does
+func (ui *tui) view(job *tracker.Job) string {
+	tmp, err := os.CreateTemp("", fmt.Sprintf("bj-%d-*.log", job.ID))
+	if err != nil {
+		return locales.Msg("err.logs_read_failed", err)
+	}
+	defer os.Remove(tmp.Name())
+	err = copyLog(tmp, job, tracker.LogSegments(job.LogFile))
+	tmp.Close()
+	if err != nil {
+		return locales.Msg("err.logs_read_failed", err)
+	}
+
+	ui.leave()
+	defer func() {
+		ui.enter()
+		ui.screen = nil // the viewer drew over it
+	}()
+
+	cmd := exec.Command(ui.cfg.Viewer, tmp.Name())
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return locales.Msg("err.viewer_failed", err)
+	}
+	return ""
+}
+
+// listRows is how many job rows fit in the list pane: up to half the screen,
+// leaving the rest to the log pane
+func (ui *tui) listRows() int {
+	// Title, table header, separator and footer take a line each
+	space := ui.height - 4
+	return max(1, min(len(ui.jobs), space/2))
+}
+
+// draw renders the whole screen: a title, the job table with the selected
+// job highlighted, the selected job's log tail and a footer
+func (ui *tui) draw() {
+	var lines []string
+
+	running := 0
+	for _, job := range ui.jobs {
+		if job.ExitCode == nil {
+			running++
+		}
+	}
+	lines = append(lines, colorBold+locales.Msg("tui.title", len(ui.jobs), running)+colorReset)
+
+	job, index := ui.current()
+	if job == nil {
+		lines = append(lines, emptyListMessage())
+	} else {
+		var table bytes.Buffer
+		printJobTable(&table, ui.jobs, nil)
+		rows := strings.Split(strings.TrimSuffix(table.String(), "\n"), "\n")
+		lines = append(lines, rows[0])
+
+		// Scroll just enough to keep the selected job in view
+		visible := ui.listRows()
+		ui.offset = max(0, min(ui.offset, index))
+		if index >= ui.offset+visible {
+			ui.offset = index - visible + 1
+		}
+		for i := ui.offset; i < min(len(ui.jobs), ui.offset+visible); i++ {
+			row := rows[i+1]
+			if i == index {
+				row = "\033[7m" + padRight(plainText(row), ui.width, ' ') + colorReset
+			}
+			lines = append(lines, row)
+		}
+	}
+
+	// The separator names the job whose log is below it
+	sep := "── "
+	if job != nil {
+		sep += fmt.Sprintf("#%d %s ", job.ID, plainText(job.Command))
+	}
+	lines = append(lines, colorDim+padRight(sep, ui.width, '─')+colorReset)
+
+	logRows := ui.height - len(lines) - 1
+	if job != nil && logRows > 0 {
+		for _, line := range ui.logTail(job, logRows) {
+			lines = append(lines, plainText(line))
+		}
+	}
+	for len(lines) < ui.height-1 {
+		lines = append(lines, "")
+	}
+
+	footer := ui.message
+	if footer == "" {
+		footer = locales.Msg("tui.keys")
+	}
+	lines = append(lines, colorDim+footer+colorReset)
+
+	var screen bytes.Buffer
+	for i, line := range lines[:min(len(lines), ui.height)] {
+		fmt.Fprintf(&screen, "\033[%d;1H%s\033[K", i+1, fitWidth(line, ui.width))
+	}
+	if !bytes.Equal(screen.Bytes(), ui.screen) {
+		os.Stdout.Write(screen.Bytes())
+		ui.screen = screen.Bytes()
+	}
+}
+
+// logTail returns the last n lines of the job's log, reading only the end of
+// the live log when that has enough of them
+func (ui *tui) logTail(job *tracker.Job, n int) []string {
+	segments := tracker.LogSegments(job.LogFile)
+	if len(segments) == 0 {
+		return nil
+	}
+	live := segments[len(segments)-1]
+	info, err := os.Stat(live)
+	if err != nil {
+		return nil
+	}
+
+	// Only read the log again once it has changed
+	key := fmt.Sprintf("%s:%d:%d:%d", live, info.Size(), len(segments), n)
+	if key == ui.tailKey {
+		return ui.tail
+	}
+	ui.tailKey = key
+
+	if !strings.HasSuffix(live, ".gz") {
+		if lines, complete := tailFile(live, info.Size(), n); complete || len(segments) == 1 {
+			ui.tail = lines
+			return ui.tail
+		}
+	}
+	ui.tail = logTail(job, n)
+	return ui.tail
+}
+
+// tailFile returns up to the last n lines of a file by reading at most
+// tuiTailBytes from its end, and whether those are all the lines asked for
+// (or all the file has)
+func tailFile(path string, size int64, n int) ([]string, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, false
+	}
+	defer f.Close()
+
+	start := max(0, size-tuiTailBytes)
+	data := make([]byte, size-start)
+	if _, err := f.ReadAt(data, start); err != nil && err != io.EOF {
+		return nil, false
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if start > 0 {
+		lines = lines[1:] // the first line was cut off
+	}
+	if len(data) == 0 {
+		lines = nil
+	}
+	if len(lines) > n {
+		return lines[len(lines)-n:], true
+	}
+	return lines, start == 0
+}
+
+// parseKeys splits what a read from the terminal returned into keys, turning
+// the escape sequences for arrows and paging into single codes
+func parseKeys(b []byte) []int {
+	sequences := map[string]int{
+		"[A": keyUp, "OA": keyUp,
+		"[B": keyDown, "OB": keyDown,
+		"[5~": keyPageUp, "[6~": keyPageDown,
+		"[H": keyHome, "OH": keyHome, "[1~": keyHome,
+		"[F": keyEnd, "OF": keyEnd, "[4~": keyEnd,
+	}
+
+	var keys []int
+	for i := 0; i < len(b); i++ {
+		if b[i] != keyEsc {
+			keys = append(keys, int(b[i]))
+			continue
+		}
+		matched := false
+		for seq, key := range sequences {
+			if bytes.HasPrefix(b[i+1:], []byte(seq)) {
+				keys = append(keys, key)
+				i += len(seq)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			keys = append(keys, keyEsc)
+		}
+	}
+	return keys
+}
+
+// plainText strips ANSI escape sequences and control characters from s, and
+// expands tabs, so it takes up exactly as many columns as it has runes
+func plainText(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == keyEsc && i+1 < len(s) && s[i+1] == '[':
+			// Skip to the sequence's final byte
+			for i += 2; i < len(s) && (s[i] < 0x40 || s[i] > 0x7e); i++ {
+			}
+		case c == '\t':
+			b.WriteString("    ")
+		case c < ' ' || c == 0x7f:
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, b.String())
+}
+
+// fitWidth cuts s down to width columns, not counting the ANSI escape
+// sequences in it, resetting colors if it had to cut
+func fitWidth(s string, width int) string {
+	var b strings.Builder
+	cols := 0
+	for i := 0; i < len(s); {
+		if s[i] == keyEsc && i+1 < len(s) && s[i+1] == '[' {
+			j := i + 2
+			for j < len(s) && (s[j] < 0x40 || s[j] > 0x7e) {
+				j++
+			}
+			b.WriteString(s[i:min(j+1, len(s))])
+			i = j + 1
+			continue
+		}
+		if cols == width {
+			b.WriteString(colorReset)
+			break
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		b.WriteString(s[i : i+size])
+		cols++
+		i += size
+	}
+	return b.String()
+}
+
+// padRight pads s with fill to width columns
+func padRight(s string, width int, fill rune) string {
+	if n := width - utf8.RuneCountInString(s); n > 0 {
+		s += strings.Repeat(string(fill), n)
+	}
+	return s
+}